@@ -103,6 +103,17 @@ func (b *binder) MustBindWithDecoder(i any, c Context, valueDecoders BinderValue
 			return err
 		}
 	}
+	err := b.decode(i, c, valueDecoders, filter...)
+	if err != nil && err != ErrUnsupportedMediaType {
+		return err
+	}
+	if sErr := BindSources(c, i, valueDecoders, filter...); sErr != nil {
+		return sErr
+	}
+	return err
+}
+
+func (b *binder) decode(i any, c Context, valueDecoders BinderValueCustomDecoders, filter ...FormDataFilter) error {
 	contentType := c.Request().Header().Get(HeaderContentType)
 	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, `;`, 2)[0]))
 	if decoder, ok := b.decoders[contentType]; ok {
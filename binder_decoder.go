@@ -0,0 +1,160 @@
+package echo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type (
+	// BodyUnmarshaler 请求正文反序列化函数
+	BodyUnmarshaler func(data []byte, v any) error
+
+	// BinderDecoder 请求正文解码器(用于 Binder 的 AddDecoder)
+	BinderDecoder = func(any, Context, BinderValueCustomDecoders, ...FormDataFilter) error
+
+	// BinderDecoderAdder 支持添加请求正文解码器的 Binder
+	BinderDecoderAdder interface {
+		AddDecoder(mime string, decoder func(any, Context, BinderValueCustomDecoders, ...FormDataFilter) error)
+	}
+)
+
+// AddBinderDecoder 为 Binder 添加指定 MIME 类型的请求正文解码器(Binder 需要实现 BinderDecoderAdder 接口)
+func (e *Echo) AddBinderDecoder(mime string, decoder BinderDecoder) *Echo {
+	if b, ok := e.binder.(BinderDecoderAdder); ok {
+		b.AddDecoder(mime, decoder)
+	} else {
+		e.Logger().Warnf(`echo: the binder %T does not support adding decoders`, e.binder)
+	}
+	return e
+}
+
+// NewBodyDecoder 创建基于 unmarshal 的请求正文解码器。
+// 未指定 FormDataFilter 和 BinderValueCustomDecoders 时直接解码到 i；
+// 否则先解码为 map，再转换为表单数据(例如：profile[addresses][0][zip])后通过 FormToStructWithDecoder 映射到 i，
+// 此时与表单绑定一样按字段名称(而不是编码格式的结构体标签)进行映射
+func NewBodyDecoder(unmarshal BodyUnmarshaler) BinderDecoder {
+	return func(i any, ctx Context, valueDecoders BinderValueCustomDecoders, filter ...FormDataFilter) error {
+		body := ctx.Request().Body()
+		if body == nil {
+			return NewHTTPError(http.StatusBadRequest, "Request body can't be nil")
+		}
+		defer body.Close()
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return bodyReadError(err)
+		}
+		if len(b) == 0 {
+			return nil
+		}
+		if len(filter) == 0 && len(valueDecoders) == 0 {
+			if err = unmarshal(b, i); err != nil {
+				return NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+			}
+			return nil
+		}
+		var m map[string]any
+		if err = unmarshal(b, &m); err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+		}
+		return FormToStructWithDecoder(ctx.Echo(), i, MapToForm(m), ``, valueDecoders, filter...)
+	}
+}
+
+// bodyReadError 读取请求正文出错时返回的错误。
+// 保留 BodyLimit、Decompress 等中间件的 Reader 返回的 HTTPError(例如：413)，其它错误返回 400
+func bodyReadError(err error) error {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he
+	}
+	return NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+}
+
+// MapToForm 将 map 转换为表单数据。嵌套的 map 和切片使用方括号表示层级，例如：profile[addresses][0][zip]
+func MapToForm(m map[string]any) map[string][]string {
+	r := map[string][]string{}
+	for k, v := range m {
+		valueToForm(r, k, reflect.ValueOf(v))
+	}
+	return r
+}
+
+func valueToForm(r map[string][]string, name string, v reflect.Value) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(a, b int) bool {
+			return fmt.Sprint(keys[a].Interface()) < fmt.Sprint(keys[b].Interface())
+		})
+		for _, key := range keys {
+			valueToForm(r, name+`[`+fmt.Sprint(key.Interface())+`]`, v.MapIndex(key))
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			r[name] = append(r[name], string(v.Bytes()))
+			return
+		}
+		for j, n := 0, v.Len(); j < n; j++ {
+			ev := v.Index(j)
+			for ev.Kind() == reflect.Interface && !ev.IsNil() {
+				ev = ev.Elem()
+			}
+			switch ev.Kind() {
+			case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+				valueToForm(r, name+`[`+strconv.Itoa(j)+`]`, ev)
+			default:
+				if s, ok := scalarToFormValue(ev); ok {
+					r[name] = append(r[name], s)
+				}
+			}
+		}
+	default:
+		if s, ok := scalarToFormValue(v); ok {
+			r[name] = append(r[name], s)
+		}
+	}
+}
+
+func scalarToFormValue(v reflect.Value) (string, bool) {
+	if !v.IsValid() {
+		return ``, false
+	}
+	if v.CanInterface() {
+		switch t := v.Interface().(type) {
+		case time.Time:
+			return t.Format(time.RFC3339Nano), true
+		case fmt.Stringer:
+			return t.String(), true
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), true
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	}
+	return ``, false
+}
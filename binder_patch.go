@@ -0,0 +1,521 @@
+package echo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==========================================
+// 部分更新(PATCH)
+// ==========================================
+
+// NewPresence 创建字段存在性记录
+func NewPresence() *Presence {
+	return &Presence{paths: map[string]struct{}{}}
+}
+
+// Presence 请求中出现过的字段路径(JSON Pointer 格式，例如：/profile/addresses/0/zip)。
+// 可以用来区分字段是被省略还是被明确设置为空值
+type Presence struct {
+	paths map[string]struct{}
+}
+
+// Add 添加路径。path 可以是 JSON Pointer(例如：/profile/name)或表单字段名(例如：profile[name] 或 profile.name)
+func (p *Presence) Add(path string) *Presence {
+	p.paths[PresencePath(path)] = struct{}{}
+	return p
+}
+
+// Has 路径是否存在。path 的格式同 Add
+func (p *Presence) Has(path string) bool {
+	_, ok := p.paths[PresencePath(path)]
+	return ok
+}
+
+// Len 路径数量
+func (p *Presence) Len() int {
+	return len(p.paths)
+}
+
+// Paths 所有路径(已排序)
+func (p *Presence) Paths() []string {
+	r := make([]string, 0, len(p.paths))
+	for path := range p.paths {
+		r = append(r, path)
+	}
+	sort.Strings(r)
+	return r
+}
+
+func (p *Presence) addTokens(tokens []string) {
+	p.paths[joinJSONPointer(tokens)] = struct{}{}
+}
+
+// addValue 添加 v 中所有字段的路径
+func (p *Presence) addValue(tokens []string, v any) {
+	switch m := v.(type) {
+	case map[string]any:
+		for k, child := range m {
+			sub := append(tokens[:len(tokens):len(tokens)], k)
+			p.addTokens(sub)
+			p.addValue(sub, child)
+		}
+	case []any:
+		for i, child := range m {
+			sub := append(tokens[:len(tokens):len(tokens)], strconv.Itoa(i))
+			p.addTokens(sub)
+			p.addValue(sub, child)
+		}
+	}
+}
+
+// PresencePath 将表单字段名(通过 FormNames 解析，例如：profile[addresses][0][zip] 或 profile.name)转换为 JSON Pointer。
+// 以“/”开头的 path 被视为 JSON Pointer 原样返回
+func PresencePath(path string) string {
+	if len(path) == 0 || path[0] == '/' {
+		return path
+	}
+	path = strings.TrimSuffix(path, `[]`)
+	var tokens []string
+	for _, name := range FormNames(path) {
+		tokens = append(tokens, strings.Split(name, `.`)...)
+	}
+	return joinJSONPointer(tokens)
+}
+
+func joinJSONPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, `~`, `~0`), `/`, `~1`))
+	}
+	return sb.String()
+}
+
+func parseJSONPointer(path string) ([]string, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	if path[0] != '/' {
+		return nil, fmt.Errorf(`invalid JSON pointer: %q`, path)
+	}
+	tokens := strings.Split(path[1:], `/`)
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, `~1`, `/`), `~0`, `~`)
+	}
+	return tokens, nil
+}
+
+// BindPatch 部分更新绑定。将请求数据应用到已有的数据 i(结构体指针或 param.Store 等 map)上，
+// 并返回请求中出现过的字段路径。根据 Content-Type 的不同：
+//   - application/merge-patch+json: 按 RFC 7396 合并(值为 null 的字段会被清除)
+//   - application/json-patch+json: 按 RFC 6902 执行操作
+//   - application/json: 通过 MustBind(使用 Binder 中注册的解码器以及 filter)解码到 i，未出现的字段保持原值
+//   - 其它: 与 MustBind 相同，字段路径来自表单字段名
+func BindPatch(c Context, i any, filter ...FormDataFilter) (*Presence, error) {
+	contentType := c.Request().Header().Get(HeaderContentType)
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, `;`, 2)[0]))
+	switch contentType {
+	case MIMEApplicationMergePatchJSON, MIMEApplicationJSONPatchJSON, MIMEApplicationJSON:
+		body := c.Request().Body()
+		if body == nil {
+			return nil, NewHTTPError(http.StatusBadRequest, "Request body can't be nil")
+		}
+		defer body.Close()
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, bodyReadError(err)
+		}
+		switch contentType {
+		case MIMEApplicationMergePatchJSON:
+			return ApplyMergePatch(i, b)
+		case MIMEApplicationJSONPatchJSON:
+			return ApplyJSONPatch(i, b)
+		}
+		var doc any
+		if err = json.Unmarshal(b, &doc); err != nil {
+			return nil, NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+		}
+		c.Request().SetBody(bytes.NewReader(b))
+		target := i
+		if rv := reflect.ValueOf(i); rv.Kind() == reflect.Map { // 解码到非指针的 map 时使用指向它的指针(已有的键保持不变)
+			ptr := reflect.New(rv.Type())
+			ptr.Elem().Set(rv)
+			target = ptr.Interface()
+		}
+		if err = c.MustBind(target, filter...); err != nil {
+			return nil, err
+		}
+		presence := NewPresence()
+		presence.addValue(nil, doc)
+		return presence, nil
+	}
+	if err := c.MustBind(i, filter...); err != nil {
+		return nil, err
+	}
+	presence := NewPresence()
+	var form map[string][]string
+	if contentType == MIMEApplicationForm {
+		form = c.Request().PostForm().All()
+	} else {
+		form = c.Request().Form().All()
+	}
+	for key := range form {
+		presence.Add(key)
+	}
+	return presence, nil
+}
+
+// ApplyMergePatch 将 JSON Merge Patch(RFC 7396)应用到 i(结构体指针或 param.Store 等 map)上，返回 patch 中出现过的字段路径
+func ApplyMergePatch(i any, patch []byte) (*Presence, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+	}
+	doc, err := jsonDocument(i)
+	if err != nil {
+		return nil, err
+	}
+	presence := NewPresence()
+	presence.addValue(nil, p)
+	if err = assignJSONDocument(i, mergePatch(doc, p)); err != nil {
+		return nil, err
+	}
+	return presence, nil
+}
+
+func mergePatch(target any, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = map[string]any{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+		} else {
+			tm[k] = mergePatch(tm[k], v)
+		}
+	}
+	return tm
+}
+
+// JSONPatchOperation JSON Patch(RFC 6902)操作
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch 将 JSON Patch(RFC 6902)应用到 i(结构体指针或 param.Store 等 map)上，返回被修改的字段路径。
+// 所有操作成功后才会修改 i；test 操作失败时返回 409 错误
+func ApplyJSONPatch(i any, patch []byte) (*Presence, error) {
+	var ops []*JSONPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+	}
+	doc, err := jsonDocument(i)
+	if err != nil {
+		return nil, err
+	}
+	presence := NewPresence()
+	for index, op := range ops {
+		doc, err = applyJSONPatchOperation(doc, op, presence)
+		if err != nil {
+			if he, ok := err.(*HTTPError); ok {
+				return nil, he
+			}
+			return nil, NewHTTPError(http.StatusUnprocessableEntity, fmt.Sprintf(`json patch: operation #%d (%s %s): %v`, index, op.Op, op.Path, err)).SetRaw(err)
+		}
+	}
+	if err = assignJSONDocument(i, doc); err != nil {
+		return nil, err
+	}
+	return presence, nil
+}
+
+func applyJSONPatchOperation(doc any, op *JSONPatchOperation, presence *Presence) (any, error) {
+	tokens, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return doc, err
+	}
+	var value any
+	switch op.Op {
+	case `add`, `replace`, `test`:
+		if len(op.Value) == 0 {
+			return doc, fmt.Errorf(`missing value`)
+		}
+		if err = json.Unmarshal(op.Value, &value); err != nil {
+			return doc, err
+		}
+	case `move`, `copy`:
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return doc, err
+		}
+		if value, err = jsonPointerGet(doc, from); err != nil {
+			return doc, err
+		}
+		if op.Op == `move` {
+			if strings.HasPrefix(op.Path+`/`, op.From+`/`) && op.Path != op.From {
+				return doc, fmt.Errorf(`cannot move a value into one of its children`)
+			}
+			if doc, err = jsonPointerRemove(doc, from); err != nil {
+				return doc, err
+			}
+			presence.addTokens(from)
+		} else {
+			value = deepCopyJSONValue(value)
+		}
+	case `remove`:
+	default:
+		return doc, fmt.Errorf(`unsupported operation: %q`, op.Op)
+	}
+	switch op.Op {
+	case `test`:
+		current, err := jsonPointerGet(doc, tokens)
+		if err != nil {
+			return doc, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return doc, NewHTTPError(http.StatusConflict, fmt.Sprintf(`json patch: test failed for path %s`, op.Path))
+		}
+		return doc, nil
+	case `remove`:
+		doc, err = jsonPointerRemove(doc, tokens)
+	case `replace`:
+		if _, err = jsonPointerGet(doc, tokens); err != nil {
+			return doc, err
+		}
+		doc, err = jsonPointerAdd(doc, tokens, value, true)
+	default: // add, move, copy
+		if n := len(tokens); n > 0 && tokens[n-1] == `-` {
+			if parent, pErr := jsonPointerGet(doc, tokens[:n-1]); pErr == nil {
+				if list, ok := parent.([]any); ok {
+					tokens = append(tokens[:n-1:n-1], strconv.Itoa(len(list)))
+				}
+			}
+		}
+		doc, err = jsonPointerAdd(doc, tokens, value, false)
+	}
+	if err == nil {
+		presence.addTokens(tokens)
+	}
+	return doc, err
+}
+
+func jsonArrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == `-` {
+		return length, nil
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || (token != `0` && token[0] == '0') {
+		return 0, fmt.Errorf(`invalid array index: %q`, token)
+	}
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if idx > max {
+		return 0, fmt.Errorf(`array index out of range: %d`, idx)
+	}
+	return idx, nil
+}
+
+func jsonPointerGet(doc any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch c := doc.(type) {
+		case map[string]any:
+			v, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf(`path not found: %s`, joinJSONPointer(tokens))
+			}
+			doc = v
+		case []any:
+			idx, err := jsonArrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[idx]
+		default:
+			return nil, fmt.Errorf(`path not found: %s`, joinJSONPointer(tokens))
+		}
+	}
+	return doc, nil
+}
+
+// jsonPointerUpdate 修改 tokens 所指向值的父级容器
+func jsonPointerUpdate(doc any, tokens []string, update func(container any, key string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return update(doc, tokens[0])
+	}
+	switch c := doc.(type) {
+	case map[string]any:
+		child, ok := c[tokens[0]]
+		if !ok {
+			return doc, fmt.Errorf(`path not found: /%s`, tokens[0])
+		}
+		child, err := jsonPointerUpdate(child, tokens[1:], update)
+		if err != nil {
+			return doc, err
+		}
+		c[tokens[0]] = child
+		return c, nil
+	case []any:
+		idx, err := jsonArrayIndex(tokens[0], len(c), false)
+		if err != nil {
+			return doc, err
+		}
+		child, err := jsonPointerUpdate(c[idx], tokens[1:], update)
+		if err != nil {
+			return doc, err
+		}
+		c[idx] = child
+		return c, nil
+	}
+	return doc, fmt.Errorf(`path not found: /%s`, tokens[0])
+}
+
+func jsonPointerAdd(doc any, tokens []string, value any, replace bool) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return jsonPointerUpdate(doc, tokens, func(container any, key string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[key] = value
+			return c, nil
+		case []any:
+			idx, err := jsonArrayIndex(key, len(c), !replace)
+			if err != nil {
+				return c, err
+			}
+			if replace {
+				c[idx] = value
+				return c, nil
+			}
+			c = append(c, nil)
+			copy(c[idx+1:], c[idx:])
+			c[idx] = value
+			return c, nil
+		}
+		return container, fmt.Errorf(`path not found: %s`, joinJSONPointer(tokens))
+	})
+}
+
+func jsonPointerRemove(doc any, tokens []string) (any, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return jsonPointerUpdate(doc, tokens, func(container any, key string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			if _, ok := c[key]; !ok {
+				return c, fmt.Errorf(`path not found: %s`, joinJSONPointer(tokens))
+			}
+			delete(c, key)
+			return c, nil
+		case []any:
+			idx, err := jsonArrayIndex(key, len(c), false)
+			if err != nil {
+				return c, err
+			}
+			return append(c[:idx], c[idx+1:]...), nil
+		}
+		return container, fmt.Errorf(`path not found: %s`, joinJSONPointer(tokens))
+	})
+}
+
+func deepCopyJSONValue(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		r := make(map[string]any, len(c))
+		for k, child := range c {
+			r[k] = deepCopyJSONValue(child)
+		}
+		return r
+	case []any:
+		r := make([]any, len(c))
+		for i, child := range c {
+			r[i] = deepCopyJSONValue(child)
+		}
+		return r
+	}
+	return v
+}
+
+// jsonDocument 将 i 转换为 JSON 文档(map[string]any 等)
+func jsonDocument(i any) (any, error) {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	err = json.Unmarshal(b, &doc)
+	return doc, err
+}
+
+// assignJSONDocument 用 JSON 文档替换 i 的内容。
+// 结构体中不参与 JSON 编码的字段(例如：`json:"-"` 和未导出的字段)保持原值
+func assignJSONDocument(i any, doc any) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(i)
+	if rv.Kind() == reflect.Map {
+		if rv.IsNil() {
+			return fmt.Errorf(`json patch: nil map %T`, i)
+		}
+		tmp := reflect.New(rv.Type())
+		if err = json.Unmarshal(b, tmp.Interface()); err != nil {
+			return NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetRaw(err)
+		}
+		rv.Clear()
+		iter := tmp.Elem().MapRange()
+		for iter.Next() {
+			rv.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return nil
+	}
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf(`json patch: unsupported type %T`, i)
+	}
+	tmp := reflect.New(rv.Type().Elem())
+	tmp.Elem().Set(rv.Elem())
+	resetJSONFields(tmp.Elem())
+	if err = json.Unmarshal(b, tmp.Interface()); err != nil {
+		return NewHTTPError(http.StatusUnprocessableEntity, err.Error()).SetRaw(err)
+	}
+	rv.Elem().Set(tmp.Elem())
+	return nil
+}
+
+// resetJSONFields 将参与 JSON 编码的字段重置为零值
+func resetJSONFields(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		v.SetZero()
+		return
+	}
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		if f.Anonymous || !f.IsExported() || f.Tag.Get(`json`) == `-` {
+			continue
+		}
+		fv, err := v.FieldByIndexErr(f.Index)
+		if err != nil || !fv.CanSet() {
+			continue
+		}
+		fv.SetZero()
+	}
+}
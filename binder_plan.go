@@ -0,0 +1,138 @@
+package echo
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webx-top/com"
+)
+
+// binderFieldPlan 结构体字段的绑定计划(预先解析的标签等)
+type binderFieldPlan struct {
+	reflect.StructField
+	skip          bool   // form_options:"-"
+	filter        string // form_filter
+	seperator     string // form_seperator 或 form_delimiter
+	format        string // form_format
+	decoder       string // form_decoder
+	decoderParams string
+	encoder       string // form_encoder
+	encoderParams string
+	isTime        bool
+	isDuration    bool
+}
+
+var emptyBinderFieldPlan = &binderFieldPlan{}
+
+func newBinderFieldPlan(f reflect.StructField) *binderFieldPlan {
+	fp := &binderFieldPlan{StructField: f}
+	if len(f.Tag) > 0 {
+		fp.skip = f.Tag.Get(`form_options`) == `-`
+		fp.filter = f.Tag.Get(`form_filter`)
+		fp.seperator = f.Tag.Get(`form_seperator`)
+		if len(fp.seperator) == 0 {
+			fp.seperator = f.Tag.Get(`form_delimiter`)
+		}
+		fp.format = f.Tag.Get(`form_format`)
+		fp.decoder, fp.decoderParams = splitBinderCoder(f.Tag.Get(`form_decoder`))
+		fp.encoder, fp.encoderParams = splitBinderCoder(f.Tag.Get(`form_encoder`))
+	}
+	switch f.Type {
+	case timeType:
+		fp.isTime = true
+	case durationType:
+		fp.isDuration = true
+	}
+	return fp
+}
+
+func splitBinderCoder(coder string) (name string, params string) {
+	name, params, _ = strings.Cut(coder, `:`)
+	return
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// binderStructPlan 结构体类型的绑定计划
+type binderStructPlan struct {
+	fields []*binderFieldPlan          // 直接字段(按定义顺序)
+	byName map[string]*binderFieldPlan // 可以通过 FieldByName 获取的字段(包括嵌入结构体中的字段)
+	keys   sync.Map                    // 表单字段名 => 经过 com.Title 处理后的结构体字段名
+}
+
+func newBinderStructPlan(t reflect.Type) *binderStructPlan {
+	p := &binderStructPlan{
+		fields: make([]*binderFieldPlan, t.NumField()),
+		byName: map[string]*binderFieldPlan{},
+	}
+	for i := range p.fields {
+		p.fields[i] = newBinderFieldPlan(t.Field(i))
+	}
+	for _, f := range reflect.VisibleFields(t) {
+		if _, ok := p.byName[f.Name]; ok {
+			continue
+		}
+		// 与 FieldByName 的结果保持一致(同层级的同名字段不可访问)
+		sf, ok := t.FieldByName(f.Name)
+		if !ok {
+			continue
+		}
+		if len(sf.Index) == 1 {
+			p.byName[f.Name] = p.fields[sf.Index[0]]
+		} else {
+			p.byName[f.Name] = newBinderFieldPlan(sf)
+		}
+	}
+	return p
+}
+
+// field 根据名称获取字段
+func (p *binderStructPlan) field(name string) *binderFieldPlan {
+	return p.byName[name]
+}
+
+// normalize 使用 com.Title 转换表单字段名。只缓存能够匹配到字段的结果，以免缓存无限增长
+func (p *binderStructPlan) normalize(name string) string {
+	if v, ok := p.keys.Load(name); ok {
+		return v.(string)
+	}
+	key := com.Title(name)
+	if _, ok := p.byName[key]; ok {
+		p.keys.Store(name, key)
+	}
+	return key
+}
+
+// fieldValue 获取字段的值(嵌入的结构体指针为 nil 时会被初始化)，无法获取时返回无效的 reflect.Value
+func (f *binderFieldPlan) fieldValue(v reflect.Value) reflect.Value {
+	if len(f.Index) == 1 {
+		return v.Field(f.Index[0])
+	}
+	for i, x := range f.Index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// binderPlan 获取结构体类型的绑定计划(按类型缓存)
+func (e *Echo) binderPlan(t reflect.Type) *binderStructPlan {
+	if v, ok := e.binderPlans.Load(t); ok {
+		return v.(*binderStructPlan)
+	}
+	v, _ := e.binderPlans.LoadOrStore(t, newBinderStructPlan(t))
+	return v.(*binderStructPlan)
+}
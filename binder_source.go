@@ -0,0 +1,125 @@
+package echo
+
+import (
+	"reflect"
+	"sync"
+)
+
+// 结构体字段的数据来源标签。例如：
+//
+//	type Request struct {
+//		ID     uint64 `param:"id"`
+//		Tenant string `header:"X-Tenant"`
+//		SID    string `cookie:"sid"`
+//		Page   int    `query:"page" header:"X-Page"`
+//	}
+const (
+	BinderSourceParam  = `param`  // 路由参数
+	BinderSourceHeader = `header` // 请求头
+	BinderSourceCookie = `cookie` // Cookie
+	BinderSourceQuery  = `query`  // 网址查询参数
+)
+
+// BinderSources 数据来源标签的优先级(排在前面的优先)。
+// 字段有多个来源标签时，使用第一个有值的来源；来自标签的值会覆盖请求正文(表单)中的同名字段值
+var BinderSources = []string{BinderSourceParam, BinderSourceHeader, BinderSourceCookie, BinderSourceQuery}
+
+var binderSourceTags = []string{BinderSourceParam, BinderSourceHeader, BinderSourceCookie, BinderSourceQuery}
+
+type binderSourceField struct {
+	name    string            // 结构体字段名
+	sources map[string]string // 来源 => 名称
+}
+
+var binderSourceFields sync.Map // reflect.Type => []*binderSourceField
+
+func getBinderSourceFields(t reflect.Type) []*binderSourceField {
+	if v, ok := binderSourceFields.Load(t); ok {
+		return v.([]*binderSourceField)
+	}
+	var fields []*binderSourceField
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous || !f.IsExported() {
+			continue
+		}
+		var field *binderSourceField
+		for _, source := range binderSourceTags {
+			name, ok := f.Tag.Lookup(source)
+			if !ok {
+				continue
+			}
+			if len(name) == 0 {
+				name = f.Name
+			} else if name == `-` {
+				continue
+			}
+			if field == nil {
+				field = &binderSourceField{name: f.Name, sources: map[string]string{}}
+			}
+			field.sources[source] = name
+		}
+		if field != nil {
+			fields = append(fields, field)
+		}
+	}
+	binderSourceFields.Store(t, fields)
+	return fields
+}
+
+func binderSourceValues(c Context, source string, name string) []string {
+	switch source {
+	case BinderSourceParam:
+		if v := c.Param(name); len(v) > 0 {
+			return []string{v}
+		}
+	case BinderSourceHeader:
+		return c.Request().Header().Values(name)
+	case BinderSourceCookie:
+		if v := c.GetCookie(name); len(v) > 0 {
+			return []string{v}
+		}
+	case BinderSourceQuery:
+		return c.QueryValues(name)
+	}
+	return nil
+}
+
+// BindSources 根据结构体字段的 param、header、cookie 和 query 标签，从路由参数、请求头、Cookie 和网址查询参数中取值并赋值给字段。
+// 类型转换以及 form_decoder 等标签的处理与表单字段相同
+func BindSources(c Context, i any, valueDecoders BinderValueCustomDecoders, filter ...FormDataFilter) error {
+	t := reflect.TypeOf(i)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	fields := getBinderSourceFields(t.Elem())
+	if len(fields) == 0 {
+		return nil
+	}
+	var topName string
+	if topNamer, ok := i.(BinderFormTopNamer); ok {
+		topName = topNamer.BinderFormTopName()
+	}
+	data := map[string][]string{}
+	for _, field := range fields {
+		for _, source := range BinderSources {
+			name, ok := field.sources[source]
+			if !ok {
+				continue
+			}
+			values := binderSourceValues(c, source, name)
+			if len(values) == 0 {
+				continue
+			}
+			key := field.name
+			if len(topName) > 0 {
+				key = topName + `.` + key
+			}
+			data[key] = values
+			break
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return FormToStructWithDecoder(c.Echo(), i, data, topName, valueDecoders, filter...)
+}
@@ -2,6 +2,9 @@ package echo_test
 
 import (
 	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
 	"sort"
 	"strings"
 	"testing"
@@ -12,6 +15,7 @@ import (
 	. "github.com/webx-top/echo"
 	"github.com/webx-top/echo/engine/mock"
 	"github.com/webx-top/echo/param"
+	test "github.com/webx-top/echo/testing"
 )
 
 type TestForm struct {
@@ -493,3 +497,289 @@ func TestBinderConvertor(t *testing.T) {
 		`result`:        {""},
 	}, ctx2.Forms())
 }
+
+type testSourcePage struct {
+	Page int `query:"page" header:"X-Page"`
+}
+
+type testSourceRequest struct {
+	testSourcePage
+	ID      uint64   `param:"id"`
+	Tenant  string   `header:"X-Tenant"`
+	SID     string   `cookie:"sid"`
+	Tags    []string `query:"tag"`
+	Name    string   `query:"name"`
+	Created int64    `query:"created" form_decoder:"time2unix"`
+	Skip    string   `query:"-"`
+}
+
+func TestBindSources(t *testing.T) {
+	e := New()
+	e.Post(`/users/:id`, func(c Context) error {
+		m := &testSourceRequest{}
+		if err := c.MustBind(m); err != nil {
+			return err
+		}
+		return c.JSON(m)
+	})
+	e.Commit()
+	rec := test.Request(POST, `/users/12?page=2&tag=a&tag=b&name=query&created=2024-01-02+03:04:05&skip=1`, e, func(r *http.Request) {
+		r.Header.Set(HeaderContentType, MIMEApplicationForm)
+		r.Header.Set(`X-Tenant`, `acme`)
+		r.Header.Set(`X-Page`, `3`)
+		r.AddCookie(&http.Cookie{Name: `sid`, Value: `s1`})
+		r.Body = io.NopCloser(strings.NewReader(`name=body&tenant=body`))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	m := &testSourceRequest{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), m))
+	assert.Equal(t, uint64(12), m.ID)
+	assert.Equal(t, `acme`, m.Tenant) // 标签的值覆盖请求正文
+	assert.Equal(t, `s1`, m.SID)
+	assert.Equal(t, 3, m.Page) // header 优先于 query
+	assert.Equal(t, []string{`a`, `b`}, m.Tags)
+	assert.Equal(t, `query`, m.Name)
+	assert.Equal(t, ``, m.Skip)
+	assert.Equal(t, `2024-01-02 03:04:05`, time.Unix(m.Created, 0).Format(time.DateTime))
+}
+
+type testPatchProfile struct {
+	City string   `json:"city"`
+	Tags []string `json:"tags"`
+}
+
+type testPatchUser struct {
+	Name     string            `json:"name"`
+	Email    string            `json:"email"`
+	Age      int               `json:"age"`
+	Profile  *testPatchProfile `json:"profile"`
+	Password string            `json:"-"`
+}
+
+type testPatchBinder struct {
+	Binder
+	calls int
+}
+
+func (b *testPatchBinder) MustBind(i any, c Context, filter ...FormDataFilter) error {
+	b.calls++
+	return b.Binder.MustBind(i, c, filter...)
+}
+
+func TestBindPatch(t *testing.T) {
+	e := New()
+	binder := &testPatchBinder{Binder: e.Binder()}
+	e.SetBinder(binder)
+	var (
+		user     *testPatchUser
+		store    param.Store
+		presence *Presence
+	)
+	e.Patch(`/user`, func(c Context) (err error) {
+		user = &testPatchUser{Name: `john`, Email: `john@example.com`, Age: 18, Profile: &testPatchProfile{City: `a`, Tags: []string{`x`}}, Password: `secret`}
+		presence, err = BindPatch(c, user)
+		return
+	})
+	e.Patch(`/store`, func(c Context) (err error) {
+		store = param.Store{`name`: `john`, `age`: 18.0}
+		presence, err = BindPatch(c, store)
+		return
+	})
+	e.Commit()
+	patch := func(path string, contentType string, body string) int {
+		return test.Request(PATCH, path, e, func(r *http.Request) {
+			r.Header.Set(HeaderContentType, contentType)
+			r.Body = io.NopCloser(strings.NewReader(body))
+		}).Code
+	}
+
+	// application/json
+	assert.Equal(t, http.StatusOK, patch(`/user`, MIMEApplicationJSON, `{"email":"","profile":{"city":"b"}}`))
+	assert.Equal(t, []string{`/email`, `/profile`, `/profile/city`}, presence.Paths())
+	assert.True(t, presence.Has(`profile.city`))
+	assert.False(t, presence.Has(`name`))
+	assert.Equal(t, `john`, user.Name)
+	assert.Equal(t, ``, user.Email)
+	assert.Equal(t, `b`, user.Profile.City)
+	// 通过 Binder 解码
+	assert.Equal(t, 1, binder.calls)
+
+	assert.Equal(t, http.StatusOK, patch(`/store`, MIMEApplicationJSON, `{"city":"b"}`))
+	assert.Equal(t, param.Store{`name`: `john`, `age`: 18.0, `city`: `b`}, store)
+	assert.Equal(t, []string{`/city`}, presence.Paths())
+
+	// form
+	assert.Equal(t, http.StatusOK, patch(`/user`, MIMEApplicationForm, `name=smith&profile[city]=`))
+	assert.True(t, presence.Has(`name`))
+	assert.True(t, presence.Has(`/profile/city`))
+	assert.False(t, presence.Has(`email`))
+	assert.Equal(t, `smith`, user.Name)
+	assert.Equal(t, `john@example.com`, user.Email)
+
+	// RFC 7396
+	assert.Equal(t, http.StatusOK, patch(`/user`, MIMEApplicationMergePatchJSON, `{"age":20,"email":null,"profile":{"tags":null}}`))
+	assert.Equal(t, []string{`/age`, `/email`, `/profile`, `/profile/tags`}, presence.Paths())
+	assert.Equal(t, &testPatchUser{Name: `john`, Age: 20, Profile: &testPatchProfile{City: `a`}, Password: `secret`}, user)
+
+	assert.Equal(t, http.StatusOK, patch(`/store`, MIMEApplicationMergePatchJSON, `{"age":null,"city":"b"}`))
+	assert.Equal(t, param.Store{`name`: `john`, `city`: `b`}, store)
+
+	// RFC 6902
+	assert.Equal(t, http.StatusOK, patch(`/user`, MIMEApplicationJSONPatchJSON, `[
+		{"op":"test","path":"/name","value":"john"},
+		{"op":"replace","path":"/name","value":"smith"},
+		{"op":"add","path":"/profile/tags/0","value":"w"},
+		{"op":"add","path":"/profile/tags/-","value":"z"},
+		{"op":"copy","from":"/profile/city","path":"/email"},
+		{"op":"remove","path":"/age"}
+	]`))
+	assert.Equal(t, []string{`/age`, `/email`, `/name`, `/profile/tags/0`, `/profile/tags/2`}, presence.Paths())
+	assert.Equal(t, &testPatchUser{Name: `smith`, Email: `a`, Profile: &testPatchProfile{City: `a`, Tags: []string{`w`, `x`, `z`}}, Password: `secret`}, user)
+
+	assert.Equal(t, http.StatusOK, patch(`/store`, MIMEApplicationJSONPatchJSON, `[{"op":"move","from":"/name","path":"/username"}]`))
+	assert.Equal(t, param.Store{`username`: `john`, `age`: 18.0}, store)
+
+	assert.Equal(t, http.StatusConflict, patch(`/user`, MIMEApplicationJSONPatchJSON, `[{"op":"test","path":"/name","value":"smith"}]`))
+	assert.Equal(t, http.StatusUnprocessableEntity, patch(`/user`, MIMEApplicationJSONPatchJSON, `[{"op":"replace","path":"/missing","value":1}]`))
+	assert.Equal(t, http.StatusUnprocessableEntity, patch(`/user`, MIMEApplicationJSONPatchJSON, `[{"op":"add","path":"/profile/tags/5","value":1}]`))
+	assert.Equal(t, http.StatusBadRequest, patch(`/user`, MIMEApplicationJSONPatchJSON, `{}`))
+}
+
+type benchBinderAddress struct {
+	Street  string
+	City    string
+	Zip     string `form_filter:"html"`
+	Country string
+}
+
+type benchBinderDTO struct {
+	ID        uint64
+	Name      string
+	Email     string
+	Phone     string
+	Age       int
+	Score     float64
+	Active    bool
+	Role      string
+	Tags      []string `form_delimiter:","`
+	Groups    []int64
+	Birthday  time.Time `form_format:"2006-01-02"`
+	Created   int64     `form_decoder:"time2unix" form_encoder:"unix2time"`
+	Timeout   time.Duration
+	Note      string
+	Title     string
+	Company   string
+	Website   string
+	Address   benchBinderAddress
+	Shipping  *benchBinderAddress
+	Extra     map[string]string
+	Level     uint8
+	Rank      int32
+	Ratio     float32
+	Nickname  string
+	Signature string
+}
+
+var benchBinderForm = map[string][]string{
+	`id`:                {`1`},
+	`name`:              {`John`},
+	`email`:             {`john@example.com`},
+	`phone`:             {`123456`},
+	`age`:               {`30`},
+	`score`:             {`99.5`},
+	`active`:            {`true`},
+	`role`:              {`admin`},
+	`tags`:              {`a,b,c`},
+	`groups`:            {`1`, `2`, `3`},
+	`birthday`:          {`1990-01-02`},
+	`created`:           {`2024-01-02 03:04:05`},
+	`timeout`:           {`5s`},
+	`note`:              {`note`},
+	`title`:             {`title`},
+	`company`:           {`company`},
+	`website`:           {`https://example.com`},
+	`address[street]`:   {`street`},
+	`address[city]`:     {`city`},
+	`address[zip]`:      {`100000`},
+	`address[country]`:  {`CN`},
+	`shipping[street]`:  {`street2`},
+	`shipping[city]`:    {`city2`},
+	`shipping[zip]`:     {`200000`},
+	`shipping[country]`: {`US`},
+	`extra[a]`:          {`1`},
+	`extra[b]`:          {`2`},
+	`level`:             {`3`},
+	`rank`:              {`4`},
+	`ratio`:             {`0.5`},
+	`nickname`:          {`jj`},
+	`signature`:         {`hello`},
+}
+
+type testBinderPlanInner struct {
+	Name string
+	Tags []string
+}
+
+type testBinderPlanOuter struct {
+	Name  string   `form_options:"-"`
+	Tags  []string `form_delimiter:","`
+	Inner testBinderPlanInner
+}
+
+func TestBinderPlan(t *testing.T) {
+	e := New()
+	m := &benchBinderDTO{}
+	assert.NoError(t, FormToStruct(e, m, benchBinderForm, ``))
+	assert.Equal(t, `John`, m.Name)
+	assert.Equal(t, []string{`a`, `b`, `c`}, m.Tags)
+	assert.Equal(t, []int64{1, 2, 3}, m.Groups)
+	assert.Equal(t, `1990-01-02`, m.Birthday.Format(`2006-01-02`))
+	assert.Equal(t, 5*time.Second, m.Timeout)
+	assert.Equal(t, `city`, m.Address.City)
+	assert.Equal(t, `US`, m.Shipping.Country)
+	assert.Equal(t, map[string]string{`a`: `1`, `b`: `2`}, m.Extra)
+
+	ctx := e.NewContext(mock.NewRequest(), mock.NewResponse())
+	StructToForm(ctx, m, ``, LowerCaseFirstLetter)
+	assert.Equal(t, `1990-01-02`, ctx.Form(`birthday`))
+	assert.Equal(t, `5s`, ctx.Form(`timeout`))
+	assert.Equal(t, `200000`, ctx.Form(`shipping.zip`))
+
+	// 嵌套结构体中的同名字段使用自身的标签
+	o := &testBinderPlanOuter{}
+	assert.NoError(t, FormToStruct(e, o, map[string][]string{
+		`name`:        {`outer`},
+		`tags`:        {`a,b`},
+		`inner[name]`: {`inner`},
+		`inner[tags]`: {`a,b`},
+	}, ``))
+	assert.Equal(t, ``, o.Name)
+	assert.Equal(t, []string{`a`, `b`}, o.Tags)
+	assert.Equal(t, `inner`, o.Inner.Name)
+	assert.Equal(t, []string{`a,b`}, o.Inner.Tags)
+}
+
+func BenchmarkFormToStruct(b *testing.B) {
+	e := New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m := &benchBinderDTO{}
+		if err := FormToStruct(e, m, benchBinderForm, ``); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStructToForm(b *testing.B) {
+	e := New()
+	m := &benchBinderDTO{}
+	if err := FormToStruct(e, m, benchBinderForm, ``); err != nil {
+		b.Fatal(err)
+	}
+	ctx := e.NewContext(mock.NewRequest(), mock.NewResponse())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		StructToForm(ctx, m, ``, LowerCaseFirstLetter)
+	}
+}
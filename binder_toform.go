@@ -4,12 +4,10 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/webx-top/echo/engine"
 	"github.com/webx-top/echo/param"
-	"github.com/webx-top/tagfast"
 )
 
 func SetFormValue(f engine.URLValuer, fName string, index int, value any) {
@@ -35,20 +33,12 @@ func FlatStructToForm(ctx Context, m any, fieldNameFormatter FieldNameFormatter,
 	StructToForm(ctx, m, ``, fieldNameFormatter, formatters...)
 }
 
-func (e *Echo) binderValueEncode(name string, typev reflect.Type, tv reflect.Value) ([]string, error) {
-	f, _ := typev.FieldByName(name)
-	encoder := tagfast.Value(typev, f, `form_encoder`)
-	if len(encoder) == 0 {
+func (e *Echo) binderValueEncode(fp *binderFieldPlan, tv reflect.Value) ([]string, error) {
+	if len(fp.encoder) == 0 {
 		return nil, ErrNotImplemented
 	}
-	parts := strings.SplitN(encoder, `:`, 2)
-	encoder = parts[0]
-	var params string
-	if len(parts) == 2 {
-		params = parts[1]
-	}
 	// ErrNotImplemented
-	return e.CallBinderValueEncoder(encoder, name, tv.Interface(), params)
+	return e.CallBinderValueEncoder(fp.encoder, fp.Name, tv.Interface(), fp.encoderParams)
 }
 
 // StructToForm 映射struct到form
@@ -130,18 +120,17 @@ func structToForm(ctx Context, m any, topName string, fieldNameFormatter FieldNa
 		//fmt.Printf("~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~>%T\n", m)
 		return
 	}
-	for i, l := 0, tc.NumField(); i < l; i++ {
+	for i, fp := range ctx.Echo().binderPlan(tc).fields {
 		fVal := vc.Field(i)
-		fStruct := tc.Field(i)
-		if fStruct.Anonymous {
+		if fp.Anonymous {
 			if fVal.CanInterface() {
 				structToForm(ctx, fVal.Interface(), topName, fieldNameFormatter, valueEncoders)
 			}
 			continue
 		}
-		err := fieldToForm(ctx, tc, fStruct, fVal, topName, fieldNameFormatter, valueEncoders)
+		err := fieldToForm(ctx, fp, fVal, topName, fieldNameFormatter, valueEncoders)
 		if err != nil {
-			fpath := fStruct.Name
+			fpath := fp.Name
 			if len(topName) > 0 {
 				fpath = topName + `.` + fpath
 			}
@@ -150,9 +139,9 @@ func structToForm(ctx Context, m any, topName string, fieldNameFormatter FieldNa
 	}
 }
 
-func fieldToForm(ctx Context, parentTyp reflect.Type, fStruct reflect.StructField, fVal reflect.Value, topName string, fieldNameFormatter FieldNameFormatter, valueEncoders BinderValueCustomEncoders) error {
+func fieldToForm(ctx Context, fp *binderFieldPlan, fVal reflect.Value, topName string, fieldNameFormatter FieldNameFormatter, valueEncoders BinderValueCustomEncoders) error {
 	f := ctx.Request().Form()
-	fName := fieldNameFormatter(topName, fStruct.Name)
+	fName := fieldNameFormatter(topName, fp.Name)
 	if !fVal.CanInterface() || len(fName) == 0 {
 		return nil
 	}
@@ -167,32 +156,29 @@ func fieldToForm(ctx Context, parentTyp reflect.Type, fStruct reflect.StructFiel
 			return nil
 		}
 	}
-	if parentTyp.Kind() == reflect.Struct {
-		values, err := ctx.Echo().binderValueEncode(fStruct.Name, parentTyp, fVal)
-		if err != nil {
-			if err != ErrNotImplemented {
-				return err
-			}
-		} else {
-			SetFormValues(f, fName, values)
-			return nil
+	values, err := ctx.Echo().binderValueEncode(fp, fVal)
+	if err != nil {
+		if err != ErrNotImplemented {
+			return err
 		}
+	} else {
+		SetFormValues(f, fName, values)
+		return nil
 	}
-	switch fVal.Type().String() {
-	case `time.Time`:
+	switch {
+	case fp.isTime:
 		if t, y := fVal.Interface().(time.Time); y {
 			if t.IsZero() {
 				f.Set(fName, ``)
 			} else {
-				dateformat := tagfast.Value(parentTyp, fStruct, `form_format`)
-				if len(dateformat) > 0 {
+				if dateformat := fp.format; len(dateformat) > 0 {
 					f.Set(fName, t.Format(dateformat))
 				} else {
 					f.Set(fName, t.Format(`2006-01-02 15:04:05`))
 				}
 			}
 		}
-	case `time.Duration`:
+	case fp.isDuration:
 		if t, y := fVal.Interface().(time.Duration); y {
 			f.Set(fName, t.String())
 		}
@@ -12,7 +12,6 @@ import (
 	"github.com/webx-top/com"
 	"github.com/webx-top/echo/logger"
 	"github.com/webx-top/echo/param"
-	"github.com/webx-top/tagfast"
 )
 
 // FormNames user[name][test]
@@ -84,7 +83,7 @@ func namedStructMap(e *Echo, m any, data map[string][]string, topName string, va
 	default:
 		return errors.New(`binder: unsupported type ` + tc.Kind().String())
 	}
-	keyNormalizer := com.Title
+	var keyNormalizer func(string) string // nil: com.Title
 	if bkn, ok := m.(BinderKeyNormalizer); ok {
 		keyNormalizer = bkn.BinderKeyNormalizer
 	}
@@ -166,7 +165,13 @@ func (e *Echo) parseFormItem(keyNormalizer func(string) string, m any, typev ref
 	isMap := value.Kind() == reflect.Map
 	for i, name := range names {
 		if !isMap {
-			name = keyNormalizer(name)
+			if keyNormalizer != nil {
+				name = keyNormalizer(name)
+			} else if value.Kind() == reflect.Struct {
+				name = e.binderPlan(typev).normalize(name)
+			} else {
+				name = com.Title(name)
+			}
 		}
 		if i > 0 {
 			propPath += `.`
@@ -192,7 +197,7 @@ func (e *Echo) parseFormItem(keyNormalizer func(string) string, m any, typev ref
 			case reflect.Map:
 				err = e.setMap(e.Logger(), tc, vc, name, value, typev, propPath, values)
 			case reflect.Struct:
-				err = e.setStructField(e.Logger(), tc, vc, name, value, typev, propPath, values, valueDecoders)
+				err = e.setStructField(e.Logger(), typev, value, name, propPath, values, valueDecoders)
 			default:
 				e.Logger().Debugf(`binder: The last layer field "%v" does not support type: %v`, propPath, value.Kind())
 			}
@@ -289,11 +294,15 @@ func (e *Echo) parseFormItem(keyNormalizer func(string) string, m any, typev ref
 			}
 			return e.parseFormItem(keyNormalizer, m, newT, newV, names[i+1:], propPath+`.`, checkPath+`.`, values, valueDecoders, filters)
 		case reflect.Struct:
-			f, _ := typev.FieldByName(name)
-			if tagfast.Value(tc, f, `form_options`) == `-` {
+			fp := e.binderPlan(typev).field(name)
+			if fp == nil {
+				e.Logger().Debugf(`binder: %T#%v field is not found`, m, propPath)
+				return nil
+			}
+			if fp.skip {
 				return nil
 			}
-			value = value.FieldByName(name)
+			value = fp.fieldValue(value)
 			if !value.IsValid() {
 				e.Logger().Debugf(`binder: %T#%v value is not valid %v`, m, propPath, value)
 				return nil
@@ -318,10 +327,6 @@ func (e *Echo) parseFormItem(keyNormalizer func(string) string, m any, typev ref
 				return nil
 			}
 			typev = value.Type()
-			f, _ = typev.FieldByName(name)
-			if tagfast.Value(tc, f, `form_options`) == `-` {
-				return nil
-			}
 		default:
 			e.Logger().Warnf(`binder: arg error, value kind is %v`, value.Kind())
 			return nil
@@ -364,10 +369,13 @@ func SafeGetFieldByName(value reflect.Value, name string) (v reflect.Value) {
 }
 
 func (e *Echo) setStructField(logger logger.Logger,
-	parentT reflect.Type, parentV reflect.Value, name string,
-	value reflect.Value, typev reflect.Type,
+	typev reflect.Type, value reflect.Value, name string,
 	propPath string, values []string, valueDecoders BinderValueCustomDecoders) error {
-	tv := SafeGetFieldByName(value, name)
+	fp := e.binderPlan(typev).field(name)
+	if fp == nil {
+		return ErrBreak
+	}
+	tv := fp.fieldValue(value)
 	if !tv.IsValid() {
 		return ErrBreak
 	}
@@ -375,8 +383,7 @@ func (e *Echo) setStructField(logger logger.Logger,
 		logger.Warnf(`binder: can not set %v=%+v to %v`, propPath, values, tv.Kind())
 		return ErrBreak
 	}
-	f, _ := typev.FieldByName(name)
-	if tagfast.Value(parentT, f, `form_options`) == `-` {
+	if fp.skip {
 		return ErrBreak
 	}
 	if tv.Kind() == reflect.Pointer {
@@ -389,22 +396,18 @@ func (e *Echo) setStructField(logger logger.Logger,
 		}
 		return decErr
 	}
-	if typev.Kind() == reflect.Struct {
-		err := e.binderValueDecode(name, typev, tv, values)
-		if err == nil || err != ErrNotImplemented {
-			return err
-		}
+	err := e.binderValueDecode(fp, tv, values)
+	if err == nil || err != ErrNotImplemented {
+		return err
 	}
-	return setField(logger, parentT, tv, f, name, values)
+	return setField(logger, tv, fp, name, values)
 }
 
-func (e *Echo) binderValueDecode(name string, typev reflect.Type, tv reflect.Value, values []string) error {
-	f, _ := typev.FieldByName(name)
-	decoder := tagfast.Value(typev, f, `form_decoder`)
-	if len(decoder) == 0 {
+func (e *Echo) binderValueDecode(fp *binderFieldPlan, tv reflect.Value, values []string) error {
+	if len(fp.decoder) == 0 {
 		return ErrNotImplemented
 	}
-	result, err := e.binderDecodeValue(decoder, name, values)
+	result, err := e.CallBinderValueDecoder(fp.decoder, fp.Name, values, fp.decoderParams)
 	if err != nil { // ErrNotImplemented
 		return err
 	}
@@ -421,16 +424,6 @@ func (e *Echo) binderValueDecode(name string, typev reflect.Type, tv reflect.Val
 	return ErrNotImplemented
 }
 
-func (e *Echo) binderDecodeValue(decoder string, name string, values []string) (any, error) {
-	parts := strings.SplitN(decoder, `:`, 2)
-	decoder = parts[0]
-	var params string
-	if len(parts) == 2 {
-		params = parts[1]
-	}
-	return e.CallBinderValueDecoder(decoder, name, values, params) // ErrNotImplemented
-}
-
 func convertMapKey(typev reflect.Type, key string) reflect.Value {
 	var index reflect.Value
 	keyT := typev.Key()
@@ -499,7 +492,7 @@ func (e *Echo) setMap(logger logger.Logger,
 	if !isPtr {
 		oldVal = reflect.New(oldVal.Type())
 	}
-	err := setField(logger, parentT, oldVal.Elem(), reflect.StructField{Name: name}, name, values)
+	err := setField(logger, oldVal.Elem(), emptyBinderFieldPlan, name, values)
 	if err == nil {
 		if !isPtr {
 			oldVal = reflect.Indirect(oldVal)
@@ -524,20 +517,16 @@ func SetReflectValue(source any, dest reflect.Value) bool {
 	return false
 }
 
-func setField(logger logger.Logger, parentT reflect.Type, tv reflect.Value, f reflect.StructField, name string, values []string) error {
+func setField(logger logger.Logger, tv reflect.Value, fp *binderFieldPlan, name string, values []string) error {
 	v := values[0]
 	switch kind := tv.Kind(); kind {
 	case reflect.String:
-		switch tagfast.Value(parentT, f, `form_filter`) {
+		switch fp.filter {
 		case `html`:
 			v = DefaultHTMLFilter(v)
 		default:
-			seperator := tagfast.Value(parentT, f, `form_seperator`)
-			if len(seperator) == 0 {
-				seperator = tagfast.Value(parentT, f, `form_delimiter`)
-			}
-			if len(seperator) > 0 {
-				v = strings.Join(values, seperator)
+			if len(fp.seperator) > 0 {
+				v = strings.Join(values, fp.seperator)
 			}
 		}
 		SetReflectValue(v, tv)
@@ -546,7 +535,7 @@ func setField(logger logger.Logger, parentT reflect.Type, tv reflect.Value, f re
 		SetReflectValue(ok, tv)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
 		var l any
-		dateformat := tagfast.Value(parentT, f, `form_format`)
+		dateformat := fp.format
 		if len(dateformat) > 0 {
 			t, err := time.ParseInLocation(dateformat, v, time.Local)
 			if err != nil {
@@ -571,7 +560,7 @@ func setField(logger logger.Logger, parentT reflect.Type, tv reflect.Value, f re
 		case time.Duration:
 			l, _ = time.ParseDuration(v)
 		default:
-			dateformat := tagfast.Value(parentT, f, `form_format`)
+			dateformat := fp.format
 			if len(dateformat) > 0 {
 				t, err := time.ParseInLocation(dateformat, v, time.Local)
 				if err != nil {
@@ -598,7 +587,7 @@ func setField(logger logger.Logger, parentT reflect.Type, tv reflect.Value, f re
 		}
 		SetReflectValue(x, tv)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		dateformat := tagfast.Value(parentT, f, `form_format`)
+		dateformat := fp.format
 		var x uint64
 		var bitSize int
 		switch kind {
@@ -668,13 +657,9 @@ func setField(logger logger.Logger, parentT reflect.Type, tv reflect.Value, f re
 			}
 		}
 	case reflect.Pointer:
-		setField(logger, parentT, tv.Elem(), f, name, values)
+		setField(logger, tv.Elem(), fp, name, values)
 	case reflect.Slice, reflect.Array:
-		seperator := tagfast.Value(parentT, f, `form_seperator`)
-		if len(seperator) == 0 {
-			seperator = tagfast.Value(parentT, f, `form_delimiter`)
-		}
-		if len(seperator) > 0 {
+		if seperator := fp.seperator; len(seperator) > 0 {
 			var parts []string
 			for _, value := range values {
 				value = strings.TrimSpace(value)
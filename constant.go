@@ -39,6 +39,8 @@ const (
 	PUT = "PUT"
 	// TRACE HTTP method
 	TRACE = "TRACE"
+	// MethodAny 匹配任意 HTTP 方法(包括自定义方法)的路由。同一路径下注册了具体方法的路由时优先使用具体方法的路由
+	MethodAny = "*"
 
 	//-------------
 	// Media types
@@ -48,11 +50,20 @@ const (
 	MIMEApplicationJSONCharsetUTF8       = MIMEApplicationJSON + "; " + CharsetUTF8
 	MIMEApplicationJavaScript            = "application/javascript"
 	MIMEApplicationJavaScriptCharsetUTF8 = MIMEApplicationJavaScript + "; " + CharsetUTF8
+	MIMEApplicationProblemJSON           = "application/problem+json"
+	MIMEApplicationMergePatchJSON        = "application/merge-patch+json"
+	MIMEApplicationJSONPatchJSON         = "application/json-patch+json"
 	MIMEApplicationXML                   = "application/xml"
 	MIMEApplicationXMLCharsetUTF8        = MIMEApplicationXML + "; " + CharsetUTF8
 	MIMEApplicationForm                  = "application/x-www-form-urlencoded"
 	MIMEApplicationProtobuf              = "application/protobuf"
+	MIMEApplicationXProtobuf             = "application/x-protobuf"
 	MIMEApplicationMsgpack               = "application/msgpack"
+	MIMEApplicationXMsgpack              = "application/x-msgpack"
+	MIMEApplicationCBOR                  = "application/cbor"
+	MIMEApplicationYAML                  = "application/yaml"
+	MIMEApplicationXYAML                 = "application/x-yaml"
+	MIMETextYAML                         = "text/yaml"
 	MIMETextHTML                         = "text/html"
 	MIMETextHTMLCharsetUTF8              = MIMETextHTML + "; " + CharsetUTF8
 	MIMETextPlain                        = "text/plain"
@@ -84,6 +95,10 @@ const (
 	HeaderContentLength       = "Content-Length"
 	HeaderContentType         = "Content-Type"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderIfNoneMatch         = "If-None-Match"
+	HeaderETag                = "ETag"
+	HeaderAge                 = "Age"
+	HeaderRetryAfter          = "Retry-After"
 	HeaderCookie              = "Cookie"
 	HeaderSetCookie           = "Set-Cookie"
 	HeaderLastModified        = "Last-Modified"
@@ -93,8 +108,11 @@ const (
 	HeaderWWWAuthenticate     = "WWW-Authenticate"
 	HeaderXForwardedProto     = "X-Forwarded-Proto"
 	HeaderXForwardedPort      = "X-Forwarded-Port"
+	HeaderXForwardedPrefix    = "X-Forwarded-Prefix"
 	HeaderXHTTPMethodOverride = "X-HTTP-Method-Override"
 	HeaderXForwardedFor       = "X-Forwarded-For"
+	HeaderXForwardedHost      = "X-Forwarded-Host"
+	HeaderForwarded           = "Forwarded"
 	HeaderXRealIP             = "X-Real-IP"
 	HeaderXRequestID          = "X-Request-ID"
 	HeaderXRequestedWith      = "X-Requested-With"
@@ -133,6 +151,11 @@ const (
 	ContentTypeXML   = "xml"
 	ContentTypeText  = "text"
 
+	ContentTypeMsgpack  = "msgpack"
+	ContentTypeCBOR     = "cbor"
+	ContentTypeYAML     = "yaml"
+	ContentTypeProtobuf = "protobuf"
+
 	// HTTP Scheme
 	SchemeHTTP  = "http"
 	SchemeHTTPS = "https"
@@ -25,6 +25,7 @@ type XContext struct {
 	cookier             Cookier
 	request             engine.Request
 	response            engine.Response
+	stdContext          context.Context
 	path                string
 	pnames              []string
 	pvalues             []string
@@ -35,6 +36,7 @@ type XContext struct {
 	handler             Handler
 	route               *Route
 	rid                 int
+	routeTable          *RouteTable
 	echo                *Echo
 	funcs               map[string]any
 	renderer            Renderer
@@ -52,6 +54,8 @@ type XContext struct {
 	onRelease           []func(Context)
 	realIP              string
 	dispatchPath        string
+	logger              logger.Logger
+	spanStarter         SpanStarter
 }
 
 var _ context.Context = (*XContext)(nil)
@@ -66,7 +70,7 @@ func NewContext(req engine.Request, res engine.Response, e *Echo) Context {
 		request:           req,
 		response:          res,
 		echo:              e,
-		pvalues:           make([]string, *e.maxParam),
+		pvalues:           make([]string, e.maxParam.Load()),
 		internal:          param.NewMap(),
 		store:             param.NewSafeStore(),
 		handler:           NotFoundHandler,
@@ -82,9 +86,18 @@ func NewContext(req engine.Request, res engine.Response, e *Echo) Context {
 }
 
 func (c *XContext) StdContext() context.Context {
+	if c.stdContext != nil {
+		return c.stdContext
+	}
 	return c.request.Context()
 }
 
+// SetStdContext 替换 StdContext()(以及 Deadline、Done、Err 和 Value)使用的 context.Context。
+// 传入 nil 时恢复为请求的 Context
+func (c *XContext) SetStdContext(ctx context.Context) {
+	c.stdContext = ctx
+}
+
 func (c *XContext) WithContext(ctx context.Context) *http.Request {
 	return c.request.WithContext(ctx)
 }
@@ -131,15 +144,36 @@ func (c *XContext) Handle(ctx Context) error {
 
 func (c *XContext) Route() *Route {
 	if c.route == nil {
-		if c.rid < 0 || c.rid >= len(c.echo.router.routes) {
+		c.route = c.RouteTable().route(c.rid)
+		if c.route == nil {
 			c.route = defaultRoute
-		} else {
-			c.route = c.echo.router.routes[c.rid]
 		}
 	}
 	return c.route
 }
 
+// RouteTable 处理当前请求所使用的路由表
+func (c *XContext) RouteTable() *RouteTable {
+	if c.routeTable == nil {
+		return c.echo.RouteTable()
+	}
+	return c.routeTable
+}
+
+func (c *XContext) setRouteTable(t *RouteTable) {
+	c.routeTable = t
+	if maxParam := int(c.echo.maxParam.Load()); len(c.pvalues) < maxParam {
+		pvalues := make([]string, maxParam)
+		copy(pvalues, c.pvalues)
+		c.pvalues = pvalues
+	}
+}
+
+// RouteVersion 处理当前请求所使用的路由表的版本号
+func (c *XContext) RouteVersion() uint64 {
+	return c.RouteTable().Version()
+}
+
 func (c *XContext) SetAuto(on bool) Context {
 	c.auto = on
 	return c
@@ -173,10 +207,24 @@ func (c *XContext) NewErrorWith(err error, code pkgCode.Code, args ...any) *Erro
 }
 
 // Logger returns the `Logger` instance.
+// 中间件通过 SetLogger 设置了当前请求的 Logger(例如附加了 request_id 等字段)时返回该 Logger
 func (c *XContext) Logger() logger.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
 	return c.echo.logger
 }
 
+// SetLogger 设置当前请求使用的 Logger
+func (c *XContext) SetLogger(l logger.Logger) {
+	c.logger = l
+}
+
+// WithLogFields 为当前请求的 Logger 附加字段(见 logger.With)
+func (c *XContext) WithLogFields(keyValues ...any) {
+	c.logger = logger.With(c.Logger(), keyValues...)
+}
+
 // Object returns the `context` object.
 func (c *XContext) Object() *XContext {
 	return c
@@ -221,6 +269,7 @@ func (c *XContext) Reset(req engine.Request, res engine.Response) {
 	c.cookier = NewCookier(c)
 	c.request = req
 	c.response = res
+	c.stdContext = nil
 	c.internal = param.NewMap()
 	c.store = param.NewSafeStore()
 	c.path = ""
@@ -231,6 +280,7 @@ func (c *XContext) Reset(req engine.Request, res engine.Response) {
 	c.handler = NotFoundHandler
 	c.route = nil
 	c.rid = -1
+	c.routeTable = nil
 	c.sessionOptions = nil
 	c.withFormatExtension = false
 	c.defaultExtension = ""
@@ -246,9 +296,15 @@ func (c *XContext) Reset(req engine.Request, res engine.Response) {
 	c.ResetFuncs(c.echo.FuncMap)
 	c.realIP = ""
 	c.dispatchPath = ""
+	c.logger = nil
+	c.spanStarter = nil
 	// NOTE: Don't reset because it has to have length c.echo.maxParam at all times
-	for i := 0; i < *c.echo.maxParam; i++ {
-		c.pvalues[i] = ""
+	if maxParam := int(c.echo.maxParam.Load()); len(c.pvalues) < maxParam {
+		c.pvalues = make([]string, maxParam) // 路由表替换后参数数量可能增加
+	} else {
+		for i := 0; i < maxParam; i++ {
+			c.pvalues[i] = ""
+		}
 	}
 }
 
@@ -472,7 +528,7 @@ func (c *XContext) Dispatch(route string) Handler {
 		}
 	}
 	c.handler = NotFoundHandler
-	return c.Echo().Router().Dispatch(c, u.Path)
+	return c.RouteTable().Router().Dispatch(c, u.Path)
 }
 
 func (c *XContext) SetDispatchPath(route string) {
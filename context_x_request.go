@@ -70,9 +70,7 @@ func (c *XContext) SetParamNames(names ...string) {
 	c.pnames = names
 
 	l := len(names)
-	if *c.echo.maxParam < l {
-		*c.echo.maxParam = l
-	}
+	c.echo.growMaxParam(l)
 
 	if len(c.pvalues) < l {
 		// Keeping the old pvalues just for backward compatibility, but it sounds that doesn't make sense to keep them,
@@ -87,8 +85,8 @@ func (c *XContext) SetParamValues(values ...string) {
 	// NOTE: Don't just set c.pvalues = values, because it has to have length c.echo.maxParam at all times
 	// It will brake the Router#Find code
 	limit := len(values)
-	if limit > *c.echo.maxParam {
-		limit = *c.echo.maxParam
+	if maxParam := min(int(c.echo.maxParam.Load()), len(c.pvalues)); limit > maxParam {
+		limit = maxParam
 	}
 	for i := 0; i < limit; i++ {
 		c.pvalues[i] = values[i]
@@ -333,35 +331,51 @@ func (c *XContext) Forms() map[string][]string {
 // Bind binds the request body into specified type `i`. The default binder does
 // it based on Content-Type header.
 func (c *XContext) Bind(i any, filter ...FormDataFilter) error {
-	return c.echo.binder.Bind(i, c, filter...)
+	return c.withSpan(SpanNameBind, func() error {
+		return c.echo.binder.Bind(i, c, filter...)
+	})
 }
 
 func (c *XContext) BindAndValidate(i any, filter ...FormDataFilter) error {
-	return c.echo.binder.BindAndValidate(i, c, filter...)
+	return c.withSpan(SpanNameBind, func() error {
+		return c.echo.binder.BindAndValidate(i, c, filter...)
+	})
 }
 
 func (c *XContext) MustBind(i any, filter ...FormDataFilter) error {
-	return c.echo.binder.MustBind(i, c, filter...)
+	return c.withSpan(SpanNameBind, func() error {
+		return c.echo.binder.MustBind(i, c, filter...)
+	})
 }
 
 func (c *XContext) MustBindAndValidate(i any, filter ...FormDataFilter) error {
-	return c.echo.binder.MustBindAndValidate(i, c, filter...)
+	return c.withSpan(SpanNameBind, func() error {
+		return c.echo.binder.MustBindAndValidate(i, c, filter...)
+	})
 }
 
 func (c *XContext) BindWithDecoder(i any, valueDecoders BinderValueCustomDecoders, filter ...FormDataFilter) error {
-	return c.echo.binder.BindWithDecoder(i, c, valueDecoders, filter...)
+	return c.withSpan(SpanNameBind, func() error {
+		return c.echo.binder.BindWithDecoder(i, c, valueDecoders, filter...)
+	})
 }
 
 func (c *XContext) BindAndValidateWithDecoder(i any, valueDecoders BinderValueCustomDecoders, filter ...FormDataFilter) error {
-	return c.echo.binder.BindAndValidateWithDecoder(i, c, valueDecoders, filter...)
+	return c.withSpan(SpanNameBind, func() error {
+		return c.echo.binder.BindAndValidateWithDecoder(i, c, valueDecoders, filter...)
+	})
 }
 
 func (c *XContext) MustBindWithDecoder(i any, valueDecoders BinderValueCustomDecoders, filter ...FormDataFilter) error {
-	return c.echo.binder.MustBindWithDecoder(i, c, valueDecoders, filter...)
+	return c.withSpan(SpanNameBind, func() error {
+		return c.echo.binder.MustBindWithDecoder(i, c, valueDecoders, filter...)
+	})
 }
 
 func (c *XContext) MustBindAndValidateWithDecoder(i any, valueDecoders BinderValueCustomDecoders, filter ...FormDataFilter) error {
-	return c.echo.binder.MustBindAndValidateWithDecoder(i, c, valueDecoders, filter...)
+	return c.withSpan(SpanNameBind, func() error {
+		return c.echo.binder.MustBindAndValidateWithDecoder(i, c, valueDecoders, filter...)
+	})
 }
 
 func (c *XContext) Header(name string) string {
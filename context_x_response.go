@@ -22,9 +22,20 @@ func (c *XContext) Response() engine.Response {
 	return c.response
 }
 
+// SetResponse 替换当前请求的 Response(用于中间件包装 Response，处理完毕后应当恢复原来的 Response)
+func (c *XContext) SetResponse(res engine.Response) {
+	c.response = res
+}
+
 // Render renders a template with data and sends a text/html response with status
 // code. Templates can be registered using `Echo.SetRenderer()`.
 func (c *XContext) Render(name string, data any, codes ...int) error {
+	return c.withSpan(SpanNameRender, func() error {
+		return c.render(name, data, codes...)
+	})
+}
+
+func (c *XContext) render(name string, data any, codes ...int) error {
 	if c.auto {
 		if ok, err := c.echo.AutoDetectRenderFormat(c, data, codes...); ok {
 			return err
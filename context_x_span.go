@@ -0,0 +1,37 @@
+package echo
+
+// SpanStarter 在 Render、Bind、保存会话等操作开始时调用，返回的函数在操作结束时调用(传入操作返回的错误)。
+// 用于链路追踪中间件创建子 span
+type SpanStarter func(c Context, name string) (end func(error))
+
+// 内置的 span 名称
+const (
+	SpanNameRender      = `echo.render`
+	SpanNameBind        = `echo.bind`
+	SpanNameSessionSave = `echo.session.save`
+)
+
+func nopSpanEnd(error) {}
+
+// SetSpanStarter 设置当前请求的 SpanStarter
+func (c *XContext) SetSpanStarter(fn SpanStarter) {
+	c.spanStarter = fn
+}
+
+// StartSpan 开始一个子操作。没有设置 SpanStarter 时返回空函数
+func (c *XContext) StartSpan(name string) (end func(error)) {
+	if c.spanStarter == nil {
+		return nopSpanEnd
+	}
+	return c.spanStarter(c, name)
+}
+
+func (c *XContext) withSpan(name string, fn func() error) error {
+	if c.spanStarter == nil {
+		return fn()
+	}
+	end := c.spanStarter(c, name)
+	err := fn()
+	end(err)
+	return err
+}
@@ -141,6 +141,12 @@ func (d *RawData) SetError(err error, args ...int) Data {
 	switch v := err.(type) {
 	case *Error:
 		d.SetInfo(v.Message, v.Code.Int()).SetByMap(v.Extra).SetZone(v.Zone)
+		if errs, ok := v.Cause().(*ValidationErrors); ok {
+			d.Data = errs.Errors()
+		}
+	case *ValidationErrors:
+		d.SetInfo(v.Error(), pkgCode.InvalidParameter.Int()).SetZone(v.Field())
+		d.Data = v.Errors()
 	case *RawData:
 		if v != d {
 			d.copyFrom(v)
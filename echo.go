@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -27,7 +29,7 @@ type (
 		hosts               map[string]*Host
 		hostAlias           map[string]string
 		onHostFound         func(Context) (bool, error)
-		maxParam            *int
+		maxParam            *atomic.Int32
 		notFoundHandler     HandlerFunc
 		httpErrorHandler    HTTPErrorHandler
 		binder              Binder
@@ -36,6 +38,11 @@ type (
 		pool                sync.Pool
 		debug               atomic.Bool
 		router              *Router
+		table               atomic.Pointer[RouteTable]
+		routeMu             sync.Mutex
+		routeVersion        atomic.Uint64
+		customMethods       []string    // 已注册路由中用到的非标准方法(例如 PROPFIND/PURGE)
+		routesPending       atomic.Bool // 是否有尚未发布到路由表中的路由
 		logger              logger.Logger
 		groups              map[string]*Group
 		handlerWrapper      []func(any) Handler
@@ -50,6 +57,10 @@ type (
 		FormSliceMaxIndex   int
 		binderValueDecoders map[string]BinderValueDecoder
 		binderValueEncoders map[string]BinderValueEncoder
+		binderPlans         sync.Map // reflect.Type => *binderStructPlan
+		paramConstraints    map[string]string
+		paramConstraintMu   sync.RWMutex
+		routeCheckMode      RouteCheckMode
 		uploadURLGenerator  func(Context, string, ...any) string
 		parseHeaderAccept   bool
 		defaultExtension    string
@@ -141,6 +152,16 @@ func (h HandlerFuncWithArg[R, W]) Handle(c Context) error {
 	return c.Render(``, w)
 }
 
+// RequestType 请求数据类型(*R)
+func (h HandlerFuncWithArg[R, W]) RequestType() reflect.Type {
+	return reflect.TypeFor[*R]()
+}
+
+// ResponseType 响应数据类型(W)
+func (h HandlerFuncWithArg[R, W]) ResponseType() reflect.Type {
+	return reflect.TypeFor[W]()
+}
+
 func (c EncodingConfig) SetFilter(f filter.Filter) EncodingConfig {
 	c.filter = f
 	return c
@@ -181,13 +202,14 @@ func (e *Echo) Reset() *Echo {
 	e.premiddleware = []Middleware{}
 	e.hosts = make(map[string]*Host)
 	e.hostAlias = make(map[string]string)
-	e.maxParam = new(int)
+	e.maxParam = new(atomic.Int32)
 	e.SetHTTPErrorHandler(e.DefaultHTTPErrorHandler)
 	e.SetBinder(NewBinder(e))
 	e.notFoundHandler = nil
 	e.renderer = nil
 	e.debug.Store(false)
 	e.router = NewRouter(e)
+	e.table.Store(&RouteTable{router: e.router})
 	e.logger = log.GetLogger("echo")
 	e.groups = make(map[string]*Group)
 	e.handlerWrapper = []func(any) Handler{}
@@ -202,12 +224,17 @@ func (e *Echo) Reset() *Echo {
 	e.FormSliceMaxIndex = 100
 	e.binderValueEncoders = DefaultBinderValueEncoders
 	e.binderValueDecoders = DefaultBinderValueDecoders
+	e.paramConstraints = make(map[string]string, len(DefaultParamConstraints))
+	for name, pattern := range DefaultParamConstraints {
+		e.paramConstraints[name] = pattern
+	}
 	e.uploadURLGenerator = DefaultUploadURLGenerator
 	e.parseHeaderAccept = false
 	e.defaultExtension = ``
 	e.maxRequestBodySize = 0
 	e.renderDataWrapper = nil
 	e.rewriter = nil
+	e.routeCheckMode = RouteCheckOff
 	e.realIPConfig = realip.New().SetIgnorePrivateIP(true)
 	e.extra = H{}
 	e.multilingual = false
@@ -279,6 +306,28 @@ func (e *Echo) CallBinderValueEncoder(name string, field string, value any, para
 	return nil, ErrNotImplemented
 }
 
+// AddParamConstraint 添加路由参数约束。
+// 注册后可在路由中使用，例如：e.AddParamConstraint(`year`, `[0-9]{4}`) => /archive/:y<year>。
+// 约束只匹配单个路径段，正则表达式中不能包含“/”
+func (e *Echo) AddParamConstraint(name string, pattern string) *Echo {
+	if strings.Contains(pattern, `/`) {
+		panic(fmt.Sprintf(`echo: param constraint %q cannot contain "/": %s`, name, pattern))
+	}
+	regexp.MustCompile(pattern)
+	e.paramConstraintMu.Lock()
+	e.paramConstraints[name] = pattern
+	e.paramConstraintMu.Unlock()
+	return e
+}
+
+// ParamConstraint 获取路由参数约束的正则表达式
+func (e *Echo) ParamConstraint(name string) (string, bool) {
+	e.paramConstraintMu.RLock()
+	pattern, ok := e.paramConstraints[name]
+	e.paramConstraintMu.RUnlock()
+	return pattern, ok
+}
+
 func (e *Echo) SetAcceptFormats(acceptFormats map[string]string) *Echo {
 	e.acceptFormats = acceptFormats
 	return e
@@ -352,6 +401,8 @@ func (e *Echo) DefaultExtension() string {
 
 // Router returns router.
 func (e *Echo) Router() *Router {
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
 	return e.router
 }
 
@@ -554,10 +605,11 @@ func (e *Echo) Trace(path string, h any, m ...any) IRouter {
 	return e.Add(TRACE, path, h, m...)
 }
 
-// Any adds a route > handler to the router for all HTTP methods.
+// Any adds a route > handler to the router for all HTTP methods
+// (the standard methods and the custom methods registered so far).
 func (e *Echo) Any(path string, h any, middleware ...any) IRouter {
 	routes := Routes{}
-	for _, m := range methods {
+	for _, m := range e.Methods() {
 		routes = append(routes, e.Add(m, path, h, middleware...))
 	}
 	return routes
@@ -662,7 +714,23 @@ func (e *Echo) addWithGroup(group *Group, host, method, prefix string, path stri
 		middleware: middleware,
 		group:      group,
 	}
+	e.routeMu.Lock()
 	e.router.routes = append(e.router.routes, r)
+	e.routesPending.Store(true)
+	if method != MethodAny && !slices.Contains(methods, method) && !slices.Contains(e.customMethods, method) {
+		e.customMethods = append(e.customMethods, method)
+	}
+	e.routeMu.Unlock()
+	return r
+}
+
+// Methods 返回标准 HTTP 方法以及已注册路由中用到的自定义方法
+func (e *Echo) Methods() []string {
+	e.routeMu.Lock()
+	r := make([]string, 0, len(methods)+len(e.customMethods))
+	r = append(r, methods...)
+	r = append(r, e.customMethods...)
+	e.routeMu.Unlock()
 	return r
 }
 
@@ -684,7 +752,7 @@ func (e *Echo) MetaHandler(m H, handler any, requests ...any) Handler {
 	for k, v := range requests {
 		switch vv := v.(type) {
 		case string:
-			if InSliceFold(vv, Methods()) {
+			if InSliceFold(vv, e.Methods()) || isMethodToken(vv) {
 				methods = append(methods, vv)
 				continue
 			}
@@ -772,44 +840,73 @@ func (e *Echo) MetaHandlerWithRequest(m H, handler any, request any, methods ...
 	return h
 }
 
-// RebuildRouter rebuild router
+// SetRouteCheckMode 设置路由冲突检查模式(默认为 RouteCheckOff)。
+// 检查需要两两比较所有路由，路由较多时会拖慢每次路由表的构建，建议只在开发环境或启动时开启
+func (e *Echo) SetRouteCheckMode(mode RouteCheckMode) *Echo {
+	e.routeCheckMode = mode
+	return e
+}
+
+// RouteCheckMode 路由冲突检查模式
+func (e *Echo) RouteCheckMode() RouteCheckMode {
+	return e.routeCheckMode
+}
+
+func (e *Echo) growMaxParam(n int) {
+	for {
+		v := e.maxParam.Load()
+		if int(v) >= n || e.maxParam.CompareAndSwap(v, int32(n)) {
+			return
+		}
+	}
+}
+
+// RouteDiagnostics 最近一次构建路由时的冲突检查结果
+func (e *Echo) RouteDiagnostics() RouteDiagnostics {
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
+	return e.router.diagnostics
+}
+
+// Build 构建路由。严格模式(RouteCheckStrict)下存在路由冲突时返回 RouteDiagnostics 错误
+func (e *Echo) Build() error {
+	return e.RebuildRouterE()
+}
+
+// RebuildRouter 重新构建路由表并原子地替换当前路由表。
+// 路由冲突只记录日志，严格模式下需要获取错误时请使用 RebuildRouterE 或 Build
 func (e *Echo) RebuildRouter(args ...[]*Route) *Echo {
+	e.RebuildRouterE(args...)
+	return e
+}
+
+// RebuildRouterE 与 RebuildRouter 相同，严格模式(RouteCheckStrict)下存在路由冲突时返回 RouteDiagnostics 错误
+// (路由表仍然会被替换)
+func (e *Echo) RebuildRouterE(args ...[]*Route) error {
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
 	routes := e.router.routes
 	if len(args) > 0 {
 		routes = args[0]
 	}
-	e.router = NewRouter(e)
-	for i, r := range routes {
-		router, _, _, _ := e.findRouter(r.Host)
-		r.apply(e)
-		router.Add(r, i)
-		if e.RouteDebug {
-			e.logger.Debugf(`Route: %7v %-30v -> %v`, r.Method, r.Host+r.Format, r.Name)
-		}
-
-		if _, ok := e.router.nroute[r.Name]; !ok {
-			e.router.nroute[r.Name] = []int{i}
-		} else {
-			e.router.nroute[r.Name] = append(e.router.nroute[r.Name], i)
-		}
+	if err := e.publishRouteTable(e.BuildRouteTable(routes)); err != nil {
+		return err
 	}
-	e.router.routes = routes
-	return e
+	if e.routeCheckMode == RouteCheckStrict && len(e.router.diagnostics) > 0 {
+		return e.router.diagnostics
+	}
+	return nil
 }
 
-// AppendRouter append router
+// AppendRouter 追加路由并重新构建路由表
 func (e *Echo) AppendRouter(routes []*Route) *Echo {
-	for i, r := range routes {
-		router, _, _, _ := e.findRouter(r.Host)
-		i = len(e.router.routes)
-		r.apply(e)
-		router.Add(r, i)
-		if _, ok := e.router.nroute[r.Name]; !ok {
-			e.router.nroute[r.Name] = []int{i}
-		} else {
-			e.router.nroute[r.Name] = append(e.router.nroute[r.Name], i)
-		}
-		e.router.routes = append(e.router.routes, r)
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
+	all := make([]*Route, 0, len(e.router.routes)+len(routes))
+	all = append(all, e.router.routes...)
+	all = append(all, routes...)
+	if err := e.publishRouteTable(e.BuildRouteTable(all)); err != nil {
+		e.logger.Error(`Route: `, err)
 	}
 	return e
 }
@@ -929,26 +1026,20 @@ func (e *Echo) findRouteBy(handler any) *Route {
 	default:
 		return nil
 	}
-	if indexes, ok := e.router.nroute[name]; ok && len(indexes) > 0 {
-		return e.router.routes[indexes[0]]
-	}
-	return nil
+	return e.RouteTable().routeByName(name)
 }
 
 // GetRoutePathByName get route path by name
 func (e *Echo) GetRoutePathByName(name string) string {
-	if indexes, ok := e.router.nroute[name]; ok && len(indexes) > 0 {
-		return e.router.routes[indexes[0]].Path
+	if r := e.RouteTable().routeByName(name); r != nil {
+		return r.Path
 	}
 	return ``
 }
 
 // GetRouteByName get route by name
 func (e *Echo) GetRouteByName(name string) *Route {
-	if indexes, ok := e.router.nroute[name]; ok && len(indexes) > 0 {
-		return e.router.routes[indexes[0]]
-	}
-	return nil
+	return e.RouteTable().routeByName(name)
 }
 
 // URL is an alias for `URI` function.
@@ -1001,11 +1092,15 @@ func (e *Echo) URIAddLangCode(c Context, uri string) string {
 
 // Routes returns the registered routes.
 func (e *Echo) Routes() []*Route {
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
 	return e.router.routes
 }
 
 // NamedRoutes returns the registered handler name.
 func (e *Echo) NamedRoutes() map[string][]int {
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
 	return e.router.nroute
 }
 
@@ -1017,7 +1112,9 @@ func (e *Echo) applyMiddleware(h Handler, middlewares []Middleware) Handler {
 }
 
 func (e *Echo) buildHandler(c Context) Handler {
-	if r, names, values, exist := e.findRouter(c.Host()); exist {
+	t := e.RouteTable()
+	c.Object().setRouteTable(t)
+	if r, names, values, exist := t.findRouter(c.Host()); exist {
 		if len(names) > 0 {
 			c.SetHostParamNames(names...)
 			c.SetHostParamValues(values...)
@@ -1029,17 +1126,39 @@ func (e *Echo) buildHandler(c Context) Handler {
 			}), e.middleware)
 		}
 		if !found {
-			return e.applyMiddleware(e.router.Handle(c), e.middleware)
+			return e.applyRouteMiddleware(c, t.router.Handle(c))
 		}
-		return e.applyMiddleware(r.Handle(c), e.middleware)
+		return e.applyRouteMiddleware(c, r.Handle(c))
+	}
+	return e.applyRouteMiddleware(c, t.router.Handle(c))
+}
+
+func (e *Echo) applyRouteMiddleware(c Context, h Handler) Handler {
+	if c.Route().isolated {
+		return h
 	}
-	return e.applyMiddleware(e.router.Handle(c), e.middleware)
+	return e.applyMiddleware(h, e.middleware)
 }
 
 func (e *Echo) ServeHTTP(req engine.Request, res engine.Response) {
 	c := e.pool.Get().(Context)
 	c.Reset(req, res)
+	e.serve(c)
+	c.FireRelease()
+	e.pool.Put(c)
+}
+
+// serveMounted 处理挂载到父级 Echo 中的请求(见 Echo.Mount)
+func (e *Echo) serveMounted(parent Context) {
+	c := e.pool.Get().(Context)
+	c.Reset(parent.Request(), parent.Response())
+	c.Object().realIP = parent.RealIP()
+	e.serve(c)
+	c.FireRelease()
+	e.pool.Put(c)
+}
 
+func (e *Echo) serve(c Context) {
 	var h Handler
 	if len(e.premiddleware) > 0 {
 		h = e.applyMiddleware(HandlerFunc(func(c Context) error {
@@ -1051,20 +1170,22 @@ func (e *Echo) ServeHTTP(req engine.Request, res engine.Response) {
 	if err := h.Handle(c); err != nil {
 		c.Error(err)
 	}
-
-	c.FireRelease()
-	e.pool.Put(c)
 }
 
 // Run starts the HTTP engine.
 func (e *Echo) Run(eng engine.Engine, handler ...engine.Handler) error {
-	err := e.buildRouter().setEngine(eng).start(handler...)
+	if err := e.Build(); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	err := e.setEngine(eng).start(handler...)
 	if err != nil {
 		fmt.Println(err)
 	}
 	return err
 }
 
+// Commit 构建路由。路由冲突只记录日志，严格模式下需要获取错误时请使用 Build
 func (e *Echo) Commit() *Echo {
 	e.buildRouter()
 	return e
@@ -1107,37 +1228,6 @@ func (e *Echo) Shutdown(ctx context.Context) error {
 	return e.engine.Shutdown(ctx)
 }
 
-func (e *Echo) findRouter(host string) (*Router, []string, []string, bool) {
-	if len(e.hosts) == 0 {
-		return e.router, nil, nil, false
-	}
-	if r, ok := e.hosts[host]; ok {
-		return r.Router, nil, nil, true
-	}
-	l := len(host)
-	for h, r := range e.hosts {
-		if r.group != nil && r.group.host != nil {
-			values, hasExpr := r.group.host.Match(host)
-			if hasExpr {
-				if len(values) > 0 {
-					return r.Router, r.group.host.names, values, true
-				}
-				continue
-			}
-		}
-		if l <= len(h) {
-			continue
-		}
-		if h[0] == '.' && strings.HasSuffix(host, h) { //.host(xxx.host)
-			return r.Router, nil, nil, true
-		}
-		if h[len(h)-1] == '.' && strings.HasPrefix(host, h) { //host.(host.xxx)
-			return r.Router, nil, nil, true
-		}
-	}
-	return e.router, nil, nil, false
-}
-
 func (e *Echo) NewContext(req engine.Request, resp engine.Response) Context {
 	return NewContext(req, resp, e)
 }
@@ -37,7 +37,9 @@ func (h *Host) Host(args ...any) (r TypeHost) {
 		return
 	}
 	r.echo = h.group.echo
+	r.echo.routeMu.Lock()
 	r.router = h.Router
+	r.echo.routeMu.Unlock()
 	if len(args) != 1 {
 		r.host = h.group.host.Format(args...)
 		return
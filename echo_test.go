@@ -7,12 +7,16 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/admpub/fasthttp"
 	"github.com/admpub/log"
 	"github.com/stretchr/testify/assert"
 
 	. "github.com/webx-top/echo"
+	"github.com/webx-top/echo/code"
+	fasthttpng "github.com/webx-top/echo/engine/fasthttp"
 	mw "github.com/webx-top/echo/middleware"
 	test "github.com/webx-top/echo/testing"
 )
@@ -284,6 +288,51 @@ func TestEchoRouter(t *testing.T) {
 	assert.Equal(t, "123", b)
 }
 
+func TestEchoCustomMethod(t *testing.T) {
+	e := New()
+
+	e.Route(`PROPFIND,MKCOL`, "/dav/*", func(c Context) error {
+		return c.String(c.Request().Method() + `:` + c.P(0))
+	})
+	e.Match([]string{`PURGE`, GET}, "/cache/:key", func(c Context) error {
+		return c.String(c.Request().Method() + `:` + c.Param(`key`))
+	})
+	assert.Equal(t, append(append([]string{}, Methods()...), `PROPFIND`, `MKCOL`, `PURGE`), e.Methods())
+	e.Any("/any", func(c Context) error {
+		return c.String(c.Request().Method())
+	})
+	assert.NotPanics(t, func() {
+		e.Add(`REPORT`, "/report", e.MakeHandler(func(c Context) error {
+			return c.String(c.Request().Method())
+		}, `REPORT`))
+	})
+	e.RebuildRouter()
+
+	c, b := request(`PROPFIND`, "/dav/docs/a.txt", e)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "PROPFIND:docs/a.txt", b)
+	c, b = request(`MKCOL`, "/dav/docs", e)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "MKCOL:docs", b)
+	c, b = request(`PURGE`, "/cache/home", e)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "PURGE:home", b)
+	c, b = request(`PURGE`, "/any", e)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "PURGE", b)
+	c, b = request(`REPORT`, "/report", e)
+	assert.Equal(t, http.StatusOK, c)
+	assert.Equal(t, "REPORT", b)
+
+	rec := test.Request(`LOCK`, "/cache/home", e)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, `GET, PURGE`, rec.Header().Get(HeaderAllow))
+
+	rec = test.Request(POST, "/dav/docs", e)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, `MKCOL, PROPFIND`, rec.Header().Get(HeaderAllow))
+}
+
 func TestEchoRealIP(t *testing.T) {
 	e := New()
 
@@ -390,3 +439,505 @@ func TestMultilevelGroupMiddleware(t *testing.T) {
 	assert.Equal(t, `2`, b)
 	assert.Equal(t, `m1-m3`, buf.String())
 }
+
+func TestEchoRouteTableSwap(t *testing.T) {
+	e := New()
+	version := func(c Context) error {
+		return c.String(fmt.Sprintf(`%d:%s`, c.Object().RouteVersion(), c.Route().Path))
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e.Get(`/slow`, func(c Context) error {
+		close(started)
+		<-release
+		return version(c)
+	})
+	e.Get(`/a`, version).SetName(`a`)
+	admin := e.Group(`/admin`)
+	admin.Get(`/users`, version)
+	admin.Group(`/sub`).Get(`/x`, version)
+	e.Commit()
+	v1 := e.RouteTable().Version()
+
+	code, body := request(GET, `/a`, e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, fmt.Sprintf(`%d:/a`, v1), body)
+
+	// 正在处理中的请求使用旧的路由表
+	done := make(chan string)
+	go func() {
+		_, body := request(GET, `/slow`, e)
+		done <- body
+	}()
+	<-started
+	routes := append([]*Route{}, e.Routes()...)
+	routes = append(routes, &Route{Method: GET, Path: `/b/:p1/:p2/:p3/:p4`, Handler: HandlerFunc(version)})
+	table := e.BuildRouteTable(routes)
+	assert.Equal(t, v1, e.RouteTable().Version())
+	assert.NoError(t, e.PublishRouteTable(table))
+	v2 := e.RouteTable().Version()
+	assert.Greater(t, v2, v1)
+	close(release)
+	assert.Equal(t, fmt.Sprintf(`%d:/slow`, v1), <-done)
+
+	code, body = request(GET, `/b/1/2/3/4`, e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, fmt.Sprintf(`%d:/b/:p1/:p2/:p3/:p4`, v2), body)
+
+	// 按名称删除
+	assert.Equal(t, 1, e.RemoveRoute(`a`))
+	code, _ = request(GET, `/a`, e)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Nil(t, e.GetRouteByName(`a`))
+
+	// 按路由组删除
+	assert.Equal(t, 2, e.RemoveGroup(`/admin`))
+	code, _ = request(GET, `/admin/users`, e)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = request(GET, `/admin/sub/x`, e)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, 0, e.RemoveGroup(`/admin`))
+
+	code, body = request(GET, `/b/1/2/3/4`, e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, fmt.Sprintf(`%d:/b/:p1/:p2/:p3/:p4`, e.RouteTable().Version()), body)
+}
+
+func TestEchoRouteTableHost(t *testing.T) {
+	e := New()
+	e.Host(`blog.example.com`).Get(`/`, func(c Context) error {
+		return c.String(`blog`)
+	})
+	e.Get(`/`, func(c Context) error {
+		return c.String(`main`)
+	})
+	e.Commit()
+	setHost := func(host string) func(*http.Request) {
+		return func(r *http.Request) { r.Host = host }
+	}
+	_, body := request(GET, `/`, e, setHost(`blog.example.com`))
+	assert.Equal(t, `blog`, body)
+
+	old := e.RouteTable()
+	e.Host(`shop.example.com`).Get(`/`, func(c Context) error {
+		return c.String(`shop`)
+	})
+	// 新的主机路由在发布前不生效
+	_, body = request(GET, `/`, e, setHost(`shop.example.com`))
+	assert.Equal(t, `main`, body)
+	e.RebuildRouter()
+	_, body = request(GET, `/`, e, setHost(`shop.example.com`))
+	assert.Equal(t, `shop`, body)
+	assert.NotNil(t, e.RouteTable().HostRouter(`shop.example.com`))
+	assert.Nil(t, old.HostRouter(`shop.example.com`))
+}
+
+func TestEchoRouteTableConcurrent(t *testing.T) {
+	e := New()
+	e.Get(`/users/:id`, func(c Context) error {
+		return c.String(c.Param(`id`))
+	})
+	e.Commit()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				code, body := request(GET, `/users/1`, e)
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, `1`, body)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			_ = len(e.Routes())
+			_ = e.Router()
+			_ = e.RouteDiagnostics()
+		}
+	}()
+	for j := 0; j < 20; j++ {
+		e.Get(fmt.Sprintf(`/new/%d`, j), func(c Context) error { return nil })
+		e.RebuildRouter()
+	}
+	wg.Wait()
+}
+
+func TestEchoPublishOutdatedRouteTable(t *testing.T) {
+	e := New()
+	e.Get(`/a`, func(c Context) error { return c.String(`a`) })
+	older := e.BuildRouteTable(e.Routes())
+	newer := e.BuildRouteTable(e.Routes())
+	assert.NoError(t, e.PublishRouteTable(newer))
+	assert.ErrorIs(t, e.PublishRouteTable(older), ErrRouteTableOutdated)
+	assert.ErrorIs(t, e.PublishRouteTable(newer), ErrRouteTableOutdated)
+	assert.Equal(t, newer.Version(), e.RouteTable().Version())
+}
+
+func TestEchoRemoveHostGroup(t *testing.T) {
+	e := New()
+	e.Host(`blog.example.com`).Group(`/admin`).Get(`/users`, func(c Context) error {
+		return c.String(`blog`)
+	})
+	e.Group(`/admin`).Get(`/users`, func(c Context) error {
+		return c.String(`main`)
+	})
+	e.Commit()
+	setHost := func(r *http.Request) { r.Host = `blog.example.com` }
+	_, body := request(GET, `/admin/users`, e, setHost)
+	assert.Equal(t, `blog`, body)
+
+	assert.Equal(t, 2, e.RemoveGroup(`/admin`))
+	code, _ := request(GET, `/admin/users`, e, setHost)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = request(GET, `/admin/users`, e)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestEchoMount(t *testing.T) {
+	e := New()
+	var trace []string
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			trace = append(trace, `parent`)
+			return next(c)
+		}
+	})
+
+	// http.Handler
+	std := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `%s|%s|%s|%s`, r.URL.Path, r.RequestURI, r.Header.Get(HeaderXForwardedPrefix), r.Header.Get(HeaderXRealIP))
+	})
+	g := e.Group(`/tenant/:id`)
+	g.Mount(`/std`, std)
+
+	// *Echo
+	child := New()
+	child.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			trace = append(trace, `child`)
+			return next(c)
+		}
+	})
+	child.Get(`/`, func(c Context) error {
+		return c.String(`index`)
+	})
+	child.Get(`/users/:name`, func(c Context) error {
+		return c.String(c.Request().URL().Path() + `|` + c.Param(`name`) + `|` + c.Query(`q`) + `|` + c.RealIP())
+	})
+	e.Mount(`/child`, child)
+	e.Mount(`/inherit/`, child, true)
+	e.Get(`/path`, func(c Context) error {
+		return c.String(c.Request().URL().Path())
+	})
+	e.Commit()
+
+	setIP := func(r *http.Request) {
+		r.RemoteAddr = `10.0.0.1:1234`
+	}
+	trace = nil
+	code, body := request(GET, `/tenant/1/std/a/b?x=1`, e, setIP)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `/a/b|/a/b?x=1|/tenant/1/std|10.0.0.1`, body)
+	assert.Equal(t, []string{`parent`}, trace)
+
+	_, body = request(GET, `/tenant/1/std`, e)
+	assert.True(t, strings.HasPrefix(body, `/|/|/tenant/1/std|`))
+
+	trace = nil
+	code, body = request(GET, `/child/users/tom?q=go`, e, setIP)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `/users/tom|tom|go|10.0.0.1`, body)
+	assert.Equal(t, []string{`child`}, trace)
+
+	_, body = request(GET, `/child`, e)
+	assert.Equal(t, `index`, body)
+	code, _ = request(GET, `/child/none`, e)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	trace = nil
+	_, body = request(GET, `/inherit/`, e)
+	assert.Equal(t, `index`, body)
+	assert.Equal(t, []string{`parent`, `child`}, trace)
+
+	_, body = request(GET, `/path`, e)
+	assert.Equal(t, `/path`, body)
+
+	assert.Panics(t, func() {
+		e.Mount(`/bad`, 1)
+	})
+}
+
+func TestEchoMountAnyMethod(t *testing.T) {
+	e := New()
+	var trace []string
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			trace = append(trace, `parent`)
+			return next(c)
+		}
+	})
+	dav := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `%s %s`, r.Method, r.URL.Path)
+	})
+	e.Mount(`/dav`, dav)
+
+	child := New()
+	child.Get(`/`, func(c Context) error {
+		return c.String(`index`)
+	})
+	g := e.Group(`/admin`, func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			trace = append(trace, `group`)
+			return next(c)
+		}
+	})
+	g.Mount(`/child`, child)
+	e.Commit()
+
+	code, body := request(`PROPFIND`, `/dav/docs/a.txt`, e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `PROPFIND /docs/a.txt`, body)
+	code, body = request(`MKCOL`, `/dav`, e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `MKCOL /`, body)
+
+	// 隔离模式下只跳过父级 Echo 的 Use 中间件，路由组的中间件仍然有效
+	trace = nil
+	_, body = request(GET, `/admin/child`, e)
+	assert.Equal(t, `index`, body)
+	assert.Equal(t, []string{`group`}, trace)
+
+	// 挂载之后添加到子 Echo 的路由
+	child.Add(`PURGE`, `/cache`, func(c Context) error {
+		return c.String(`purged`)
+	})
+	code, body = request(`PURGE`, `/admin/child/cache`, e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `purged`, body)
+	code, _ = request(POST, `/admin/child/cache`, e)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestEchoMountFastHTTP(t *testing.T) {
+	e := New()
+	e.Mount(`/dav`, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `%s %s %s`, r.Method, r.URL.RequestURI(), r.Header.Get(HeaderXForwardedPrefix))
+	})
+	child := New()
+	child.Get(`/users/:name`, func(c Context) error {
+		return c.String(c.Request().URL().Path() + `|` + c.Param(`name`) + `|` + c.Query(`q`))
+	})
+	e.Mount(`/child`, child)
+	e.Commit()
+
+	serve := func(method, uri string) (int, string) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(uri)
+		req := fasthttpng.NewRequest(ctx)
+		e.ServeHTTP(req, fasthttpng.NewResponse(req))
+		return ctx.Response.StatusCode(), string(ctx.Response.Body())
+	}
+
+	code, body := serve(`PROPFIND`, `/dav/docs/a.txt?depth=1`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `PROPFIND /docs/a.txt?depth=1 /dav`, body)
+
+	code, body = serve(GET, `/child/users/tom?q=go`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `/users/tom|tom|go`, body)
+
+	child.Get(`/later`, func(c Context) error {
+		return c.String(`later`)
+	})
+	code, body = serve(GET, `/child/later`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `later`, body)
+}
+
+func TestEchoProblemDetails(t *testing.T) {
+	e := New()
+	cfg := NewProblemConfig()
+	cfg.SetCodeType(code.DataNotFound, `https://example.com/problems/not-found`, `Resource not found`)
+	cfg.SetStatusType(http.StatusConflict, `https://example.com/problems/conflict`, `Conflict`)
+	e.SetHTTPErrorHandler(ProblemHTTPErrorHandler(cfg))
+	e.Get(`/error`, func(c Context) error {
+		return NewError(`user 1 not found`, code.DataNotFound).SetZone(`id`).Set(`retry`, false)
+	})
+	e.Get(`/http`, func(c Context) error {
+		return NewHTTPError(http.StatusConflict, `version mismatch`)
+	})
+	e.Get(`/valid`, func(c Context) error {
+		return NewValidateResult().SetError(errors.New(`must be positive`)).SetField(`age`).AsError()
+	})
+	e.Get(`/plain`, func(c Context) error {
+		c.Response().Header().Set(HeaderXRequestID, `abc`)
+		return errors.New(`secret`)
+	})
+	e.Commit()
+	acceptJSON := func(r *http.Request) {
+		r.Header.Set(HeaderAccept, MIMEApplicationProblemJSON)
+	}
+
+	rec := test.Request(GET, `/error`, e, acceptJSON)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(HeaderContentType))
+	assert.JSONEq(t, `{
+		"type":"https://example.com/problems/not-found",
+		"title":"Resource not found",
+		"status":404,
+		"detail":"user 1 not found",
+		"code":`+fmt.Sprint(code.DataNotFound.Int())+`,
+		"retry":false,
+		"invalid-params":[{"name":"id","reason":"user 1 not found"}]
+	}`, rec.Body.String())
+
+	rec = test.Request(GET, `/http`, e, acceptJSON)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.JSONEq(t, `{"type":"https://example.com/problems/conflict","title":"Conflict","status":409,"detail":"version mismatch"}`, rec.Body.String())
+
+	rec = test.Request(GET, `/valid`, e, acceptJSON)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Bad Request","status":400,"detail":"must be positive","invalid-params":[{"name":"age","reason":"must be positive"}]}`, rec.Body.String())
+
+	rec = test.Request(GET, `/plain`, e, acceptJSON)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"urn:request-id:abc"}`, rec.Body.String())
+
+	rec = test.Request(GET, `/none`, e, acceptJSON)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404}`, rec.Body.String())
+
+	// HTML 请求使用 fallback
+	rec = test.Request(GET, `/http`, e, func(r *http.Request) {
+		r.Header.Set(HeaderAccept, MIMETextHTML)
+	})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, `version mismatch`, rec.Body.String())
+}
+
+type testValidAddress struct {
+	Zip string `valid:"Required;Length(6)"`
+}
+
+type testValidProfile struct {
+	Age       int `valid:"Range(18,60)"`
+	Addresses []*testValidAddress
+}
+
+type testValidForm struct {
+	Name    string `valid:"Required"`
+	Profile *testValidProfile
+}
+
+type testValidTranslator struct {
+	NopTranslate
+}
+
+func (t *testValidTranslator) T(format string, args ...any) string {
+	return `T(` + fmt.Sprintf(format, args...) + `)`
+}
+
+func TestEchoValidationErrors(t *testing.T) {
+	e := New()
+	e.Use(func(h Handler) HandlerFunc {
+		return func(c Context) error {
+			c.SetValidator(NewValidation())
+			if c.Query(`translate`) == `1` {
+				c.SetTranslator(&testValidTranslator{})
+			}
+			return h.Handle(c)
+		}
+	})
+	form := func() *testValidForm {
+		return &testValidForm{
+			Profile: &testValidProfile{
+				Age: 10,
+				Addresses: []*testValidAddress{
+					{Zip: `100000`},
+					{Zip: `1`},
+					{},
+				},
+			},
+		}
+	}
+	e.Get(`/data`, func(c Context) error {
+		return c.JSON(c.Data().SetError(c.Validate(form())))
+	})
+	e.Get(`/error`, func(c Context) error {
+		return DetectError(c, c.Validate(form()))
+	})
+	e.Commit()
+
+	errs := NewValidation().Validate(form()).(*ValidationErrors)
+	assert.False(t, errs.Ok())
+	var paths []string
+	for _, fe := range errs.Errors() {
+		paths = append(paths, fe.Field+`|`+fe.Rule)
+	}
+	assert.Equal(t, []string{
+		`Name|Required`,
+		`Profile.Age|Range`,
+		`Profile.Addresses[1].Zip|Length`,
+		`Profile.Addresses[2].Zip|Required`,
+		`Profile.Addresses[2].Zip|Length`,
+	}, paths)
+	assert.Equal(t, []any{int64(18), int64(60)}, errs.Errors()[1].Params)
+	assert.Equal(t, `Range is 18 to 60`, errs.Errors()[1].Message)
+	assert.Equal(t, `Name`, errs.Field())
+	assert.Equal(t, `Name: Can not be empty`, errs.Error())
+	assert.Equal(t, `Required length is 6`, errs.Map()[`Profile.Addresses[1].Zip`])
+
+	// 只验证指定字段
+	errs = NewValidation().Validate(form(), `Profile`).(*ValidationErrors)
+	assert.Len(t, errs.Errors(), 4)
+	assert.Equal(t, `Profile.Age`, errs.Field())
+
+	// 使用其它的字段名称格式
+	errs = NewValidation().(*Validation).SetFieldNameFormatter(LowerCaseFirstLetter).Validate(form()).(*ValidationErrors)
+	assert.Equal(t, `profile.addresses[2].zip`, errs.Errors()[3].Field)
+
+	errs = NewValidation().Validate(&testValidForm{Name: `ok`, Profile: &testValidProfile{Age: 18}}).(*ValidationErrors)
+	assert.True(t, errs.Ok())
+	assert.NoError(t, errs.AsError())
+
+	status, body := request(GET, `/data?translate=1`, e)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{
+		"Code":`+fmt.Sprint(code.InvalidParameter.Int())+`,
+		"State":"`+code.InvalidParameter.String()+`",
+		"Info":"Name: T(Can not be empty)",
+		"Zone":"Name",
+		"Data":[
+			{"field":"Name","rule":"Required","message":"T(Can not be empty)"},
+			{"field":"Profile.Age","rule":"Range","params":[18,60],"message":"T(Range is 18 to 60)"},
+			{"field":"Profile.Addresses[1].Zip","rule":"Length","params":[6],"message":"T(Required length is 6)"},
+			{"field":"Profile.Addresses[2].Zip","rule":"Required","message":"T(Can not be empty)"},
+			{"field":"Profile.Addresses[2].Zip","rule":"Length","params":[6],"message":"T(Required length is 6)"}
+		]
+	}`, body)
+
+	e.SetHTTPErrorHandler(ProblemHTTPErrorHandler(nil))
+	rec := test.Request(GET, `/error`, e, func(r *http.Request) {
+		r.Header.Set(HeaderAccept, MIMEApplicationJSON)
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{
+		"type":"about:blank",
+		"title":"Bad Request",
+		"status":400,
+		"detail":"Name: Can not be empty",
+		"code":`+fmt.Sprint(code.InvalidParameter.Int())+`,
+		"invalid-params":[
+			{"name":"Name","reason":"Can not be empty"},
+			{"name":"Profile.Age","reason":"Range is 18 to 60"},
+			{"name":"Profile.Addresses[1].Zip","reason":"Required length is 6"},
+			{"name":"Profile.Addresses[2].Zip","reason":"Can not be empty"},
+			{"name":"Profile.Addresses[2].Zip","reason":"Required length is 6"}
+		]
+	}`, rec.Body.String())
+}
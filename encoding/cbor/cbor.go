@@ -0,0 +1,48 @@
+// Package cbor 提供 CBOR 格式的请求正文解码器和响应输出(需要通过 Register 启用)
+package cbor
+
+import (
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/webx-top/echo"
+)
+
+// MIMEs 使用 CBOR 格式的 MIME 类型
+var MIMEs = []string{echo.MIMEApplicationCBOR}
+
+// Decoder 请求正文解码器
+var Decoder = echo.NewBodyDecoder(Unmarshal)
+
+var decMode, _ = cbor.DecOptions{
+	DefaultMapType: reflect.TypeOf(map[string]any(nil)),
+}.DecMode()
+
+// Unmarshal 解码 CBOR 数据(没有 cbor 标签时使用 json 标签)。map 类型的数据默认解码为 map[string]any
+func Unmarshal(data []byte, v any) error {
+	return decMode.Unmarshal(data, v)
+}
+
+// Marshal 编码为 CBOR 数据(没有 cbor 标签时使用 json 标签)
+func Marshal(v any) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+// Render 以 CBOR 格式输出 c.Data()
+func Render(c echo.Context, data any, code ...int) error {
+	b, err := Marshal(c.Data())
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationCBOR)
+	return c.Blob(b, code...)
+}
+
+// Register 注册 CBOR 格式的请求正文解码器、Accept 格式以及响应输出
+func Register(e *echo.Echo) {
+	for _, mime := range MIMEs {
+		e.AddAcceptFormat(mime, echo.ContentTypeCBOR)
+		e.AddBinderDecoder(mime, Decoder)
+	}
+	e.AddFormatRenderer(echo.ContentTypeCBOR, Render)
+}
@@ -0,0 +1,62 @@
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/code"
+	test "github.com/webx-top/echo/testing"
+)
+
+type testReading struct {
+	Sensor string             `json:"sensor"`
+	Values map[string]float64 `json:"values"`
+}
+
+func TestCBOR(t *testing.T) {
+	e := echo.New()
+	Register(e)
+	e.Post(`/reading`, func(c echo.Context) error {
+		m := &testReading{}
+		if err := c.MustBind(m); err != nil {
+			return err
+		}
+		return c.SetAuto(true).Render(`reading`, m)
+	})
+	e.Post(`/filter`, func(c echo.Context) error {
+		m := &testReading{}
+		if err := c.MustBind(m, echo.ExcludeFieldName(`Sensor`)); err != nil {
+			return err
+		}
+		return c.JSON(m)
+	})
+	e.Commit()
+
+	body, err := Marshal(map[string]any{`sensor`: `t1`, `values`: map[string]any{`temp`: 21.5}})
+	assert.NoError(t, err)
+	rec := test.Request(echo.POST, `/reading`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationCBOR)
+		r.Header.Set(echo.HeaderAccept, echo.MIMEApplicationCBOR)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationCBOR, rec.Header().Get(echo.HeaderContentType))
+	var resp struct {
+		Code code.Code
+		Data *testReading
+	}
+	assert.NoError(t, Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, code.Success, resp.Code)
+	assert.Equal(t, &testReading{Sensor: `t1`, Values: map[string]float64{`temp`: 21.5}}, resp.Data)
+
+	rec = test.Request(echo.POST, `/filter`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationCBOR)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"sensor":"","values":{"temp":21.5}}`, rec.Body.String())
+}
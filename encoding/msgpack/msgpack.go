@@ -0,0 +1,53 @@
+// Package msgpack 提供 MessagePack 格式的请求正文解码器和响应输出(需要通过 Register 启用)
+package msgpack
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/webx-top/echo"
+)
+
+// MIMEs 使用 MessagePack 格式的 MIME 类型
+var MIMEs = []string{echo.MIMEApplicationMsgpack, echo.MIMEApplicationXMsgpack}
+
+// Decoder 请求正文解码器
+var Decoder = echo.NewBodyDecoder(Unmarshal)
+
+// Unmarshal 解码 MessagePack 数据(没有 msgpack 标签时使用 json 标签)
+func Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag(`json`)
+	return dec.Decode(v)
+}
+
+// Marshal 编码为 MessagePack 数据(没有 msgpack 标签时使用 json 标签)
+func Marshal(v any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := msgpack.NewEncoder(buf)
+	enc.SetCustomStructTag(`json`)
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Render 以 MessagePack 格式输出 c.Data()
+func Render(c echo.Context, data any, code ...int) error {
+	b, err := Marshal(c.Data())
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationMsgpack)
+	return c.Blob(b, code...)
+}
+
+// Register 注册 MessagePack 格式的请求正文解码器、Accept 格式以及响应输出
+func Register(e *echo.Echo) {
+	for _, mime := range MIMEs {
+		e.AddAcceptFormat(mime, echo.ContentTypeMsgpack)
+		e.AddBinderDecoder(mime, Decoder)
+	}
+	e.AddFormatRenderer(echo.ContentTypeMsgpack, Render)
+}
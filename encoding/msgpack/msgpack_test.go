@@ -0,0 +1,70 @@
+package msgpack
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/code"
+	test "github.com/webx-top/echo/testing"
+)
+
+type testDevice struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Tags []string
+}
+
+func TestMsgpack(t *testing.T) {
+	e := echo.New()
+	Register(e)
+	e.Post(`/device`, func(c echo.Context) error {
+		m := &testDevice{}
+		if err := c.MustBind(m); err != nil {
+			return err
+		}
+		return c.SetAuto(true).Render(`device`, m)
+	})
+	e.Post(`/filter`, func(c echo.Context) error {
+		m := &testDevice{}
+		err := c.MustBind(m, func(key string, values []string) (string, []string) {
+			if key == `Name` {
+				return key, []string{strings.ToUpper(values[0])}
+			}
+			return key, values
+		})
+		if err != nil {
+			return err
+		}
+		return c.String(m.Name + `|` + strings.Join(m.Tags, `,`))
+	})
+	e.Commit()
+
+	body, err := Marshal(echo.H{`id`: 1, `name`: `sensor`, `Tags`: []string{`a`, `b`}})
+	assert.NoError(t, err)
+	rec := test.Request(echo.POST, `/device`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationMsgpack)
+		r.Header.Set(echo.HeaderAccept, echo.MIMEApplicationMsgpack)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationMsgpack, rec.Header().Get(echo.HeaderContentType))
+	var resp struct {
+		Code code.Code
+		Data *testDevice
+	}
+	assert.NoError(t, Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, code.Success, resp.Code)
+	assert.Equal(t, &testDevice{ID: 1, Name: `sensor`, Tags: []string{`a`, `b`}}, resp.Data)
+
+	rec = test.Request(echo.POST, `/filter`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationXMsgpack)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `SENSOR|a,b`, rec.Body.String())
+}
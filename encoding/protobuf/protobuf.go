@@ -0,0 +1,102 @@
+// Package protobuf 提供 Protobuf 格式的请求正文解码器和响应输出(需要通过 Register 启用)。
+// 只支持实现了 proto.Message 接口的数据
+package protobuf
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/webx-top/echo"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MIMEs 使用 Protobuf 格式的 MIME 类型
+var MIMEs = []string{echo.MIMEApplicationXProtobuf, echo.MIMEApplicationProtobuf}
+
+// Decoder 请求正文解码器。i 必须实现 proto.Message 接口；
+// 指定了 FormDataFilter 或 BinderValueCustomDecoders 时，以字段的 JSON 名称映射到 i
+func Decoder(i any, ctx echo.Context, valueDecoders echo.BinderValueCustomDecoders, filter ...echo.FormDataFilter) error {
+	msg, ok := i.(proto.Message)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf(`protobuf: %T does not implement proto.Message`, i))
+	}
+	return echo.NewBodyDecoder(func(data []byte, v any) error {
+		m, ok := v.(*map[string]any)
+		if !ok {
+			return proto.Unmarshal(data, msg)
+		}
+		tmp := msg.ProtoReflect().New()
+		if err := proto.Unmarshal(data, tmp.Interface()); err != nil {
+			return err
+		}
+		*m = messageToMap(tmp)
+		return nil
+	})(i, ctx, valueDecoders, filter...)
+}
+
+// messageToMap 以字段的 JSON 名称(例如：userName)作为 key 转换为 map
+func messageToMap(msg protoreflect.Message) map[string]any {
+	r := map[string]any{}
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		r[fd.JSONName()] = fieldValue(fd, v)
+		return true
+	})
+	return r
+}
+
+func fieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		r := make([]any, list.Len())
+		for i := range r {
+			r[i] = singularValue(fd, list.Get(i))
+		}
+		return r
+	case fd.IsMap():
+		r := map[string]any{}
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			r[k.String()] = singularValue(fd.MapValue(), mv)
+			return true
+		})
+		return r
+	}
+	return singularValue(fd, v)
+}
+
+func singularValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageToMap(v.Message())
+	case protoreflect.EnumKind:
+		return int32(v.Enum())
+	}
+	return v.Interface()
+}
+
+// Render 以 Protobuf 格式输出 data 或 c.Data().GetData()(必须实现 proto.Message 接口)
+func Render(c echo.Context, data any, code ...int) error {
+	msg, ok := data.(proto.Message)
+	if !ok {
+		msg, ok = c.Data().GetData().(proto.Message)
+	}
+	if !ok {
+		return echo.ErrUnsupportedRenderData
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXProtobuf)
+	return c.Blob(b, code...)
+}
+
+// Register 注册 Protobuf 格式的请求正文解码器、Accept 格式以及响应输出
+func Register(e *echo.Echo) {
+	for _, mime := range MIMEs {
+		e.AddAcceptFormat(mime, echo.ContentTypeProtobuf)
+		e.AddBinderDecoder(mime, Decoder)
+	}
+	e.AddFormatRenderer(echo.ContentTypeProtobuf, Render)
+}
@@ -0,0 +1,67 @@
+package protobuf
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtobuf(t *testing.T) {
+	e := echo.New()
+	Register(e)
+	e.Post(`/echo`, func(c echo.Context) error {
+		m := &wrapperspb.StringValue{}
+		if err := c.MustBind(m); err != nil {
+			return err
+		}
+		return c.SetAuto(true).Render(`echo`, m)
+	})
+	e.Post(`/filter`, func(c echo.Context) error {
+		m := &wrapperspb.StringValue{}
+		err := c.MustBind(m, func(key string, values []string) (string, []string) {
+			return key, []string{strings.ToUpper(values[0])}
+		})
+		if err != nil {
+			return err
+		}
+		return c.String(m.GetValue())
+	})
+	e.Post(`/invalid`, func(c echo.Context) error {
+		return c.MustBind(&struct{}{})
+	})
+	e.Commit()
+
+	body, err := proto.Marshal(wrapperspb.String(`hello`))
+	assert.NoError(t, err)
+	rec := test.Request(echo.POST, `/echo`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationXProtobuf)
+		r.Header.Set(echo.HeaderAccept, echo.MIMEApplicationXProtobuf)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationXProtobuf, rec.Header().Get(echo.HeaderContentType))
+	resp := &wrapperspb.StringValue{}
+	assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), resp))
+	assert.Equal(t, `hello`, resp.GetValue())
+
+	rec = test.Request(echo.POST, `/filter`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationXProtobuf)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `HELLO`, rec.Body.String())
+
+	rec = test.Request(echo.POST, `/invalid`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationXProtobuf)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	})
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
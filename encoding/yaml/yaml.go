@@ -0,0 +1,32 @@
+// Package yaml 提供 YAML 格式的请求正文解码器和响应输出(需要通过 Register 启用)
+package yaml
+
+import (
+	"github.com/webx-top/echo"
+	"gopkg.in/yaml.v3"
+)
+
+// MIMEs 使用 YAML 格式的 MIME 类型
+var MIMEs = []string{echo.MIMEApplicationYAML, echo.MIMEApplicationXYAML, echo.MIMETextYAML}
+
+// Decoder 请求正文解码器
+var Decoder = echo.NewBodyDecoder(yaml.Unmarshal)
+
+// Render 以 YAML 格式输出 c.Data()
+func Render(c echo.Context, data any, code ...int) error {
+	b, err := yaml.Marshal(c.Data())
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationYAML+`; `+echo.CharsetUTF8)
+	return c.Blob(b, code...)
+}
+
+// Register 注册 YAML 格式的请求正文解码器、Accept 格式以及响应输出
+func Register(e *echo.Echo) {
+	for _, mime := range MIMEs {
+		e.AddAcceptFormat(mime, echo.ContentTypeYAML)
+		e.AddBinderDecoder(mime, Decoder)
+	}
+	e.AddFormatRenderer(echo.ContentTypeYAML, Render)
+}
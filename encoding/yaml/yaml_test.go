@@ -0,0 +1,44 @@
+package yaml
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+	"gopkg.in/yaml.v3"
+)
+
+type testConfig struct {
+	Name    string   `yaml:"name"`
+	Servers []string `yaml:"servers"`
+}
+
+func TestYAML(t *testing.T) {
+	e := echo.New()
+	Register(e)
+	e.Post(`/config`, func(c echo.Context) error {
+		m := &testConfig{}
+		if err := c.MustBind(m); err != nil {
+			return err
+		}
+		return c.SetAuto(true).Render(`config`, m)
+	})
+	e.Commit()
+
+	rec := test.Request(echo.POST, `/config`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationYAML)
+		r.Header.Set(echo.HeaderAccept, echo.MIMETextYAML)
+		r.Body = io.NopCloser(strings.NewReader("name: app\nservers:\n  - a\n  - b\n"))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationYAML))
+	var resp struct {
+		Data *testConfig `yaml:"data"`
+	}
+	assert.NoError(t, yaml.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, &testConfig{Name: `app`, Servers: []string{`a`, `b`}}, resp.Data)
+}
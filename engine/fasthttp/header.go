@@ -28,12 +28,14 @@ type (
 func (h *RequestHeader) Add(key, val string) {
 	h.lock.Lock()
 	h.header.Set(key, val)
+	h.stdhdr = nil
 	h.lock.Unlock()
 }
 
 func (h *RequestHeader) Del(key string) {
 	h.lock.Lock()
 	h.header.Del(key)
+	h.stdhdr = nil
 	h.lock.Unlock()
 }
 
@@ -54,6 +56,7 @@ func (h *RequestHeader) Values(key string) []string {
 func (h *RequestHeader) Set(key, val string) {
 	h.lock.Lock()
 	h.header.Set(key, val)
+	h.stdhdr = nil
 	h.lock.Unlock()
 }
 
@@ -67,12 +70,14 @@ func (h *RequestHeader) Object() any {
 func (h *ResponseHeader) Add(key, val string) {
 	h.lock.Lock()
 	h.header.Set(key, val)
+	h.stdhdr = nil
 	h.lock.Unlock()
 }
 
 func (h *RequestHeader) reset(hdr *fasthttp.RequestHeader) {
 	h.lock.Lock()
 	h.header = hdr
+	h.stdhdr = nil
 	h.lock.Unlock()
 }
 
@@ -94,6 +99,7 @@ func (h *RequestHeader) Std() http.Header {
 func (h *ResponseHeader) Del(key string) {
 	h.lock.Lock()
 	h.header.Del(key)
+	h.stdhdr = nil
 	h.lock.Unlock()
 }
 
@@ -114,6 +120,7 @@ func (h *ResponseHeader) Values(key string) []string {
 func (h *ResponseHeader) Set(key, val string) {
 	h.lock.Lock()
 	h.header.Set(key, val)
+	h.stdhdr = nil
 	h.lock.Unlock()
 }
 
@@ -127,12 +134,14 @@ func (h *ResponseHeader) Object() any {
 func (h *ResponseHeader) reset(hdr *fasthttp.ResponseHeader) {
 	h.lock.Lock()
 	h.header = hdr
+	h.stdhdr = nil
 	h.lock.Unlock()
 }
 
 func (h *ResponseHeader) Std() http.Header {
 	h.lock.Lock()
 	if h.stdhdr != nil {
+		h.lock.Unlock()
 		return *h.stdhdr
 	}
 	hdr := http.Header{}
@@ -86,6 +86,7 @@ func (r *Request) URI() string {
 // SetURI implements `engine.Request#SetURI` function.
 func (r *Request) SetURI(uri string) {
 	r.context.Request.Header.SetRequestURI(uri)
+	r.stdRequest = nil
 }
 
 func (r *Request) URL() engine.URL {
@@ -128,11 +128,14 @@ func (r *Response) reset(w http.ResponseWriter, req *http.Request, h *Header) {
 }
 
 func (r *Response) Hijacker(fn func(net.Conn)) error {
-	conn, bufrw, err := r.ResponseWriter.(http.Hijacker).Hijack()
+	conn, bufrw, err := r.Hijack()
 	if err != nil {
 		return err
 	}
-	_ = bufrw
+	if bufrw != nil && bufrw.Reader.Buffered() > 0 {
+		// 保留已经读取到缓冲区中的数据
+		conn = &bufferedConn{Conn: conn, reader: bufrw.Reader}
+	}
 	fn(conn)
 	conn.Close()
 	r.committed = true
@@ -193,7 +196,11 @@ func (r *Response) Flush() {
 }
 
 func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return r.ResponseWriter.(http.Hijacker).Hijack()
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
 }
 
 func (r *Response) StdResponseWriter() http.ResponseWriter {
@@ -225,3 +232,12 @@ func (r *responseWriter) Write(b []byte) (n int, err error) {
 func (r *responseWriter) WriteHeader(code int) {
 	r.Response.WriteHeader(code)
 }
+
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -213,6 +213,27 @@ func (e *HTTPError) Unwrap() error {
 	return e.raw
 }
 
+// ErrorHTTPCode 获取错误对应的 HTTP 状态码：HTTPError 为其 Code，Error 为其 Code 对应的 HTTP 状态码，其它错误为 500
+func ErrorHTTPCode(err error) int {
+	var he *HTTPError
+	if errors.As(err, &he) && he.Code > 0 {
+		return he.Code
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code.HTTPCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// ResponseStatus 获取请求的响应状态码。处理函数返回错误且尚未输出响应时，状态码以错误为准(参见 ErrorHTTPCode)
+func ResponseStatus(c Context, err error) int {
+	if err == nil || c.Response().Committed() {
+		return c.Response().Status()
+	}
+	return ErrorHTTPCode(err)
+}
+
 // ==========================================
 // PanicError
 // ==========================================
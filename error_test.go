@@ -1,7 +1,10 @@
 package echo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,3 +19,12 @@ func TestIsErrorCode(t *testing.T) {
 	assert.True(t, IsErrorCode(err2, code.Unauthenticated))
 	assert.False(t, IsErrorCode(err2, code.Unsupported))
 }
+
+func TestErrorHTTPCode(t *testing.T) {
+	assert.Equal(t, http.StatusForbidden, ErrorHTTPCode(ErrForbidden))
+	assert.Equal(t, http.StatusForbidden, ErrorHTTPCode(fmt.Errorf(`wrapped: %w`, ErrForbidden)))
+	assert.Equal(t, http.StatusUnauthorized, ErrorHTTPCode(NewError(`test`, code.Unauthenticated)))
+	assert.Equal(t, http.StatusNotFound, ErrorHTTPCode(fmt.Errorf(`wrapped: %w`, NewError(`test`, code.DataNotFound))))
+	assert.Equal(t, http.StatusInternalServerError, ErrorHTTPCode(errors.New(`test`)))
+	assert.Equal(t, http.StatusInternalServerError, ErrorHTTPCode(context.DeadlineExceeded))
+}
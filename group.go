@@ -102,7 +102,7 @@ func (g *Group) Trace(path string, h any, m ...any) IRouter {
 
 func (g *Group) Any(path string, h any, middleware ...any) IRouter {
 	routes := Routes{}
-	for _, m := range methods {
+	for _, m := range g.echo.Methods() {
 		routes = append(routes, g.Add(m, path, h, middleware...))
 	}
 	return routes
@@ -200,3 +200,32 @@ func (g *Group) SetMetaKV(key string, value any) *Group {
 	g.meta[key] = value
 	return g
 }
+
+// contains 路由组是否为 g 或者 g 的子路由组
+func (g *Group) contains(sub *Group) bool {
+	for ; sub != nil; sub = sub.parent {
+		if sub == g {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove 删除本路由组(包括子路由组)中的所有路由，并重新构建和发布路由表。返回删除的路由数量。
+// 路由组也会被注销，再次调用 Group 时将创建新的路由组
+func (g *Group) Remove() int {
+	groups := g.echo.groups
+	if g.host != nil {
+		if hs, ok := g.echo.hosts[g.host.name]; ok {
+			groups = hs.groups
+		}
+	}
+	for prefix, sub := range groups {
+		if g.contains(sub) {
+			delete(groups, prefix)
+		}
+	}
+	return g.echo.RemoveRoutes(func(r *Route) bool {
+		return g.contains(r.group)
+	})
+}
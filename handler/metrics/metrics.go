@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware"
+)
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = `text/plain; version=0.0.4; charset=utf-8`
+
+// Handler 以 Prometheus 文本格式输出指标。未指定 registry 时使用 middleware.DefaultMetricsRegistry
+func Handler(registry ...*middleware.MetricsRegistry) echo.HandlerFunc {
+	r := middleware.DefaultMetricsRegistry
+	if len(registry) > 0 && registry[0] != nil {
+		r = registry[0]
+	}
+	return func(c echo.Context) error {
+		buf := new(bytes.Buffer)
+		if _, err := r.WriteTo(buf); err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, ContentType)
+		return c.Blob(buf.Bytes(), http.StatusOK)
+	}
+}
+
+// RegisterRoute 注册 /metrics 路由
+func RegisterRoute(router echo.RouteRegister, registry ...*middleware.MetricsRegistry) {
+	router.Get(`/metrics`, Handler(registry...)).SetName(`metrics`)
+}
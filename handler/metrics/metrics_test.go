@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func TestHandler(t *testing.T) {
+	registry := middleware.NewMetricsRegistry(`app`)
+	registry.Counter(`logins_total`, `Total logins.`).Inc()
+	e := echo.New()
+	RegisterRoute(e, registry)
+	e.RebuildRouter()
+	rec := myTesting.Request(http.MethodGet, `/metrics`, e)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "# HELP app_logins_total Total logins.\n# TYPE app_logins_total counter\napp_logins_total 1\n", rec.Body.String())
+}
@@ -0,0 +1,321 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/webx-top/echo"
+)
+
+// MetaKey 路由 Meta 中用于补充文档信息的键名。
+// 例如：route.SetMetaKV(openapi.MetaKey, echo.H{"summary": "...", "description": "...", "tags": []string{"user"}, "deprecated": true})
+const MetaKey = `openapi`
+
+type Config struct {
+	Info    Info
+	Servers []Server
+	Tags    []Tag
+
+	// Skipper 返回 true 时跳过该路由
+	Skipper func(*echo.Route) bool
+
+	// WildcardName 通配符“*”参数在文档中的名称(默认为 wildcard)
+	WildcardName string
+
+	// BodyContentTypes 请求体支持的内容类型(默认为 JSON 和表单)
+	BodyContentTypes []string
+
+	// ResponseContentType 响应内容类型(默认为 application/json)
+	ResponseContentType string
+}
+
+var DefaultConfig = Config{
+	Info: Info{
+		Title:   `API`,
+		Version: `1.0.0`,
+	},
+	WildcardName: `wildcard`,
+	BodyContentTypes: []string{
+		echo.MIMEApplicationJSON,
+		echo.MIMEApplicationForm,
+		echo.MIMEMultipartForm,
+	},
+	ResponseContentType: echo.MIMEApplicationJSON,
+}
+
+func (c *Config) setDefaults() {
+	if len(c.Info.Title) == 0 {
+		c.Info.Title = DefaultConfig.Info.Title
+	}
+	if len(c.Info.Version) == 0 {
+		c.Info.Version = DefaultConfig.Info.Version
+	}
+	if len(c.WildcardName) == 0 {
+		c.WildcardName = DefaultConfig.WildcardName
+	}
+	if len(c.BodyContentTypes) == 0 {
+		c.BodyContentTypes = DefaultConfig.BodyContentTypes
+	}
+	if len(c.ResponseContentType) == 0 {
+		c.ResponseContentType = DefaultConfig.ResponseContentType
+	}
+}
+
+// Generate 根据已注册的路由生成 OpenAPI 文档。
+// 需要在路由构建(Echo.Commit / Echo.RebuildRouter)之后调用。
+// OpenAPI 3.1 不支持的方法(CONNECT 以及自定义方法)会被忽略
+func Generate(e *echo.Echo, configs ...Config) *Document {
+	var cfg Config
+	if len(configs) > 0 {
+		cfg = configs[0]
+	}
+	cfg.setDefaults()
+	g := &generator{
+		cfg:      &cfg,
+		registry: newSchemaRegistry(),
+		opIDs:    map[string]int{},
+	}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    cfg.Info,
+		Servers: cfg.Servers,
+		Tags:    cfg.Tags,
+		Paths:   map[string]*PathItem{},
+	}
+	for _, route := range e.Routes() {
+		if cfg.Skipper != nil && cfg.Skipper(route) {
+			continue
+		}
+		if len(route.Format) == 0 && len(route.Path) > 0 {
+			continue // 尚未构建
+		}
+		path := g.path(route)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+		}
+		if !item.SetOperation(route.Method, g.operation(e, route)) {
+			continue
+		}
+		doc.Paths[path] = item
+	}
+	if len(g.registry.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.registry.schemas}
+	}
+	return doc
+}
+
+type generator struct {
+	cfg      *Config
+	registry *schemaRegistry
+	opIDs    map[string]int
+}
+
+// path 将路由路径转换为 OpenAPI 路径模板。例如：/user/:id => /user/{id}
+func (g *generator) path(route *echo.Route) string {
+	if len(route.Params) == 0 {
+		return route.Format
+	}
+	values := make([]any, len(route.Params))
+	for i, name := range route.Params {
+		values[i] = `{` + g.paramName(name) + `}`
+	}
+	return fmt.Sprintf(route.Format, values...)
+}
+
+func (g *generator) paramName(name string) string {
+	if name == `*` {
+		return g.cfg.WildcardName
+	}
+	return name
+}
+
+func (g *generator) operationID(route *echo.Route) string {
+	id := route.Name
+	if len(id) == 0 {
+		id = route.Method + route.Path
+	}
+	id = invalidNameChars.ReplaceAllString(id, `_`)
+	if n, ok := g.opIDs[id]; ok {
+		g.opIDs[id] = n + 1
+		return id + `_` + strings.ToLower(route.Method) + fmt.Sprint(n)
+	}
+	g.opIDs[id] = 1
+	return id
+}
+
+func (g *generator) operation(e *echo.Echo, route *echo.Route) *Operation {
+	op := &Operation{
+		OperationID: g.operationID(route),
+		Responses:   map[string]*Response{},
+	}
+	meta := route.GetStore(MetaKey)
+	op.Summary = meta.String(`summary`)
+	op.Description = meta.String(`description`)
+	op.Deprecated = meta.Bool(`deprecated`)
+	switch tags := meta.Get(`tags`).(type) {
+	case []string:
+		op.Tags = tags
+	case string:
+		op.Tags = strings.Split(tags, `,`)
+	}
+
+	pathParams := map[string]struct{}{}
+	for _, name := range route.Params {
+		pathParams[strings.ToLower(name)] = struct{}{}
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     g.paramName(name),
+			In:       `path`,
+			Required: true,
+			Schema:   pathParamSchema(e, route, name),
+		})
+	}
+
+	reqType, respType := handlerTypes(e, route)
+	if reqType != nil {
+		op.Parameters = append(op.Parameters, g.sourceParameters(reqType)...)
+		switch route.Method {
+		case echo.GET, echo.HEAD, echo.DELETE, echo.OPTIONS, echo.TRACE:
+			formFields(g.registry, reqType, ``, func(name string, f reflect.StructField, s *Schema) {
+				if _, ok := pathParams[strings.ToLower(name)]; ok || hasSourceTag(f) {
+					return
+				}
+				op.Parameters = append(op.Parameters, &Parameter{
+					Name:        name,
+					In:          `query`,
+					Required:    isRequired(f),
+					Description: f.Tag.Get(`description`),
+					Schema:      s,
+				})
+			})
+		default:
+			op.RequestBody = g.requestBody(reqType)
+		}
+	}
+
+	resp := &Response{Description: http.StatusText(http.StatusOK)}
+	if respType != nil {
+		resp.Content = map[string]*MediaType{
+			g.cfg.ResponseContentType: {Schema: g.registry.Schema(respType)},
+		}
+	}
+	op.Responses[`200`] = resp
+	return op
+}
+
+func (g *generator) requestBody(t reflect.Type) *RequestBody {
+	body := &RequestBody{Required: true, Content: map[string]*MediaType{}}
+	var formSchema *Schema
+	for _, contentType := range g.cfg.BodyContentTypes {
+		switch contentType {
+		case echo.MIMEApplicationForm, echo.MIMEMultipartForm:
+			if formSchema == nil {
+				formSchema = &Schema{Type: `object`, Properties: map[string]*Schema{}}
+				formFields(g.registry, t, ``, func(name string, f reflect.StructField, s *Schema) {
+					if hasSourceTag(f) {
+						return
+					}
+					formSchema.Properties[name] = s
+					if isRequired(f) {
+						formSchema.Required = append(formSchema.Required, name)
+					}
+				})
+				sort.Strings(formSchema.Required)
+			}
+			body.Content[contentType] = &MediaType{Schema: formSchema}
+		default:
+			body.Content[contentType] = &MediaType{Schema: g.registry.Schema(t)}
+		}
+	}
+	return body
+}
+
+// pathParamSchema 根据路由参数约束生成路径参数的 Schema。
+// 例如：:id<int> => integer，:id<uuid> => string(uuid)，其它正则表达式 => string(pattern)
+func pathParamSchema(e *echo.Echo, route *echo.Route, name string) *Schema {
+	pattern, ok := route.ParamPattern(name)
+	if !ok {
+		return &Schema{Type: `string`}
+	}
+	constraint := func(name string) bool {
+		v, ok := e.ParamConstraint(name)
+		return ok && v == pattern
+	}
+	switch {
+	case constraint(`int`):
+		return &Schema{Type: `integer`}
+	case constraint(`uint`):
+		zero := float64(0)
+		return &Schema{Type: `integer`, Minimum: &zero}
+	case constraint(`uuid`):
+		return &Schema{Type: `string`, Format: `uuid`}
+	case constraint(`date`):
+		return &Schema{Type: `string`, Format: `date`}
+	}
+	return &Schema{Type: `string`, Pattern: `^(?:` + pattern + `)$`}
+}
+
+// sourceParameters 根据请求结构体字段的 header、cookie 和 query 标签(见 echo.BinderSources)生成参数
+func (g *generator) sourceParameters(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []*Parameter
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous || !f.IsExported() {
+			continue
+		}
+		for _, source := range []string{echo.BinderSourceHeader, echo.BinderSourceCookie, echo.BinderSourceQuery} {
+			name, ok := f.Tag.Lookup(source)
+			if !ok || name == `-` {
+				continue
+			}
+			if len(name) == 0 {
+				name = f.Name
+			}
+			params = append(params, &Parameter{
+				Name:        name,
+				In:          source,
+				Required:    isRequired(f),
+				Description: f.Tag.Get(`description`),
+				Schema:      g.registry.Schema(f.Type),
+			})
+		}
+	}
+	return params
+}
+
+// hasSourceTag 字段是否有 param、header、cookie 或 query 标签(值不从请求正文或表单中获取)
+func hasSourceTag(f reflect.StructField) bool {
+	for _, source := range echo.BinderSources {
+		if name, ok := f.Tag.Lookup(source); ok && name != `-` {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerTypes 获取路由 handler 的请求和响应数据类型
+func handlerTypes(e *echo.Echo, route *echo.Route) (reqType reflect.Type, respType reflect.Type) {
+	raw := route.RawHandler()
+	if raw == nil {
+		return
+	}
+	var h any = raw
+	if _, ok := raw.(echo.Handler); !ok {
+		h = e.WrapHandler(raw)
+	}
+	if v, ok := h.(echo.RequestTypeGetter); ok {
+		reqType = v.RequestType()
+	}
+	if v, ok := h.(echo.ResponseTypeGetter); ok {
+		respType = v.ResponseType()
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/webx-top/echo"
+)
+
+const MIMEApplicationYAMLCharsetUTF8 = `application/yaml; ` + echo.CharsetUTF8
+
+// Handler 输出 OpenAPI 文档的 handler。
+// 文档在第一次请求时生成，路由表版本变化(例如添加或删除路由后重新发布路由表)时重新生成。
+// 请求路径以 .yaml/.yml 结尾或 format=yaml 时输出 YAML 格式，否则输出 JSON 格式
+func Handler(e *echo.Echo, configs ...Config) echo.HandlerFunc {
+	var (
+		mu    sync.Mutex
+		cache *documentCache
+	)
+	return func(c echo.Context) error {
+		version := e.RouteTable().Version()
+		mu.Lock()
+		if cache == nil || cache.version != version {
+			cache = newDocumentCache(version, Generate(e, configs...))
+		}
+		dc := cache
+		mu.Unlock()
+		if dc.err != nil {
+			return dc.err
+		}
+		if isYAML(c) {
+			c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationYAMLCharsetUTF8)
+			return c.Blob(dc.yaml)
+		}
+		return c.JSONBlob(dc.json)
+	}
+}
+
+// documentCache 根据指定版本的路由表生成的文档
+type documentCache struct {
+	version uint64
+	json    []byte
+	yaml    []byte
+	err     error
+}
+
+func newDocumentCache(version uint64, doc *Document) *documentCache {
+	dc := &documentCache{version: version}
+	dc.json, dc.err = doc.JSON()
+	if dc.err != nil {
+		return dc
+	}
+	dc.yaml, dc.err = doc.YAML()
+	return dc
+}
+
+func isYAML(c echo.Context) bool {
+	path := c.Request().URL().Path()
+	if strings.HasSuffix(path, `.yaml`) || strings.HasSuffix(path, `.yml`) {
+		return true
+	}
+	return c.Query(`format`) == `yaml`
+}
+
+// RegisterRoute 注册文档路由(path 默认为 /openapi.json，同时注册 .yaml 后缀的路由)
+func RegisterRoute(router echo.RouteRegister, e *echo.Echo, path string, configs ...Config) {
+	if len(path) == 0 {
+		path = `/openapi.json`
+	}
+	var cfg Config
+	if len(configs) > 0 {
+		cfg = configs[0]
+	}
+	docPaths := []string{path}
+	if base := strings.TrimSuffix(path, `.json`); base != path {
+		docPaths = append(docPaths, base+`.yaml`)
+	}
+	skipper := cfg.Skipper
+	cfg.Skipper = func(r *echo.Route) bool {
+		for _, docPath := range docPaths {
+			if strings.HasSuffix(r.Path, docPath) {
+				return true
+			}
+		}
+		return skipper != nil && skipper(r)
+	}
+	h := Handler(e, cfg)
+	for _, docPath := range docPaths {
+		router.Get(docPath, h)
+	}
+}
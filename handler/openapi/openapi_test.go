@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+)
+
+type Address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type CreateUserRequest struct {
+	Name    string    `json:"name" valid:"required"`
+	Age     int       `json:"age,omitempty"`
+	Tags    []string  `json:"tags"`
+	Address *Address  `json:"address"`
+	Birth   time.Time `json:"birth"`
+	Secret  string    `json:"-" form_options:"-"`
+}
+
+type ListUserRequest struct {
+	Page   int    `description:"page number"`
+	Search string `form:"q"`
+	ID     string
+}
+
+type UpdateOrderRequest struct {
+	ID      int    `param:"id" json:"-" form_options:"-"`
+	Tenant  string `header:"X-Tenant" json:"-" valid:"required"`
+	Session string `cookie:"sid" json:"-"`
+	DryRun  bool   `query:"dry_run" json:"-"`
+	Note    string `json:"note"`
+}
+
+type User struct {
+	ID      uint64   `json:"id"`
+	Name    string   `json:"name"`
+	Address Address  `json:"address"`
+	Friends []*User  `json:"friends"`
+	Extra   echo.H   `json:"extra"`
+	Roles   []string `json:"roles"`
+}
+
+func TestGenerate(t *testing.T) {
+	e := echo.New()
+	e.Post(`/users`, echo.HandlerFuncWithArg[CreateUserRequest, *User](func(c echo.Context, r *CreateUserRequest) (*User, error) {
+		return &User{}, nil
+	})).SetName(`user.create`).SetMetaKV(MetaKey, echo.H{`summary`: `create user`, `tags`: []string{`user`}})
+	e.Get(`/users/:id`, e.MetaHandler(nil, func(c echo.Context) error {
+		return nil
+	}, &ListUserRequest{})).SetName(`user.list`)
+	e.Get(`/files/*`, func(c echo.Context) error { return nil }).SetName(`files`)
+	e.Connect(`/tunnel`, func(c echo.Context) error { return nil })
+	e.RebuildRouter()
+
+	doc := Generate(e)
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Len(t, doc.Paths, 3)
+
+	create := doc.Paths[`/users`].Post
+	assert.Equal(t, `user.create`, create.OperationID)
+	assert.Equal(t, `create user`, create.Summary)
+	assert.Equal(t, []string{`user`}, create.Tags)
+	assert.Equal(t, `#/components/schemas/CreateUserRequest`, create.RequestBody.Content[echo.MIMEApplicationJSON].Schema.Ref)
+	form := create.RequestBody.Content[echo.MIMEApplicationForm].Schema
+	assert.Contains(t, form.Properties, `Address.City`)
+	assert.NotContains(t, form.Properties, `Secret`)
+	assert.Equal(t, []string{`Name`}, form.Required)
+	assert.Equal(t, `#/components/schemas/User`, create.Responses[`200`].Content[echo.MIMEApplicationJSON].Schema.Ref)
+
+	reqSchema := doc.Components.Schemas[`CreateUserRequest`]
+	assert.Equal(t, []string{`name`}, reqSchema.Required)
+	assert.Equal(t, `date-time`, reqSchema.Properties[`birth`].Format)
+	assert.Equal(t, `array`, reqSchema.Properties[`tags`].Type)
+	assert.NotContains(t, reqSchema.Properties, `Secret`)
+	userSchema := doc.Components.Schemas[`User`]
+	assert.Equal(t, `#/components/schemas/User`, userSchema.Properties[`friends`].Items.Ref)
+
+	list := doc.Paths[`/users/{id}`].Get
+	assert.Nil(t, list.RequestBody)
+	names := []string{}
+	for _, p := range list.Parameters {
+		names = append(names, p.In+`:`+p.Name)
+	}
+	assert.Equal(t, []string{`path:id`, `query:Page`, `query:q`}, names)
+	assert.Equal(t, `page number`, list.Parameters[1].Description)
+
+	files := doc.Paths[`/files/{wildcard}`].Get
+	assert.Equal(t, `wildcard`, files.Parameters[0].Name)
+	assert.Nil(t, files.Responses[`200`].Content)
+
+	b, err := doc.YAML()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(b), "openapi: 3.1.0\n"), string(b))
+	assert.Contains(t, string(b), `"200":`)
+}
+
+func TestGenerateParameters(t *testing.T) {
+	e := echo.New()
+	e.Put(`/orders/:id<int>/items/<sku:[A-Z]{3}>`, echo.HandlerFuncWithArg[UpdateOrderRequest, *User](func(c echo.Context, r *UpdateOrderRequest) (*User, error) {
+		return &User{}, nil
+	}))
+	e.Get(`/accounts/:uid<uuid>/:day<date>/:n<uint>`, func(c echo.Context) error { return nil })
+	e.RebuildRouter()
+
+	doc := Generate(e)
+	update := doc.Paths[`/orders/{id}/items/{sku}`].Put
+	if assert.NotNil(t, update) {
+		names := []string{}
+		for _, p := range update.Parameters {
+			names = append(names, p.In+`:`+p.Name)
+		}
+		assert.Equal(t, []string{`path:id`, `path:sku`, `header:X-Tenant`, `cookie:sid`, `query:dry_run`}, names)
+		assert.Equal(t, `integer`, update.Parameters[0].Schema.Type)
+		assert.Equal(t, `string`, update.Parameters[1].Schema.Type)
+		assert.Equal(t, `^(?:[A-Z]{3})$`, update.Parameters[1].Schema.Pattern)
+		assert.True(t, update.Parameters[2].Required)
+		assert.Equal(t, `boolean`, update.Parameters[4].Schema.Type)
+		form := update.RequestBody.Content[echo.MIMEApplicationForm].Schema
+		assert.Equal(t, []string{`Note`}, keys(form.Properties))
+	}
+
+	account := doc.Paths[`/accounts/{uid}/{day}/{n}`].Get
+	if assert.NotNil(t, account) {
+		assert.Equal(t, `uuid`, account.Parameters[0].Schema.Format)
+		assert.Equal(t, `date`, account.Parameters[1].Schema.Format)
+		assert.Equal(t, `integer`, account.Parameters[2].Schema.Type)
+		assert.Equal(t, float64(0), *account.Parameters[2].Schema.Minimum)
+	}
+}
+
+func keys(m map[string]*Schema) []string {
+	r := make([]string, 0, len(m))
+	for k := range m {
+		r = append(r, k)
+	}
+	return r
+}
+
+func TestHandler(t *testing.T) {
+	e := echo.New()
+	e.Get(`/ping`, func(c echo.Context) error { return c.String(`pong`) })
+	RegisterRoute(e.Group(`/docs`), e, ``, Config{Info: Info{Title: `Test`}})
+	e.RebuildRouter()
+
+	rec := test.Request(echo.GET, `/docs/openapi.json`, e)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	doc := &Document{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), doc))
+	assert.Equal(t, `Test`, doc.Info.Title)
+	assert.Len(t, doc.Paths, 1)
+	assert.NotNil(t, doc.Paths[`/ping`].Get)
+
+	rec = test.Request(echo.GET, `/docs/openapi.yaml`, e)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEApplicationYAMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Body.String(), `title: Test`)
+
+	// 路由表变化后重新生成文档
+	e.Get(`/users`, func(c echo.Context) error { return c.String(`users`) }).SetName(`users`)
+	e.RebuildRouter()
+	rec = test.Request(echo.GET, `/docs/openapi.json`, e)
+	doc = &Document{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), doc))
+	assert.Len(t, doc.Paths, 2)
+	assert.NotNil(t, doc.Paths[`/users`].Get)
+
+	assert.Equal(t, 1, e.RemoveRoute(`users`))
+	rec = test.Request(echo.GET, `/docs/openapi.yaml`, e)
+	assert.NotContains(t, rec.Body.String(), `/users`)
+	rec = test.Request(echo.GET, `/docs/openapi.json`, e)
+	doc = &Document{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), doc))
+	assert.Len(t, doc.Paths, 1)
+}
@@ -0,0 +1,249 @@
+package openapi
+
+import (
+	"encoding"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	invalidNameChars  = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+	zero              = float64(0)
+)
+
+// schemaRegistry 用于生成结构体对应的 Schema 并登记到 components 中
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+func (r *schemaRegistry) typeName(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	name := invalidNameChars.ReplaceAllString(t.Name(), `_`)
+	name = strings.Trim(name, `_`)
+	if len(name) == 0 {
+		name = `Object`
+	}
+	if _, exists := r.schemas[name]; exists {
+		pkg := t.PkgPath()
+		if pos := strings.LastIndex(pkg, `/`); pos > -1 {
+			pkg = pkg[pos+1:]
+		}
+		name = invalidNameChars.ReplaceAllString(pkg, `_`) + `.` + name
+		base := name
+		for i := 2; ; i++ {
+			if _, exists := r.schemas[name]; !exists {
+				break
+			}
+			name = base + `_` + strconv.Itoa(i)
+		}
+	}
+	r.names[t] = name
+	return name
+}
+
+// Schema 获取类型对应的 Schema。结构体会被登记到 components 中并返回引用
+func (r *schemaRegistry) Schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if s := basicSchema(t); s != nil {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: `string`, Format: `byte`}
+		}
+		return &Schema{Type: `array`, Items: r.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: `object`, AdditionalProperties: r.Schema(t.Elem())}
+	case reflect.Struct:
+		if len(t.Name()) == 0 {
+			return r.structSchema(t)
+		}
+		_, registered := r.names[t]
+		name := r.typeName(t)
+		if !registered {
+			r.schemas[name] = &Schema{Type: `object`} // 占位，避免递归引用时死循环
+			r.schemas[name] = r.structSchema(t)
+		}
+		return &Schema{Ref: `#/components/schemas/` + name}
+	}
+	return &Schema{}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: `object`, Properties: map[string]*Schema{}}
+	r.fillProperties(s, t)
+	return s
+}
+
+func (r *schemaRegistry) fillProperties(s *Schema, t reflect.Type) {
+	for i, l := 0, t.NumField(); i < l; i++ {
+		f := t.Field(i)
+		name, skip := jsonFieldName(f)
+		if skip {
+			continue
+		}
+		if f.Anonymous && len(name) == 0 {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.fillProperties(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		prop := r.Schema(f.Type)
+		if desc := f.Tag.Get(`description`); len(desc) > 0 {
+			prop = withDescription(prop, desc)
+		}
+		s.Properties[name] = prop
+		if isRequired(f) {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func withDescription(s *Schema, desc string) *Schema {
+	if len(s.Ref) > 0 {
+		// $ref 的同级字段在 3.1 中是允许的
+		cp := *s
+		cp.Description = desc
+		return &cp
+	}
+	s.Description = desc
+	return s
+}
+
+func basicSchema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: `string`, Format: `date-time`}
+	case durationType:
+		return &Schema{Type: `string`, Format: `duration`}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: `boolean`}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: `integer`, Format: `int64`}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: `integer`, Format: `int32`}
+	case reflect.Uint, reflect.Uint64:
+		return &Schema{Type: `integer`, Format: `int64`, Minimum: &zero}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: `integer`, Format: `int32`, Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: `number`, Format: `float`}
+	case reflect.Float64:
+		return &Schema{Type: `number`, Format: `double`}
+	case reflect.String:
+		return &Schema{Type: `string`}
+	case reflect.Interface:
+		return &Schema{}
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: `string`}
+	}
+	return nil
+}
+
+// jsonFieldName 解析 json 标签
+func jsonFieldName(f reflect.StructField) (name string, skip bool) {
+	tag := f.Tag.Get(`json`)
+	if tag == `-` {
+		return ``, true
+	}
+	name = strings.SplitN(tag, `,`, 2)[0]
+	return
+}
+
+// formFieldName 表单字段名称。优先使用 form 标签，否则使用结构体字段名称(与 binder 的映射规则一致)
+func formFieldName(f reflect.StructField) (name string, skip bool) {
+	if f.Tag.Get(`form_options`) == `-` {
+		return ``, true
+	}
+	tag := f.Tag.Get(`form`)
+	if tag == `-` {
+		return ``, true
+	}
+	name = strings.SplitN(tag, `,`, 2)[0]
+	if len(name) == 0 {
+		name = f.Name
+	}
+	return
+}
+
+func isRequired(f reflect.StructField) bool {
+	valid := f.Tag.Get(`valid`)
+	if len(valid) == 0 {
+		valid = f.Tag.Get(`validate`)
+	}
+	for _, rule := range strings.FieldsFunc(valid, func(r rune) bool { return r == ';' || r == ',' }) {
+		if strings.TrimSpace(rule) == `required` || strings.TrimSpace(rule) == `Required` {
+			return true
+		}
+	}
+	return false
+}
+
+// formFields 将结构体展开为表单字段(嵌套结构体使用“.”连接)
+func formFields(r *schemaRegistry, t reflect.Type, prefix string, fn func(name string, f reflect.StructField, s *Schema)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i, l := 0, t.NumField(); i < l; i++ {
+		f := t.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct {
+			formFields(r, ft, prefix, fn)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name, skip := formFieldName(f)
+		if skip {
+			continue
+		}
+		name = prefix + name
+		if ft.Kind() == reflect.Struct && basicSchema(ft) == nil {
+			formFields(r, ft, name+`.`, fn)
+			continue
+		}
+		fn(name, f, r.Schema(f.Type))
+	}
+}
@@ -0,0 +1,154 @@
+package openapi
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version OpenAPI 规范版本
+const Version = `3.1.0`
+
+type (
+	// Document OpenAPI 文档
+	Document struct {
+		OpenAPI    string               `json:"openapi"`
+		Info       Info                 `json:"info"`
+		Servers    []Server             `json:"servers,omitempty"`
+		Paths      map[string]*PathItem `json:"paths"`
+		Components *Components          `json:"components,omitempty"`
+		Tags       []Tag                `json:"tags,omitempty"`
+	}
+
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	Server struct {
+		URL         string `json:"url"`
+		Description string `json:"description,omitempty"`
+	}
+
+	Tag struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	}
+
+	PathItem struct {
+		Get     *Operation `json:"get,omitempty"`
+		Put     *Operation `json:"put,omitempty"`
+		Post    *Operation `json:"post,omitempty"`
+		Delete  *Operation `json:"delete,omitempty"`
+		Options *Operation `json:"options,omitempty"`
+		Head    *Operation `json:"head,omitempty"`
+		Patch   *Operation `json:"patch,omitempty"`
+		Trace   *Operation `json:"trace,omitempty"`
+	}
+
+	Operation struct {
+		Tags        []string             `json:"tags,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		Description string               `json:"description,omitempty"`
+		OperationID string               `json:"operationId,omitempty"`
+		Parameters  []*Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+		Deprecated  bool                 `json:"deprecated,omitempty"`
+	}
+
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"` // path / query / header / cookie
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema,omitempty"`
+	}
+
+	RequestBody struct {
+		Description string                `json:"description,omitempty"`
+		Required    bool                  `json:"required,omitempty"`
+		Content     map[string]*MediaType `json:"content"`
+	}
+
+	MediaType struct {
+		Schema *Schema `json:"schema,omitempty"`
+	}
+
+	Response struct {
+		Description string                `json:"description"`
+		Content     map[string]*MediaType `json:"content,omitempty"`
+	}
+
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	}
+
+	// Schema JSON Schema (2020-12)
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 any                `json:"type,omitempty"` // string or []string
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		Enum                 []any              `json:"enum,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+	}
+)
+
+// SetOperation 设置指定方法的操作。不支持的方法返回 false
+func (p *PathItem) SetOperation(method string, op *Operation) bool {
+	switch method {
+	case `GET`:
+		p.Get = op
+	case `PUT`:
+		p.Put = op
+	case `POST`:
+		p.Post = op
+	case `DELETE`:
+		p.Delete = op
+	case `OPTIONS`:
+		p.Options = op
+	case `HEAD`:
+		p.Head = op
+	case `PATCH`:
+		p.Patch = op
+	case `TRACE`:
+		p.Trace = op
+	default:
+		return false
+	}
+	return true
+}
+
+// JSON 生成 JSON 格式的文档
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, ``, `  `)
+}
+
+// YAML 生成 YAML 格式的文档
+func (d *Document) YAML() ([]byte, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	// 通过 yaml.Node 中转以保持字段顺序
+	node := &yaml.Node{}
+	if err = yaml.Unmarshal(b, node); err != nil {
+		return nil, err
+	}
+	resetNodeStyle(node)
+	return yaml.Marshal(node)
+}
+
+func resetNodeStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetNodeStyle(child)
+	}
+}
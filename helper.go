@@ -114,6 +114,19 @@ func Methods() []string {
 	return methods
 }
 
+// isMethodToken 是否为大写字母组成的 HTTP 方法名(例如自定义的 PURGE)
+func isMethodToken(method string) bool {
+	if len(method) == 0 {
+		return false
+	}
+	for _, r := range method {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
 // ContentTypeByExtension returns the MIME type associated with the file based on
 // its extension. It returns `application/octet-stream` incase MIME type is not
 // found.
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// FieldsLogger 支持附加键值对字段的 Logger
+	FieldsLogger interface {
+		Logger
+		// WithFields 返回附加了字段的子 Logger。keyValues 依次为键和值
+		WithFields(keyValues ...any) Logger
+	}
+
+	// Valuer 延迟求值的字段值，在输出日志时才调用
+	Valuer func() any
+
+	// fieldsLogger 为不支持字段的 Logger 附加字段(以 key=value 的形式追加到消息后面)
+	fieldsLogger struct {
+		Logger
+		fields []any
+	}
+)
+
+// With 返回附加了字段的子 Logger。l 实现了 FieldsLogger 时由 l 自行处理，否则以 key=value 的形式追加到每条消息后面
+func With(l Logger, keyValues ...any) Logger {
+	if len(keyValues) == 0 {
+		return l
+	}
+	if fl, ok := l.(FieldsLogger); ok {
+		return fl.WithFields(keyValues...)
+	}
+	return &fieldsLogger{Logger: l, fields: normalizeFields(keyValues)}
+}
+
+// normalizeFields 键必须为字符串，最后一个键没有值时值为 nil
+func normalizeFields(keyValues []any) []any {
+	fields := make([]any, 0, len(keyValues)+len(keyValues)%2)
+	for i := 0; i < len(keyValues); i += 2 {
+		key, ok := keyValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyValues[i])
+		}
+		var value any
+		if i+1 < len(keyValues) {
+			value = keyValues[i+1]
+		}
+		fields = append(fields, key, value)
+	}
+	return fields
+}
+
+// FieldValue 返回字段的值(Valuer 会被调用)
+func FieldValue(v any) any {
+	if fn, ok := v.(Valuer); ok {
+		return fn()
+	}
+	return v
+}
+
+func (l *fieldsLogger) WithFields(keyValues ...any) Logger {
+	fields := make([]any, 0, len(l.fields)+len(keyValues))
+	fields = append(fields, l.fields...)
+	fields = append(fields, normalizeFields(keyValues)...)
+	return &fieldsLogger{Logger: l.Logger, fields: fields}
+}
+
+// Fields 已附加的字段
+func (l *fieldsLogger) Fields() []any {
+	return l.fields
+}
+
+// Unwrap 返回原始的 Logger
+func (l *fieldsLogger) Unwrap() Logger {
+	return l.Logger
+}
+
+func (l *fieldsLogger) format(msg string) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(l.fields); i += 2 {
+		b.WriteByte(' ')
+		b.WriteString(l.fields[i].(string))
+		b.WriteByte('=')
+		s := fmt.Sprint(FieldValue(l.fields[i+1]))
+		if len(s) == 0 || strings.ContainsAny(s, " \t\r\n\"=") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+func (l *fieldsLogger) Debug(a ...any) {
+	l.Logger.Debug(l.format(fmt.Sprint(a...)))
+}
+
+func (l *fieldsLogger) Debugf(format string, a ...any) {
+	l.Logger.Debug(l.format(fmt.Sprintf(format, a...)))
+}
+
+func (l *fieldsLogger) Info(a ...any) {
+	l.Logger.Info(l.format(fmt.Sprint(a...)))
+}
+
+func (l *fieldsLogger) Infof(format string, a ...any) {
+	l.Logger.Info(l.format(fmt.Sprintf(format, a...)))
+}
+
+func (l *fieldsLogger) Warn(a ...any) {
+	l.Logger.Warn(l.format(fmt.Sprint(a...)))
+}
+
+func (l *fieldsLogger) Warnf(format string, a ...any) {
+	l.Logger.Warn(l.format(fmt.Sprintf(format, a...)))
+}
+
+func (l *fieldsLogger) Error(a ...any) {
+	l.Logger.Error(l.format(fmt.Sprint(a...)))
+}
+
+func (l *fieldsLogger) Errorf(format string, a ...any) {
+	l.Logger.Error(l.format(fmt.Sprintf(format, a...)))
+}
+
+func (l *fieldsLogger) Fatal(a ...any) {
+	l.Logger.Fatal(l.format(fmt.Sprint(a...)))
+}
+
+func (l *fieldsLogger) Fatalf(format string, a ...any) {
+	l.Logger.Fatal(l.format(fmt.Sprintf(format, a...)))
+}
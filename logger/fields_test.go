@@ -0,0 +1,74 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/webx-top/echo/logger"
+)
+
+type recordLogger struct {
+	logger.Base
+	lines []string
+}
+
+func (r *recordLogger) Info(a ...any) {
+	r.lines = append(r.lines, `INFO `+fmt.Sprint(a...))
+}
+
+func (r *recordLogger) Warn(a ...any) {
+	r.lines = append(r.lines, `WARN `+fmt.Sprint(a...))
+}
+
+func TestWithFallback(t *testing.T) {
+	base := &recordLogger{}
+	calls := 0
+	l := logger.With(base, `request_id`, `abc`, `lazy`, logger.Valuer(func() any {
+		calls++
+		return `v`
+	}))
+	assert.Equal(t, 0, calls)
+	l = logger.With(l, `user`, `a b`, `odd`)
+	l.Infof(`hello %d`, 1)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{`INFO hello 1 request_id=abc lazy=v user="a b" odd=<nil>`}, base.lines)
+	assert.Same(t, base, logger.With(base))
+}
+
+func TestSlogAdapters(t *testing.T) {
+	// slog 作为 Logger
+	buf := new(bytes.Buffer)
+	l := logger.NewSlogLogger(slog.New(slog.NewJSONHandler(buf, nil)))
+	logger.With(l, `request_id`, `abc`, `session`, logger.Valuer(func() any { return `s1` })).Infof(`hello %s`, `world`)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, `hello world`, entry[`msg`])
+	assert.Equal(t, `INFO`, entry[`level`])
+	assert.Equal(t, `abc`, entry[`request_id`])
+	assert.Equal(t, `s1`, entry[`session`])
+
+	// Valuer 在输出日志时才求值
+	buf.Reset()
+	calls := 0
+	lazy := logger.With(l, `lazy`, logger.Valuer(func() any {
+		calls++
+		return calls
+	}))
+	assert.Equal(t, 0, calls)
+	lazy.Info(`first`)
+	lazy.Info(`second`)
+	assert.Equal(t, 2, calls)
+	assert.Contains(t, buf.String(), `"msg":"second","lazy":2`)
+
+	// Logger 作为 slog 的输出
+	base := &recordLogger{}
+	s := slog.New(logger.NewSlogHandler(base, slog.LevelInfo))
+	s.Debug(`ignored`)
+	s.With(`request_id`, `abc`).WithGroup(`http`).Warn(`slow`, `status`, 200, slog.Group(`route`, `name`, `home`))
+	assert.Equal(t, []string{`WARN slow request_id=abc http.status=200 http.route.name=home`}, base.lines)
+}
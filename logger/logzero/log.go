@@ -19,6 +19,8 @@ var (
 	_                    logger.Logger = &Logger{}
 )
 
+var _ logger.FieldsLogger = &Logger{}
+
 func SetGlobal(l *Logger) {
 	global = l
 }
@@ -151,6 +153,34 @@ func (a *Logger) GetLogger(category string, writers ...io.Writer) *Logger {
 	return subLogger
 }
 
+// WithFields 返回附加了字段的子 Logger(logger.Valuer 类型的值在输出日志时才求值)
+func (a *Logger) WithFields(keyValues ...any) logger.Logger {
+	var fields, lazy []any
+	for i := 0; i < len(keyValues); i += 2 {
+		key := fmt.Sprint(keyValues[i])
+		var value any
+		if i+1 < len(keyValues) {
+			value = keyValues[i+1]
+		}
+		if _, ok := value.(logger.Valuer); ok {
+			lazy = append(lazy, key, value)
+		} else {
+			fields = append(fields, key, value)
+		}
+	}
+	l := a.Logger.With().Fields(fields).Logger()
+	if len(lazy) > 0 {
+		l = l.Hook(zerolog.HookFunc(func(e *zerolog.Event, _ zerolog.Level, _ string) {
+			for i := 0; i < len(lazy); i += 2 {
+				e.Interface(lazy[i].(string), logger.FieldValue(lazy[i+1]))
+			}
+		}))
+	}
+	sub := *a
+	sub.Logger = &l
+	return &sub
+}
+
 func (a *Logger) Write(p []byte) (int, error) {
 	n := len(p)
 	if n > 0 && p[n-1] == '\n' {
@@ -1,10 +1,15 @@
 package logzero_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"log"
 	"os"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/webx-top/echo/logger"
 	"github.com/webx-top/echo/logger/logzero"
 )
 
@@ -28,3 +33,22 @@ func TestLog(t *testing.T) {
 	log.SetOutput(logzero.GetLogger(`test2`, f))
 	log.Println(`test log message(test)`)
 }
+
+func TestWithFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := logzero.NewLogger(-1, buf)
+	id := `first`
+	sub := logger.With(l, `request_id`, `abc`, `session`, logger.Valuer(func() any { return id }))
+	id = `second`
+	sub.Info(`hello`)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, `hello`, entry[`message`])
+	assert.Equal(t, `abc`, entry[`request_id`])
+	assert.Equal(t, `second`, entry[`session`])
+
+	// 不影响原来的 Logger
+	buf.Reset()
+	l.Info(`plain`)
+	assert.NotContains(t, buf.String(), `request_id`)
+}
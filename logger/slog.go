@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+var (
+	_ slog.Handler = &slogHandler{}
+	_ FieldsLogger = &slogLogger{}
+)
+
+// NewSlogHandler 以 Logger 作为 slog 的输出(slog.New(logger.NewSlogHandler(l)))。
+// level 为启用的最低级别，默认为 slog.LevelDebug(由 Logger 自行过滤)
+func NewSlogHandler(l Logger, level ...slog.Leveler) slog.Handler {
+	h := &slogHandler{l: l}
+	if len(level) > 0 {
+		h.level = level[0]
+	}
+	return h
+}
+
+type slogHandler struct {
+	l      Logger
+	level  slog.Leveler
+	fields []any
+	prefix string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelDebug
+	if h.level != nil {
+		minLevel = h.level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]any, len(h.fields), len(h.fields)+r.NumAttrs()*2)
+	copy(fields, h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.prefix, a)
+		return true
+	})
+	l := With(h.l, fields...)
+	switch {
+	case r.Level >= slog.LevelError:
+		l.Error(r.Message)
+	case r.Level >= slog.LevelWarn:
+		l.Warn(r.Message)
+	case r.Level >= slog.LevelInfo:
+		l.Info(r.Message)
+	default:
+		l.Debug(r.Message)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.fields = make([]any, len(h.fields), len(h.fields)+len(attrs)*2)
+	copy(c.fields, h.fields)
+	for _, a := range attrs {
+		c.fields = appendSlogAttr(c.fields, h.prefix, a)
+	}
+	return &c
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	c := *h
+	c.prefix = h.prefix + name + `.`
+	return &c
+}
+
+// appendSlogAttr 分组中的字段名以 group.key 表示
+func appendSlogAttr(fields []any, prefix string, a slog.Attr) []any {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if len(a.Key) > 0 {
+			prefix += a.Key + `.`
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendSlogAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, prefix+a.Key, a.Value.Any())
+}
+
+// NewSlogLogger 以 *slog.Logger 作为 Logger(例如 e.SetLogger(logger.NewSlogLogger(slog.Default())))
+func NewSlogLogger(l *slog.Logger) FieldsLogger {
+	return &slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l    *slog.Logger
+	lazy []any // Valuer 类型的字段。slog 的 Handler 在 With 时就会对 LogValuer 求值，所以在输出日志时才添加
+}
+
+// slogValuer 将 Valuer 转换为 slog.LogValuer
+type slogValuer Valuer
+
+func (v slogValuer) LogValue() slog.Value {
+	return slog.AnyValue(v())
+}
+
+func (s *slogLogger) WithFields(keyValues ...any) Logger {
+	fields := normalizeFields(keyValues)
+	eager := make([]any, 0, len(fields))
+	lazy := s.lazy[:len(s.lazy):len(s.lazy)]
+	for i := 0; i < len(fields); i += 2 {
+		if fn, ok := fields[i+1].(Valuer); ok {
+			lazy = append(lazy, fields[i], slogValuer(fn))
+		} else {
+			eager = append(eager, fields[i], fields[i+1])
+		}
+	}
+	l := s.l
+	if len(eager) > 0 {
+		l = l.With(eager...)
+	}
+	return &slogLogger{l: l, lazy: lazy}
+}
+
+func (s *slogLogger) log(level slog.Level, msg string) {
+	s.l.Log(context.Background(), level, msg, s.lazy...)
+}
+
+// Slog 返回 *slog.Logger
+func (s *slogLogger) Slog() *slog.Logger {
+	return s.l
+}
+
+func (s *slogLogger) Debug(a ...any) {
+	s.log(slog.LevelDebug, fmt.Sprint(a...))
+}
+
+func (s *slogLogger) Debugf(format string, a ...any) {
+	s.log(slog.LevelDebug, fmt.Sprintf(format, a...))
+}
+
+func (s *slogLogger) Info(a ...any) {
+	s.log(slog.LevelInfo, fmt.Sprint(a...))
+}
+
+func (s *slogLogger) Infof(format string, a ...any) {
+	s.log(slog.LevelInfo, fmt.Sprintf(format, a...))
+}
+
+func (s *slogLogger) Warn(a ...any) {
+	s.log(slog.LevelWarn, fmt.Sprint(a...))
+}
+
+func (s *slogLogger) Warnf(format string, a ...any) {
+	s.log(slog.LevelWarn, fmt.Sprintf(format, a...))
+}
+
+func (s *slogLogger) Error(a ...any) {
+	s.log(slog.LevelError, fmt.Sprint(a...))
+}
+
+func (s *slogLogger) Errorf(format string, a ...any) {
+	s.log(slog.LevelError, fmt.Sprintf(format, a...))
+}
+
+func (s *slogLogger) Fatal(a ...any) {
+	s.log(slog.LevelError, fmt.Sprint(a...))
+	os.Exit(1)
+}
+
+func (s *slogLogger) Fatalf(format string, a ...any) {
+	s.log(slog.LevelError, fmt.Sprintf(format, a...))
+	os.Exit(1)
+}
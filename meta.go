@@ -18,6 +18,8 @@
 
 package echo
 
+import "reflect"
+
 type MetaValidator interface {
 	MethodGetter
 	FiltersGetter
@@ -32,6 +34,16 @@ type MethodGetter interface {
 	Methods() []string
 }
 
+// RequestTypeGetter 获取请求数据类型(用于生成文档等)
+type RequestTypeGetter interface {
+	RequestType() reflect.Type
+}
+
+// ResponseTypeGetter 获取响应数据类型(用于生成文档等)
+type ResponseTypeGetter interface {
+	ResponseType() reflect.Type
+}
+
 type RequestValidator func() MetaValidator
 
 func NewBaseRequestValidator(data any, method ...string) *BaseRequestValidator {
@@ -88,6 +100,29 @@ func (m *MetaHandler) Meta() H {
 	return m.meta
 }
 
+// RequestType 请求数据结构体类型。没有指定请求数据时返回 nil
+func (m *MetaHandler) RequestType() reflect.Type {
+	if m.request == nil {
+		return nil
+	}
+	recv := m.request()
+	if bs, ok := recv.(*BaseRequestValidator); ok {
+		if bs.data == nil {
+			return nil
+		}
+		return reflect.TypeOf(bs.data)
+	}
+	return reflect.TypeOf(recv)
+}
+
+// ResponseType 响应数据类型。仅在被包装的 Handler 提供了类型信息时有效
+func (m *MetaHandler) ResponseType() reflect.Type {
+	if v, y := m.Handler.(ResponseTypeGetter); y {
+		return v.ResponseType()
+	}
+	return nil
+}
+
 func (m *MetaHandler) Handle(c Context) error {
 	if m.request == nil {
 		return m.Handler.Handle(c)
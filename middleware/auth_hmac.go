@@ -0,0 +1,413 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
+)
+
+type (
+	// HMACAuthConfig defines the config for HMACAuth middleware.
+	HMACAuthConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// Scheme 签名方式。默认为 HMACSchemeCanonical；
+		// HMACSchemeBody 为 GitHub Webhook 风格的签名(只对请求正文签名，例如 X-Hub-Signature-256: sha256=<十六进制签名>)
+		Scheme string `json:"scheme"`
+
+		// SignatureHeader HMACSchemeBody 方式下签名所在的请求头。默认为 X-Hub-Signature-256
+		SignatureHeader string `json:"signatureHeader"`
+
+		// SignaturePrefix HMACSchemeBody 方式下签名值的前缀。默认为 sha256=
+		SignaturePrefix string `json:"signaturePrefix"`
+
+		// Keys 当前有效的密钥(键为密钥 ID)。轮换密钥时可以同时设置新旧密钥
+		Keys map[string][]byte `json:"-"`
+
+		// KeyGetter 根据密钥 ID 获取密钥，设置后不再使用 Keys。密钥不存在时返回 nil, nil
+		KeyGetter func(c echo.Context, keyID string) ([]byte, error) `json:"-"`
+
+		// Hash 签名使用的哈希函数。默认为 sha256.New
+		Hash func() hash.Hash `json:"-"`
+
+		// RequiredHeaders 必须参与签名的请求头。默认为 Host
+		RequiredHeaders []string `json:"requiredHeaders"`
+
+		// MaxSkew 允许的客户端与服务端时间偏差。默认 5 分钟
+		MaxSkew time.Duration `json:"maxSkew"`
+
+		// NonceStore 用于拒绝重放请求。默认为内存存储
+		NonceStore HMACNonceStore `json:"-"`
+
+		// MaxBodySize 计算正文摘要时读取的最大请求正文字节数，超出时返回 413。默认 10MB
+		MaxBodySize int64 `json:"maxBodySize"`
+
+		// ContextKey 验证通过后保存密钥 ID 的键(通过 c.Internal().Get 获取)。默认为 hmacKeyID
+		ContextKey string `json:"contextKey"`
+	}
+
+	// HMACNonceStore 一次性随机数存储
+	HMACNonceStore interface {
+		// Use 记录 nonce 在 ttl 内已被使用。已被使用过时返回 false
+		Use(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+	}
+)
+
+// 签名方式
+const (
+	// HMACSchemeCanonical 对请求方法、路径、网址查询参数、请求头、时间戳、nonce 和请求正文摘要签名(见 HMACSigner)
+	HMACSchemeCanonical = `canonical`
+	// HMACSchemeBody 只对请求正文签名(GitHub Webhook 风格)。没有时间戳和 nonce，不能防止重放
+	HMACSchemeBody = `body`
+)
+
+// 签名相关的请求头
+const (
+	// HeaderXSignature 格式为：keyId=<密钥 ID>,headers=<参与签名的请求头(小写，以分号分隔)>,signature=<十六进制签名>
+	HeaderXSignature          = `X-Signature`
+	HeaderXSignatureTimestamp = `X-Signature-Timestamp`
+	HeaderXSignatureNonce     = `X-Signature-Nonce`
+	HeaderXContentSHA256      = `X-Content-Sha256`
+	// HeaderXHubSignature256 GitHub Webhook 的签名请求头，格式为：sha256=<十六进制签名>
+	HeaderXHubSignature256 = `X-Hub-Signature-256`
+)
+
+var (
+	ErrHMACSignatureMissing   = errors.New("missing or malformed signature")
+	ErrHMACSignatureInvalid   = errors.New("invalid signature")
+	ErrHMACTimestampInvalid   = errors.New("signature timestamp is missing or out of range")
+	ErrHMACNonceMissing       = errors.New("missing signature nonce")
+	ErrHMACNonceUsed          = errors.New("signature nonce has already been used")
+	ErrHMACUnknownKey         = errors.New("unknown signature key")
+	ErrHMACHeaderNotSigned    = errors.New("required header is not signed")
+	ErrHMACContentDigestWrong = errors.New("content digest mismatch")
+
+	// DefaultHMACAuthConfig is the default HMACAuth middleware config.
+	DefaultHMACAuthConfig = HMACAuthConfig{
+		Skipper:         echo.DefaultSkipper,
+		Scheme:          HMACSchemeCanonical,
+		SignatureHeader: HeaderXHubSignature256,
+		SignaturePrefix: `sha256=`,
+		Hash:            sha256.New,
+		RequiredHeaders: []string{`Host`},
+		MaxSkew:         5 * time.Minute,
+		MaxBodySize:     10 << 20,
+		ContextKey:      `hmacKeyID`,
+	}
+)
+
+// HMACAuth returns an HMACAuth middleware which verifies signatures created by `HMACSigner`.
+//
+// For valid signature it calls the next handler.
+// For missing, invalid or replayed signature, it sends "401 - Unauthorized" response.
+func HMACAuth(keys map[string][]byte) echo.MiddlewareFunc {
+	config := DefaultHMACAuthConfig
+	config.Keys = keys
+	return HMACAuthWithConfig(config)
+}
+
+// HMACAuthWithConfig returns an HMACAuth middleware with config.
+// See `HMACAuth()`.
+//
+// 签名内容由 HMACCanonicalString 生成，包含请求方法、路径、网址查询参数、参与签名的请求头、时间戳、nonce 和请求正文的 SHA-256。
+// 时间戳与服务端时间相差超过 MaxSkew 或 nonce 在 2 倍 MaxSkew 内重复使用时拒绝请求。
+// Scheme 为 HMACSchemeBody 时只验证 SignatureHeader 中对请求正文的签名，依次尝试 Keys 中的密钥(设置 KeyGetter 时以空的密钥 ID 获取密钥)
+func HMACAuthWithConfig(config HMACAuthConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultHMACAuthConfig.Skipper
+	}
+	if len(config.Scheme) == 0 {
+		config.Scheme = DefaultHMACAuthConfig.Scheme
+	}
+	if len(config.SignatureHeader) == 0 {
+		config.SignatureHeader = DefaultHMACAuthConfig.SignatureHeader
+	}
+	if len(config.SignaturePrefix) == 0 {
+		config.SignaturePrefix = DefaultHMACAuthConfig.SignaturePrefix
+	}
+	if config.Hash == nil {
+		config.Hash = DefaultHMACAuthConfig.Hash
+	}
+	if config.RequiredHeaders == nil {
+		config.RequiredHeaders = DefaultHMACAuthConfig.RequiredHeaders
+	}
+	if config.MaxSkew <= 0 {
+		config.MaxSkew = DefaultHMACAuthConfig.MaxSkew
+	}
+	if config.NonceStore == nil {
+		config.NonceStore = NewHMACNonceMemoryStore()
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultHMACAuthConfig.MaxBodySize
+	}
+	if len(config.ContextKey) == 0 {
+		config.ContextKey = DefaultHMACAuthConfig.ContextKey
+	}
+	if config.KeyGetter == nil && len(config.Keys) == 0 {
+		panic("echo: hmac-auth middleware requires keys or a key getter function")
+	}
+	if config.Scheme == HMACSchemeBody {
+		return hmacBodyAuth(config)
+	}
+	if config.KeyGetter == nil {
+		keys := config.Keys
+		config.KeyGetter = func(_ echo.Context, keyID string) ([]byte, error) {
+			return keys[keyID], nil
+		}
+	}
+	required := make([]string, len(config.RequiredHeaders))
+	for i, name := range config.RequiredHeaders {
+		required[i] = strings.ToLower(name)
+	}
+	unauthorized := func(err error) error {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error()).SetRaw(err)
+	}
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			req := c.Request()
+			header := req.Header()
+			keyID, signedHeaders, signature, err := parseHMACSignature(header.Get(HeaderXSignature))
+			if err != nil {
+				return unauthorized(err)
+			}
+			for _, name := range required {
+				if !hmacContains(signedHeaders, name) {
+					return unauthorized(ErrHMACHeaderNotSigned)
+				}
+			}
+			timestamp := header.Get(HeaderXSignatureTimestamp)
+			ts, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				return unauthorized(ErrHMACTimestampInvalid)
+			}
+			if skew := time.Since(time.Unix(ts, 0)); skew > config.MaxSkew || skew < -config.MaxSkew {
+				return unauthorized(ErrHMACTimestampInvalid)
+			}
+			nonce := header.Get(HeaderXSignatureNonce)
+			if len(nonce) == 0 {
+				return unauthorized(ErrHMACNonceMissing)
+			}
+			secret, err := config.KeyGetter(c, keyID)
+			if err != nil {
+				return err
+			}
+			if len(secret) == 0 {
+				return unauthorized(ErrHMACUnknownKey)
+			}
+
+			body, err := hmacReadBody(req, config.MaxBodySize)
+			if err != nil {
+				return err
+			}
+			digest := hmacBodyDigest(body)
+			if v := header.Get(HeaderXContentSHA256); len(v) > 0 && v != digest {
+				return unauthorized(ErrHMACContentDigestWrong)
+			}
+
+			headerValues := make([]string, len(signedHeaders))
+			for i, name := range signedHeaders {
+				if name == `host` {
+					headerValues[i] = req.Host()
+				} else {
+					headerValues[i] = strings.Join(header.Values(name), `,`)
+				}
+			}
+			canonical := HMACCanonicalString(req.Method(), req.URL().Path(), req.URL().Query().Encode(), signedHeaders, headerValues, timestamp, nonce, digest)
+			mac := hmac.New(config.Hash, secret)
+			mac.Write([]byte(canonical))
+			if !hmac.Equal(mac.Sum(nil), signature) {
+				return unauthorized(ErrHMACSignatureInvalid)
+			}
+			// 签名验证通过后才记录 nonce，避免伪造的请求占用 nonce
+			ok, err := config.NonceStore.Use(c, keyID+`:`+nonce, 2*config.MaxSkew)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return unauthorized(ErrHMACNonceUsed)
+			}
+			c.Internal().Set(config.ContextKey, keyID)
+			return next.Handle(c)
+		})
+	}
+}
+
+// hmacBodyAuth 验证只对请求正文的签名(HMACSchemeBody)
+func hmacBodyAuth(config HMACAuthConfig) echo.MiddlewareFunc {
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			req := c.Request()
+			value, ok := strings.CutPrefix(req.Header().Get(config.SignatureHeader), config.SignaturePrefix)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, ErrHMACSignatureMissing.Error()).SetRaw(ErrHMACSignatureMissing)
+			}
+			signature, err := hex.DecodeString(value)
+			if err != nil || len(signature) == 0 {
+				return echo.NewHTTPError(http.StatusUnauthorized, ErrHMACSignatureMissing.Error()).SetRaw(ErrHMACSignatureMissing)
+			}
+			body, err := hmacReadBody(req, config.MaxBodySize)
+			if err != nil {
+				return err
+			}
+			verify := func(secret []byte) bool {
+				mac := hmac.New(config.Hash, secret)
+				mac.Write(body)
+				return hmac.Equal(mac.Sum(nil), signature)
+			}
+			if config.KeyGetter != nil {
+				secret, err := config.KeyGetter(c, ``)
+				if err != nil {
+					return err
+				}
+				if len(secret) > 0 && verify(secret) {
+					return next.Handle(c)
+				}
+			} else {
+				for keyID, secret := range config.Keys {
+					if verify(secret) {
+						c.Internal().Set(config.ContextKey, keyID)
+						return next.Handle(c)
+					}
+				}
+			}
+			return echo.NewHTTPError(http.StatusUnauthorized, ErrHMACSignatureInvalid.Error()).SetRaw(ErrHMACSignatureInvalid)
+		})
+	}
+}
+
+// hmacReadBody 读取请求正文(最多 maxSize 字节)并重新设置，以便后续的处理函数读取
+func hmacReadBody(req engine.Request, maxSize int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(req.Body(), maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge)
+	}
+	req.SetBody(bytes.NewReader(body))
+	return body, nil
+}
+
+// HMACCanonicalString 生成签名内容。signedHeaders 为小写的请求头名称，headerValues 为对应的值
+func HMACCanonicalString(method string, path string, query string, signedHeaders []string, headerValues []string, timestamp string, nonce string, bodyDigest string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(method))
+	b.WriteString("\n")
+	b.WriteString(path)
+	b.WriteString("\n")
+	b.WriteString(query)
+	b.WriteString("\n")
+	for i, name := range signedHeaders {
+		b.WriteString(name)
+		b.WriteString(`:`)
+		b.WriteString(strings.TrimSpace(headerValues[i]))
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.Join(signedHeaders, `;`))
+	b.WriteString("\n")
+	b.WriteString(timestamp)
+	b.WriteString("\n")
+	b.WriteString(nonce)
+	b.WriteString("\n")
+	b.WriteString(bodyDigest)
+	return b.String()
+}
+
+func hmacBodyDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacContains(list []string, name string) bool {
+	for _, v := range list {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parseHMACSignature(value string) (keyID string, signedHeaders []string, signature []byte, err error) {
+	if len(value) == 0 {
+		err = ErrHMACSignatureMissing
+		return
+	}
+	for _, part := range strings.Split(value, `,`) {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), `=`)
+		if !ok {
+			err = ErrHMACSignatureMissing
+			return
+		}
+		switch k {
+		case `keyId`:
+			keyID = v
+		case `headers`:
+			if len(v) > 0 {
+				signedHeaders = strings.Split(strings.ToLower(v), `;`)
+			}
+		case `signature`:
+			signature, err = hex.DecodeString(v)
+			if err != nil {
+				err = ErrHMACSignatureMissing
+				return
+			}
+		}
+	}
+	if len(keyID) == 0 || len(signature) == 0 {
+		err = ErrHMACSignatureMissing
+	}
+	return
+}
+
+// NewHMACNonceMemoryStore 创建内存 nonce 存储。仅适用于单实例部署
+func NewHMACNonceMemoryStore() *HMACNonceMemoryStore {
+	return &HMACNonceMemoryStore{items: make(map[string]time.Time)}
+}
+
+// HMACNonceMemoryStore 内存 nonce 存储
+type HMACNonceMemoryStore struct {
+	items     map[string]time.Time
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func (s *HMACNonceMemoryStore) Use(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 定期清理过期的 nonce
+	if now.Sub(s.lastSweep) > ttl {
+		for k, expireAt := range s.items {
+			if now.After(expireAt) {
+				delete(s.items, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	if expireAt, ok := s.items[nonce]; ok && now.Before(expireAt) {
+		return false, nil
+	}
+	s.items[nonce] = now.Add(ttl)
+	return true, nil
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/webx-top/echo"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestHMACAuth(t *testing.T) {
+	e := echo.New()
+	e.Use(HMACAuthWithConfig(HMACAuthConfig{
+		Keys: map[string][]byte{
+			`old`: []byte(`secret-1`),
+			`new`: []byte(`secret-2`),
+		},
+		RequiredHeaders: []string{`Host`, `Content-Type`},
+	}))
+	e.Post(`/hooks`, func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body())
+		return c.String(c.Internal().String(`hmacKeyID`) + `:` + string(body))
+	})
+	e.RebuildRouter()
+
+	var signed *http.Request
+	send := func(signer *HMACSigner, body string, modify ...func(*http.Request)) *httptest.ResponseRecorder {
+		return myTesting.Request(http.MethodPost, `/hooks?b=2&a=1`, e, func(r *http.Request) {
+			r.Host = `example.com`
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			r.Body = io.NopCloser(strings.NewReader(body))
+			if signer != nil {
+				require.NoError(t, signer.Sign(r))
+			}
+			for _, fn := range modify {
+				fn(r)
+			}
+			signed = r
+		})
+	}
+	signer := NewHMACSigner(`new`, []byte(`secret-2`), `Host`, `Content-Type`)
+
+	rec := send(signer, `{"event":"push"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `new:{"event":"push"}`, rec.Body.String())
+
+	// 重放
+	replay := signed.Header.Clone()
+	rec = send(nil, `{"event":"push"}`, func(r *http.Request) { r.Header = replay })
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// 轮换中的旧密钥
+	rec = send(NewHMACSigner(`old`, []byte(`secret-1`), `Host`, `Content-Type`), `{}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `old:{}`, rec.Body.String())
+
+	// 错误的密钥、未知的密钥、缺少签名
+	assert.Equal(t, http.StatusUnauthorized, send(NewHMACSigner(`new`, []byte(`wrong`), `Host`, `Content-Type`), `{}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, send(NewHMACSigner(`gone`, []byte(`secret-2`), `Host`, `Content-Type`), `{}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, send(nil, `{}`).Code)
+
+	// 缺少必须签名的请求头
+	assert.Equal(t, http.StatusUnauthorized, send(NewHMACSigner(`new`, []byte(`secret-2`)), `{}`).Code)
+
+	// 篡改正文、请求头或网址
+	assert.Equal(t, http.StatusUnauthorized, send(signer, `{}`, func(r *http.Request) {
+		r.Body = io.NopCloser(strings.NewReader(`{"x":1}`))
+	}).Code)
+	assert.Equal(t, http.StatusUnauthorized, send(signer, `{}`, func(r *http.Request) {
+		r.Body = io.NopCloser(strings.NewReader(`{"x":1}`))
+		r.Header.Del(HeaderXContentSHA256)
+	}).Code)
+	assert.Equal(t, http.StatusUnauthorized, send(signer, `{}`, func(r *http.Request) {
+		r.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	}).Code)
+	assert.Equal(t, http.StatusUnauthorized, send(signer, `{}`, func(r *http.Request) {
+		r.URL.RawQuery = `a=1&b=3`
+	}).Code)
+
+	// 超出允许的时间偏差
+	stale := NewHMACSigner(`new`, []byte(`secret-2`), `Host`, `Content-Type`)
+	stale.Now = func() time.Time { return time.Now().Add(-10 * time.Minute) }
+	assert.Equal(t, http.StatusUnauthorized, send(stale, `{}`).Code)
+
+	// 通过 Transport 自动签名
+	client := &http.Client{Transport: signer.Transport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(myTesting.WrapRequest(r), myTesting.WrapResponse(r, rec))
+		return rec.Result(), nil
+	}))}
+	req, _ := http.NewRequest(http.MethodPost, `http://example.com/hooks?a=1`, strings.NewReader(`{"via":"client"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `new:{"via":"client"}`, string(body))
+	assert.Empty(t, req.Header.Get(HeaderXSignature))
+}
+
+func TestHMACAuthBody(t *testing.T) {
+	e := echo.New()
+	e.Use(HMACAuthWithConfig(HMACAuthConfig{
+		Scheme: HMACSchemeBody,
+		Keys: map[string][]byte{
+			`github`: []byte(`It's a Secret to Everybody`),
+		},
+	}))
+	e.Post(`/hooks`, func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body())
+		return c.String(c.Internal().String(`hmacKeyID`) + `:` + string(body))
+	})
+	e.RebuildRouter()
+
+	send := func(signature string, body string) *httptest.ResponseRecorder {
+		return myTesting.Request(http.MethodPost, `/hooks`, e, func(r *http.Request) {
+			if len(signature) > 0 {
+				r.Header.Set(HeaderXHubSignature256, signature)
+			}
+			r.Body = io.NopCloser(strings.NewReader(body))
+		})
+	}
+	// 示例来自 GitHub 文档(Validating webhook deliveries)
+	const signature = `sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17`
+	rec := send(signature, `Hello, World!`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `github:Hello, World!`, rec.Body.String())
+
+	rec = send(signature, `Hello, World?`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = send(strings.TrimPrefix(signature, `sha256=`), `Hello, World!`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = send(``, `Hello, World!`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
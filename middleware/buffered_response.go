@@ -0,0 +1,235 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
+	"github.com/webx-top/echo/engine/standard"
+)
+
+type (
+	// bufferedResponse 缓存处理函数的响应，调用 flushTo 时才输出。
+	// Context 超时或调用 expire() 后丢弃所有写入(返回 http.ErrHandlerTimeout)
+	bufferedResponse struct {
+		engine.Response
+		request  engine.Request
+		ctx      context.Context
+		mu       sync.Mutex
+		header   *standard.Header
+		status   int
+		wrote    bool
+		buf      bytes.Buffer
+		writer   io.Writer
+		timedOut bool
+	}
+
+	bufferedStdResponseWriter struct {
+		*bufferedResponse
+	}
+)
+
+func newBufferedResponse(resp engine.Response, req engine.Request, ctx context.Context) *bufferedResponse {
+	w := &bufferedResponse{
+		Response: resp,
+		request:  req,
+		ctx:      ctx,
+		header:   standard.NewHeader(resp.Header().Std().Clone()),
+	}
+	w.writer = &w.buf
+	return w
+}
+
+// expired 超时后(包括 Context 已超时但尚未调用 expire() 时)丢弃所有写入
+func (w *bufferedResponse) expired() bool {
+	return w.timedOut || w.ctx.Err() == context.DeadlineExceeded
+}
+
+// expire 丢弃之后的所有写入
+func (w *bufferedResponse) expire() {
+	w.mu.Lock()
+	w.timedOut = true
+	w.mu.Unlock()
+}
+
+// notModified 以 304 响应代替已缓存的响应
+func (w *bufferedResponse) notModified() {
+	w.mu.Lock()
+	w.status = http.StatusNotModified
+	w.buf.Reset()
+	w.header.Del(echo.HeaderContentType)
+	w.header.Del(echo.HeaderContentLength)
+	w.mu.Unlock()
+}
+
+// flushTo 将缓存的响应输出到 dst
+func (w *bufferedResponse) flushTo(dst engine.Response) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	header := dst.Header()
+	std := w.header.Std()
+	for k := range header.Std() {
+		if _, ok := std[k]; !ok {
+			header.Del(k)
+		}
+	}
+	for k, v := range std {
+		header.Del(k)
+		for _, vv := range v {
+			header.Add(k, vv)
+		}
+	}
+	if !w.wrote {
+		return nil
+	}
+	if w.bodyAllowed() && w.request.Method() != echo.HEAD {
+		header.Set(echo.HeaderContentLength, strconv.Itoa(w.buf.Len()))
+	}
+	dst.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := dst.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *bufferedResponse) bodyAllowed() bool {
+	switch {
+	case w.status >= 100 && w.status < 200, w.status == http.StatusNoContent, w.status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+func (w *bufferedResponse) Header() engine.Header {
+	return w.header
+}
+
+func (w *bufferedResponse) WriteHeader(code int) {
+	w.mu.Lock()
+	if !w.expired() && !w.wrote {
+		w.status = code
+		w.wrote = true
+	}
+	w.mu.Unlock()
+}
+
+func (w *bufferedResponse) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.wrote {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		w.wrote = true
+	}
+	return w.writer.Write(b)
+}
+
+func (w *bufferedResponse) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedResponse) Size() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return int64(w.buf.Len())
+}
+
+func (w *bufferedResponse) Committed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.wrote
+}
+
+func (w *bufferedResponse) SetWriter(writer io.Writer) {
+	w.mu.Lock()
+	w.writer = writer
+	w.mu.Unlock()
+}
+
+func (w *bufferedResponse) Writer() io.Writer {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writer
+}
+
+func (w *bufferedResponse) KeepBody(_ bool) {
+}
+
+func (w *bufferedResponse) Body() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Bytes()
+}
+
+// Flush 响应会在处理函数结束后一次性输出
+func (w *bufferedResponse) Flush() {
+}
+
+func (w *bufferedResponse) Redirect(url string, code int) {
+	w.header.Set(echo.HeaderLocation, url)
+	w.WriteHeader(code)
+}
+
+func (w *bufferedResponse) NotFound() {
+	w.Error(http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
+
+func (w *bufferedResponse) Error(errMsg string, args ...int) {
+	code := http.StatusInternalServerError
+	if len(args) > 0 {
+		code = args[0]
+	}
+	w.WriteHeader(code)
+	w.Write(engine.Str2bytes(errMsg))
+}
+
+func (w *bufferedResponse) SetCookie(cookie *http.Cookie) {
+	w.header.Add(echo.HeaderSetCookie, cookie.String())
+}
+
+func (w *bufferedResponse) ServeFile(file string) {
+	http.ServeFile(w.StdResponseWriter(), w.request.StdRequest(), file)
+}
+
+func (w *bufferedResponse) ServeContent(content io.ReadSeeker, name string, modtime time.Time) {
+	http.ServeContent(w.StdResponseWriter(), w.request.StdRequest(), name, modtime, content)
+}
+
+func (w *bufferedResponse) Stream(step func(context.Context, io.Writer) (bool, error)) error {
+	for {
+		keepOpen, err := step(w.ctx, w)
+		if err != nil {
+			if err == context.Canceled {
+				return nil
+			}
+			return err
+		}
+		if !keepOpen {
+			return nil
+		}
+	}
+}
+
+func (w *bufferedResponse) StdResponseWriter() http.ResponseWriter {
+	return &bufferedStdResponseWriter{w}
+}
+
+func (w *bufferedStdResponseWriter) Header() http.Header {
+	return w.header.Std()
+}
@@ -0,0 +1,399 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine/standard"
+)
+
+type (
+	// CacheConfig defines the config for Cache middleware.
+	CacheConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// Store 缓存存储。默认为容量 1000 的内存 LRU 存储
+		Store CacheStore `json:"-"`
+
+		// TTL 缓存有效期。默认 1 分钟
+		TTL time.Duration `json:"ttl"`
+
+		// StaleWhileRevalidate 缓存过期后仍然可以使用的时长。
+		// 在此期间请求直接使用过期的缓存，同时在后台执行一次处理函数来更新缓存
+		StaleWhileRevalidate time.Duration `json:"staleWhileRevalidate"`
+
+		// Vary 参与缓存键计算的请求头(同时会添加到响应头 Vary 中)
+		Vary []string `json:"vary"`
+
+		// Methods 可以缓存的请求方法。默认为 GET 和 HEAD
+		Methods []string `json:"methods"`
+
+		// StatusCodes 可以缓存的响应状态码。默认为 200
+		StatusCodes []int `json:"statusCodes"`
+
+		// MaxBodySize 可以缓存的最大响应正文字节数。0 为不限制
+		MaxBodySize int `json:"maxBodySize"`
+
+		// DisableETag 不自动生成 ETag
+		DisableETag bool `json:"disableETag"`
+
+		// KeyGenerator 生成缓存键。默认由请求方法、主机名、路径和排序后的网址查询参数组成
+		KeyGenerator func(c echo.Context) string `json:"-"`
+
+		// AllowCookie 请求带有 Cookie 时也使用缓存(确定响应与 Cookie 无关时才开启)。
+		// 默认请求带有 Authorization 或 Cookie 时，只使用和保存 Cache-Control 包含 public 或 s-maxage 的响应
+		AllowCookie bool `json:"allowCookie"`
+
+		methods     map[string]struct{}
+		statusCodes map[int]struct{}
+	}
+
+	// CacheStore 响应缓存存储
+	CacheStore interface {
+		// Get 获取缓存。不存在时返回 nil, nil
+		Get(ctx context.Context, key string) (*CachedResponse, error)
+		// Set 保存缓存。ttl 为存储的有效期(已包含 StaleWhileRevalidate)
+		Set(ctx context.Context, key string, value *CachedResponse, ttl time.Duration) error
+		Delete(ctx context.Context, key string) error
+	}
+
+	// CachedResponse 缓存的响应
+	CachedResponse struct {
+		Status    int         `json:"status"`
+		Header    http.Header `json:"header"`
+		Body      []byte      `json:"body"`
+		StoredAt  time.Time   `json:"storedAt"`
+		ExpiresAt time.Time   `json:"expiresAt"`
+	}
+)
+
+// HeaderXCache 缓存状态响应头：HIT、STALE 或 MISS
+const HeaderXCache = `X-Cache`
+
+var (
+	// DefaultCacheConfig is the default Cache middleware config.
+	DefaultCacheConfig = CacheConfig{
+		Skipper:      echo.DefaultSkipper,
+		TTL:          time.Minute,
+		Methods:      []string{echo.GET, echo.HEAD},
+		StatusCodes:  []int{http.StatusOK},
+		KeyGenerator: DefaultCacheKeyGenerator,
+	}
+)
+
+// DefaultCacheKeyGenerator 默认的缓存键生成函数
+func DefaultCacheKeyGenerator(c echo.Context) string {
+	req := c.Request()
+	return req.Method() + ` ` + req.Host() + req.URL().Path() + `?` + req.URL().Query().Encode()
+}
+
+// Cache returns a middleware which caches the status, headers and body of responses.
+func Cache(ttl time.Duration, vary ...string) echo.MiddlewareFunc {
+	config := DefaultCacheConfig
+	config.TTL = ttl
+	config.Vary = vary
+	return CacheWithConfig(config)
+}
+
+// CacheWithConfig returns a Cache middleware with config.
+// See: `Cache()`.
+//
+// 可缓存的响应会带有自动生成的强 ETag(处理函数没有设置 ETag 时)，并支持 If-None-Match 返回 304。
+// 未命中时处理函数的响应先缓存在内存中，处理函数结束后才输出，所以不适用于 Context.Stream、SSE 等流式响应；
+// 压缩中间件需要在 Cache 之前注册(位于外层)，缓存的是压缩前的内容。
+// 设置了 Set-Cookie 或 Cache-Control 包含 no-store、no-cache、private 的响应不会被缓存。
+// 缓存过期后的 StaleWhileRevalidate 期间直接使用过期的缓存，同时在后台重新执行请求以更新缓存
+func CacheWithConfig(config CacheConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCacheConfig.Skipper
+	}
+	if config.Store == nil {
+		config.Store = NewCacheMemoryStore(1000)
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultCacheConfig.TTL
+	}
+	if len(config.Methods) == 0 {
+		config.Methods = DefaultCacheConfig.Methods
+	}
+	if len(config.StatusCodes) == 0 {
+		config.StatusCodes = DefaultCacheConfig.StatusCodes
+	}
+	if config.KeyGenerator == nil {
+		config.KeyGenerator = DefaultCacheConfig.KeyGenerator
+	}
+	config.methods = make(map[string]struct{}, len(config.Methods))
+	for _, method := range config.Methods {
+		config.methods[strings.ToUpper(method)] = struct{}{}
+	}
+	config.statusCodes = make(map[int]struct{}, len(config.StatusCodes))
+	for _, status := range config.StatusCodes {
+		config.statusCodes[status] = struct{}{}
+	}
+	var (
+		group      singleflight.Group
+		refreshing sync.Map
+	)
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			if _, ok := config.methods[c.Request().Method()]; !ok {
+				return next.Handle(c)
+			}
+			key := config.key(c)
+			if c.Value(cacheRefreshKey{}) == &config {
+				// 后台更新缓存的请求
+				_, err := config.capture(c, next, key)
+				return err
+			}
+			entry, err := config.Store.Get(c, key)
+			if err != nil {
+				c.Logger().Warnf(`cache: failed to get %q: %v`, key, err)
+				entry = nil
+			}
+			credentialed := config.credentialed(c)
+			if entry != nil && (!credentialed || sharedCacheable(entry.Header)) {
+				now := time.Now()
+				if now.Before(entry.ExpiresAt) {
+					return config.serve(c, entry, `HIT`)
+				}
+				if now.Before(entry.ExpiresAt.Add(config.StaleWhileRevalidate)) {
+					if _, loaded := refreshing.LoadOrStore(key, struct{}{}); !loaded {
+						config.refresh(c, func() {
+							refreshing.Delete(key)
+						})
+					}
+					return config.serve(c, entry, `STALE`)
+				}
+			}
+			if credentialed {
+				// 带有身份凭证的请求不与其它请求合并
+				_, err = config.capture(c, next, key)
+				return err
+			}
+
+			// 合并并发的未命中请求：只有一个请求执行处理函数，其余请求等待并使用其结果
+			var leader bool
+			v, err, _ := group.Do(key, func() (any, error) {
+				leader = true
+				return config.capture(c, next, key)
+			})
+			if leader {
+				return err
+			}
+			if entry, _ := v.(*CachedResponse); entry != nil {
+				return config.serve(c, entry, `HIT`)
+			}
+			return next.Handle(c)
+		})
+	}
+}
+
+// cacheRefreshKey 后台更新缓存的请求在 Context 中的标记(值为 *CacheConfig)
+type cacheRefreshKey struct{}
+
+// refresh 在后台重新执行当前请求以更新缓存，完成后调用 done
+func (config *CacheConfig) refresh(c echo.Context, done func()) {
+	e := c.Echo()
+	ctx := context.WithValue(context.WithoutCancel(c.StdContext()), cacheRefreshKey{}, config)
+	r := c.Request().StdRequest().Clone(ctx)
+	r.Header.Del(echo.HeaderIfNoneMatch)
+	r.Header.Del(echo.HeaderIfModifiedSince)
+	go func() {
+		defer done()
+		defer func() {
+			if rec := recover(); rec != nil {
+				e.Logger().Errorf(`cache: refresh %s panicked: %v`, r.URL.Path, rec)
+			}
+		}()
+		e.ServeHTTP(standard.NewRequest(r), standard.NewResponse(newDiscardResponseWriter(), r, e.Logger()))
+	}()
+}
+
+// discardResponseWriter 丢弃后台更新缓存的请求的输出
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func newDiscardResponseWriter() http.ResponseWriter {
+	return &discardResponseWriter{header: http.Header{}}
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
+
+// credentialed 请求是否带有 Authorization(或 AllowCookie 为 false 时的 Cookie)
+func (config *CacheConfig) credentialed(c echo.Context) bool {
+	header := c.Request().Header()
+	if len(header.Get(echo.HeaderAuthorization)) > 0 {
+		return true
+	}
+	return !config.AllowCookie && len(header.Get(echo.HeaderCookie)) > 0
+}
+
+// sharedCacheable 响应是否声明可以由共享缓存提供给其它用户(Cache-Control 包含 public 或 s-maxage)
+func sharedCacheable(header http.Header) bool {
+	for _, directive := range strings.Split(header.Get(echo.HeaderCacheControl), `,`) {
+		name, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), `=`)
+		switch name {
+		case `public`, `s-maxage`:
+			return true
+		}
+	}
+	return false
+}
+
+func (config *CacheConfig) key(c echo.Context) string {
+	key := config.KeyGenerator(c)
+	if len(config.Vary) == 0 {
+		return key
+	}
+	var b strings.Builder
+	b.WriteString(key)
+	header := c.Request().Header()
+	for _, name := range config.Vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(`: `)
+		b.WriteString(strings.Join(header.Values(name), `,`))
+	}
+	return b.String()
+}
+
+// capture 执行处理函数并缓存响应。响应不可缓存时返回 nil。
+// 处理函数的响应会先缓存在内存中，以便在输出之前添加 ETag 并处理 If-None-Match
+func (config *CacheConfig) capture(c echo.Context, next echo.Handler, key string) (*CachedResponse, error) {
+	resp := c.Response()
+	for _, name := range config.Vary {
+		resp.Header().Add(echo.HeaderVary, name)
+	}
+	resp.Header().Set(HeaderXCache, `MISS`)
+	w := newBufferedResponse(resp, c.Request(), c)
+	xc := c.Object()
+	xc.SetResponse(w)
+	err := next.Handle(c)
+	xc.SetResponse(resp)
+	var entry *CachedResponse
+	if err == nil {
+		entry = config.store(c, w, key)
+	}
+	if entry != nil {
+		if etag := entry.Header.Get(echo.HeaderETag); len(etag) > 0 && etagMatch(c.Request().Header().Get(echo.HeaderIfNoneMatch), etag) {
+			w.notModified()
+		}
+	}
+	if ferr := w.flushTo(resp); ferr != nil && err == nil {
+		err = ferr
+	}
+	return entry, err
+}
+
+// store 保存缓存的响应。自动生成的 ETag 会同时添加到当前响应中
+func (config *CacheConfig) store(c echo.Context, w *bufferedResponse, key string) *CachedResponse {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.wrote || !config.cacheable(c, w.status, w.header.Std()) {
+		return nil
+	}
+	body := w.buf.Bytes()
+	if config.MaxBodySize > 0 && len(body) > config.MaxBodySize {
+		return nil
+	}
+	if !config.DisableETag && len(w.header.Get(echo.HeaderETag)) == 0 {
+		w.header.Set(echo.HeaderETag, strongETag(body))
+	}
+	header := w.header.Std().Clone()
+	header.Del(HeaderXCache)
+	header.Del(echo.HeaderContentLength)
+	now := time.Now()
+	entry := &CachedResponse{
+		Status:    w.status,
+		Header:    header,
+		Body:      bytes.Clone(body),
+		StoredAt:  now,
+		ExpiresAt: now.Add(config.TTL),
+	}
+	if err := config.Store.Set(c, key, entry, config.TTL+config.StaleWhileRevalidate); err != nil {
+		c.Logger().Warnf(`cache: failed to set %q: %v`, key, err)
+	}
+	return entry
+}
+
+func (config *CacheConfig) cacheable(c echo.Context, status int, header http.Header) bool {
+	if _, ok := config.statusCodes[status]; !ok {
+		return false
+	}
+	if len(header.Get(echo.HeaderSetCookie)) > 0 {
+		return false
+	}
+	for _, directive := range strings.Split(header.Get(echo.HeaderCacheControl), `,`) {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case `no-store`, `no-cache`, `private`:
+			return false
+		}
+	}
+	return !config.credentialed(c) || sharedCacheable(header)
+}
+
+func (config *CacheConfig) serve(c echo.Context, entry *CachedResponse, state string) error {
+	resp := c.Response()
+	header := resp.Header()
+	for k, v := range entry.Header {
+		header.Del(k)
+		for _, vv := range v {
+			header.Add(k, vv)
+		}
+	}
+	header.Set(HeaderXCache, state)
+	header.Set(echo.HeaderAge, strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+	if etag := entry.Header.Get(echo.HeaderETag); len(etag) > 0 && etagMatch(c.Request().Header().Get(echo.HeaderIfNoneMatch), etag) {
+		return c.NotModified()
+	}
+	resp.WriteHeader(entry.Status)
+	if c.Request().Method() == echo.HEAD {
+		return nil
+	}
+	_, err := resp.Write(entry.Body)
+	return err
+}
+
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatch If-None-Match 使用弱比较
+func etagMatch(ifNoneMatch string, etag string) bool {
+	if len(ifNoneMatch) == 0 {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, `W/`)
+	for _, v := range strings.Split(ifNoneMatch, `,`) {
+		v = strings.TrimSpace(v)
+		if v == `*` || strings.TrimPrefix(v, `W/`) == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// NewCacheMemoryStore 创建内存 LRU 缓存存储。capacity 为最多保存的条目数(小于等于 0 时为 1000)
+func NewCacheMemoryStore(capacity int) *CacheMemoryStore {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &CacheMemoryStore{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// CacheMemoryStore 内存 LRU 缓存存储
+type CacheMemoryStore struct {
+	capacity int
+	items    map[string]*list.Element
+	lru      *list.List
+	mu       sync.Mutex
+}
+
+type cacheMemoryItem struct {
+	key      string
+	value    *CachedResponse
+	expireAt time.Time
+}
+
+func (s *CacheMemoryStore) Get(_ context.Context, key string) (*CachedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	item := elem.Value.(*cacheMemoryItem)
+	if !item.expireAt.IsZero() && time.Now().After(item.expireAt) {
+		s.remove(elem)
+		return nil, nil
+	}
+	s.lru.MoveToFront(elem)
+	return item.value, nil
+}
+
+func (s *CacheMemoryStore) Set(_ context.Context, key string, value *CachedResponse, ttl time.Duration) error {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		item := elem.Value.(*cacheMemoryItem)
+		item.value = value
+		item.expireAt = expireAt
+		s.lru.MoveToFront(elem)
+		return nil
+	}
+	s.items[key] = s.lru.PushFront(&cacheMemoryItem{key: key, value: value, expireAt: expireAt})
+	for s.lru.Len() > s.capacity {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+func (s *CacheMemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Len 当前保存的条目数
+func (s *CacheMemoryStore) Len() int {
+	s.mu.Lock()
+	n := s.lru.Len()
+	s.mu.Unlock()
+	return n
+}
+
+func (s *CacheMemoryStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.items, elem.Value.(*cacheMemoryItem).key)
+}
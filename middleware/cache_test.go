@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func TestCache(t *testing.T) {
+	e := echo.New()
+	var calls int32
+	e.Use(CacheWithConfig(CacheConfig{
+		TTL:  time.Minute,
+		Vary: []string{`Accept-Language`},
+	}))
+	e.Get(`/catalog`, func(c echo.Context) error {
+		n := atomic.AddInt32(&calls, 1)
+		c.Response().Header().Set(`X-Calls`, strconv.Itoa(int(n)))
+		return c.String(`catalog:` + c.Query(`page`) + `:` + c.Header(`Accept-Language`))
+	})
+	e.Get(`/private`, func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		c.Response().Header().Set(echo.HeaderCacheControl, `private`)
+		return c.String(`private`)
+	})
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodGet, `/catalog?page=1&sort=a`, e)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `catalog:1:`, rec.Body.String())
+	assert.Equal(t, `MISS`, rec.Header().Get(HeaderXCache))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 查询参数顺序不影响缓存键
+	rec = myTesting.Request(http.MethodGet, `/catalog?sort=a&page=1`, e)
+	assert.Equal(t, `catalog:1:`, rec.Body.String())
+	assert.Equal(t, `HIT`, rec.Header().Get(HeaderXCache))
+	assert.Equal(t, `1`, rec.Header().Get(`X-Calls`))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	etag := rec.Header().Get(echo.HeaderETag)
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, `Accept-Language`, rec.Header().Get(echo.HeaderVary))
+
+	rec = myTesting.Request(http.MethodGet, `/catalog?page=1&sort=a`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderIfNoneMatch, `W/`+etag)
+	})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// Vary
+	rec = myTesting.Request(http.MethodGet, `/catalog?page=1&sort=a`, e, func(r *http.Request) {
+		r.Header.Set(`Accept-Language`, `en`)
+	})
+	assert.Equal(t, `catalog:1:en`, rec.Body.String())
+	assert.Equal(t, `MISS`, rec.Header().Get(HeaderXCache))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// 不可缓存的响应
+	for i := 0; i < 2; i++ {
+		rec = myTesting.Request(http.MethodGet, `/private`, e)
+		assert.Equal(t, `MISS`, rec.Header().Get(HeaderXCache))
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestCacheCoalescing(t *testing.T) {
+	e := echo.New()
+	var calls int32
+	e.Use(Cache(time.Minute))
+	e.Get(`/slow`, func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return c.String(`slow`)
+	})
+	e.RebuildRouter()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := myTesting.Request(http.MethodGet, `/slow`, e)
+			assert.Equal(t, `slow`, rec.Body.String())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	e := echo.New()
+	store := NewCacheMemoryStore(10)
+	var calls int32
+	e.Use(CacheWithConfig(CacheConfig{
+		Store:                store,
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: time.Minute,
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		n := atomic.AddInt32(&calls, 1)
+		return c.String(strconv.Itoa(int(n)))
+	})
+	e.RebuildRouter()
+	rec := myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, `1`, rec.Body.String())
+
+	key := `GET /?`
+	entry, _ := store.Get(context.Background(), key)
+	if !assert.NotNil(t, entry) {
+		return
+	}
+	entry.ExpiresAt = time.Now().Add(-time.Second)
+
+	// 过期后直接使用过期的缓存，并在后台更新缓存
+	rec = myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, `1`, rec.Body.String())
+	assert.Equal(t, `STALE`, rec.Header().Get(HeaderXCache))
+	assert.Eventually(t, func() bool {
+		entry, _ := store.Get(context.Background(), key)
+		return entry != nil && string(entry.Body) == `2`
+	}, time.Second, 5*time.Millisecond)
+	rec = myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, `2`, rec.Body.String())
+	assert.Equal(t, `HIT`, rec.Header().Get(HeaderXCache))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheETagOnMiss(t *testing.T) {
+	e := echo.New()
+	e.Use(Cache(time.Minute))
+	e.Get(`/`, func(c echo.Context) error {
+		return c.String(`content`)
+	})
+	e.Get(`/gzip`, func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentEncoding, `gzip`)
+		return c.Blob([]byte(`gzipped`))
+	})
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, `MISS`, rec.Header().Get(HeaderXCache))
+	etag := rec.Header().Get(echo.HeaderETag)
+	assert.NotEmpty(t, etag)
+	rec = myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, `HIT`, rec.Header().Get(HeaderXCache))
+	assert.Equal(t, etag, rec.Header().Get(echo.HeaderETag))
+
+	// 未命中时也支持 If-None-Match
+	rec = myTesting.Request(http.MethodGet, `/?v=2`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderIfNoneMatch, etag)
+	})
+	assert.Equal(t, `MISS`, rec.Header().Get(HeaderXCache))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// 保留处理函数设置的 Content-Encoding
+	for _, state := range []string{`MISS`, `HIT`} {
+		rec = myTesting.Request(http.MethodGet, `/gzip`, e)
+		assert.Equal(t, state, rec.Header().Get(HeaderXCache))
+		assert.Equal(t, `gzip`, rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Equal(t, `gzipped`, rec.Body.String())
+	}
+}
+
+func TestCacheCredentials(t *testing.T) {
+	e := echo.New()
+	var calls int32
+	e.Use(Cache(time.Minute))
+	e.Get(`/me`, func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return c.String(`user:` + c.Header(echo.HeaderAuthorization) + c.Header(echo.HeaderCookie))
+	})
+	e.Get(`/public`, func(c echo.Context) error {
+		atomic.AddInt32(&calls, 1)
+		c.Response().Header().Set(echo.HeaderCacheControl, `public, max-age=60`)
+		return c.String(`public`)
+	})
+	e.RebuildRouter()
+
+	withHeader := func(name, value string) func(*http.Request) {
+		return func(r *http.Request) {
+			r.Header.Set(name, value)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		rec := myTesting.Request(http.MethodGet, `/me`, e, withHeader(echo.HeaderAuthorization, `Bearer alice`))
+		assert.Equal(t, `user:Bearer alice`, rec.Body.String())
+		rec = myTesting.Request(http.MethodGet, `/me`, e, withHeader(echo.HeaderAuthorization, `Bearer bob`))
+		assert.Equal(t, `user:Bearer bob`, rec.Body.String())
+		rec = myTesting.Request(http.MethodGet, `/me`, e, withHeader(echo.HeaderCookie, `sid=carol`))
+		assert.Equal(t, `user:sid=carol`, rec.Body.String())
+	}
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+
+	// 未带身份凭证的请求的缓存不会提供给带有身份凭证的请求
+	rec := myTesting.Request(http.MethodGet, `/me`, e)
+	assert.Equal(t, `user:`, rec.Body.String())
+	rec = myTesting.Request(http.MethodGet, `/me`, e, withHeader(echo.HeaderAuthorization, `Bearer alice`))
+	assert.Equal(t, `user:Bearer alice`, rec.Body.String())
+	assert.Equal(t, int32(8), atomic.LoadInt32(&calls))
+
+	// Cache-Control: public 的响应可以共享
+	rec = myTesting.Request(http.MethodGet, `/public`, e, withHeader(echo.HeaderAuthorization, `Bearer alice`))
+	assert.Equal(t, `MISS`, rec.Header().Get(HeaderXCache))
+	rec = myTesting.Request(http.MethodGet, `/public`, e, withHeader(echo.HeaderAuthorization, `Bearer bob`))
+	assert.Equal(t, `HIT`, rec.Header().Get(HeaderXCache))
+	assert.Equal(t, `public`, rec.Body.String())
+	assert.Equal(t, int32(9), atomic.LoadInt32(&calls))
+}
+
+func TestCacheMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewCacheMemoryStore(2)
+	s.Set(ctx, `a`, &CachedResponse{Status: 1}, 0)
+	s.Set(ctx, `b`, &CachedResponse{Status: 2}, 0)
+	v, _ := s.Get(ctx, `a`)
+	assert.Equal(t, 1, v.Status)
+	s.Set(ctx, `c`, &CachedResponse{Status: 3}, 0)
+	assert.Equal(t, 2, s.Len())
+	v, _ = s.Get(ctx, `b`)
+	assert.Nil(t, v)
+	v, _ = s.Get(ctx, `a`)
+	assert.NotNil(t, v)
+
+	s.Set(ctx, `d`, &CachedResponse{Status: 4}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	v, _ = s.Get(ctx, `d`)
+	assert.Nil(t, v)
+	s.Delete(ctx, `a`)
+	assert.Equal(t, 0, s.Len())
+}
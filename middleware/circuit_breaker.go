@@ -0,0 +1,446 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/admpub/events"
+
+	"github.com/webx-top/echo"
+)
+
+type (
+	// CircuitBreakerConfig defines the config for CircuitBreaker middleware.
+	CircuitBreakerConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// KeyFunc 生成熔断器的键，同一个键共用一个熔断器。默认为路由名称(没有名称时为路由路径)
+		KeyFunc func(c echo.Context) string `json:"-"`
+
+		// FailureThreshold 连续失败多少次后熔断。默认为 5
+		FailureThreshold int `json:"failureThreshold"`
+
+		// FailureRatio 失败比例(0~1)，在统计周期内请求数达到 MinRequests 且失败比例达到此值时熔断。0 为不启用
+		FailureRatio float64 `json:"failureRatio"`
+
+		// MinRequests 按失败比例熔断时要求的最少请求数。默认为 10
+		MinRequests int `json:"minRequests"`
+
+		// Interval 关闭状态下的统计周期，周期结束后清零计数。0 为不清零
+		Interval time.Duration `json:"interval"`
+
+		// CoolDown 熔断持续时间，之后进入半开状态。默认为 30 秒
+		CoolDown time.Duration `json:"coolDown"`
+
+		// HalfOpenRequests 半开状态下允许通过的请求数，全部成功后关闭熔断器，任意一个失败则重新熔断。默认为 1
+		HalfOpenRequests int `json:"halfOpenRequests"`
+
+		// SlowThreshold 处理时间超过此值时视为失败。0 为不限制
+		SlowThreshold time.Duration `json:"slowThreshold"`
+
+		// IsFailure 根据处理函数返回的错误和响应状态码判断是否失败。默认为 DefaultCircuitBreakerIsFailure
+		IsFailure func(c echo.Context, err error) bool `json:"-"`
+
+		// StatusCode 熔断时的响应状态码。默认为 503
+		StatusCode int `json:"statusCode"`
+
+		// ErrorMessage 熔断时的错误信息。默认为状态码对应的文本
+		ErrorMessage string `json:"errorMessage"`
+
+		// EventName 状态变化时触发的事件名称。默认为 echo.circuitBreaker.stateChange
+		EventName string `json:"eventName"`
+
+		// Emitter 用于触发状态变化事件。默认为 Context.Emitterer()
+		Emitter events.Emitterer `json:"-"`
+
+		breakers *sync.Map
+	}
+
+	// CircuitBreakerState 熔断器状态
+	CircuitBreakerState int32
+
+	// CircuitBreakerTargeter 带熔断器的代理目标，熔断期间不会转发请求到该目标
+	CircuitBreakerTargeter struct {
+		ProxyTargeter
+		config  *CircuitBreakerConfig
+		breaker *circuitBreaker
+	}
+
+	circuitBreaker struct {
+		key        string
+		config     *CircuitBreakerConfig
+		mu         sync.Mutex
+		state      CircuitBreakerState
+		generation uint64
+		expiry     time.Time
+		requests   int
+		failures   int
+		successes  int
+		sequential int // 连续失败次数
+	}
+
+	circuitBreakerChange struct {
+		key  string
+		from CircuitBreakerState
+		to   CircuitBreakerState
+	}
+)
+
+const (
+	CircuitBreakerClosed CircuitBreakerState = iota
+	CircuitBreakerOpen
+	CircuitBreakerHalfOpen
+)
+
+var (
+	// ErrCircuitBreakerOpen 熔断器处于打开状态
+	ErrCircuitBreakerOpen = errors.New(`circuit breaker is open`)
+	// ErrCircuitBreakerTooManyRequests 熔断器处于半开状态且试探请求数已满
+	ErrCircuitBreakerTooManyRequests = errors.New(`circuit breaker is half-open and has too many requests`)
+
+	// DefaultCircuitBreakerConfig is the default CircuitBreaker middleware config.
+	DefaultCircuitBreakerConfig = CircuitBreakerConfig{
+		Skipper:          echo.DefaultSkipper,
+		KeyFunc:          DefaultCircuitBreakerKeyFunc,
+		FailureThreshold: 5,
+		MinRequests:      10,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+		IsFailure:        DefaultCircuitBreakerIsFailure,
+		StatusCode:       http.StatusServiceUnavailable,
+		EventName:        `echo.circuitBreaker.stateChange`,
+	}
+
+	_ ProxyTargetHandler      = &CircuitBreakerTargeter{}
+	_ ProxyTargetWeighter     = &CircuitBreakerTargeter{}
+	_ ProxyTargetAvailabler   = &CircuitBreakerTargeter{}
+	_ ProxyTargetConnConfiger = &CircuitBreakerTargeter{}
+)
+
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case CircuitBreakerClosed:
+		return `closed`
+	case CircuitBreakerOpen:
+		return `open`
+	case CircuitBreakerHalfOpen:
+		return `half-open`
+	default:
+		return `unknown`
+	}
+}
+
+// DefaultCircuitBreakerKeyFunc 默认使用路由名称作为熔断器的键
+func DefaultCircuitBreakerKeyFunc(c echo.Context) string {
+	if name := c.Route().GetName(); len(name) > 0 {
+		return name
+	}
+	return c.Path()
+}
+
+// DefaultCircuitBreakerIsFailure 默认的失败判断：超时、网络错误或响应状态码为 5xx(客户端取消除外)。
+// 处理函数返回错误且尚未输出响应时，状态码由 echo.ResponseStatus 根据错误获得(例如 echo.Error 为其 Code 对应的 HTTP 状态码)
+func DefaultCircuitBreakerIsFailure(c echo.Context, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, http.ErrHandlerTimeout) {
+			return true
+		}
+		var ne net.Error
+		if errors.As(err, &ne) {
+			return true
+		}
+	}
+	return echo.ResponseStatus(c, err) >= http.StatusInternalServerError
+}
+
+// CircuitBreaker returns a middleware which stops calling the handler for a while
+// after it keeps failing, and responds with "503 - Service Unavailable" instead.
+func CircuitBreaker(failureThreshold int, coolDown time.Duration) echo.MiddlewareFunc {
+	config := DefaultCircuitBreakerConfig
+	config.FailureThreshold = failureThreshold
+	config.CoolDown = coolDown
+	return CircuitBreakerWithConfig(config)
+}
+
+// CircuitBreakerWithConfig returns a CircuitBreaker middleware with config.
+// See: `CircuitBreaker()`.
+//
+// 熔断器有关闭、打开和半开三种状态：关闭时正常处理请求并统计失败次数，达到阈值后打开；
+// 打开时直接返回错误，经过 CoolDown 后进入半开状态；半开时放行 HalfOpenRequests 个请求试探，
+// 全部成功则关闭，否则重新打开。状态变化时会触发 EventName 事件，事件数据包含 key、from 和 to
+func CircuitBreakerWithConfig(config CircuitBreakerConfig) echo.MiddlewareFunc {
+	config.init()
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			return config.execute(c, config.breaker(config.KeyFunc(c)), func() error {
+				return next.Handle(c)
+			})
+		})
+	}
+}
+
+// NewCircuitBreakerTargeter 为代理目标添加熔断器(以目标名称作为熔断器的键，忽略 KeyFunc 和 Skipper)
+func NewCircuitBreakerTargeter(t ProxyTargeter, config ...CircuitBreakerConfig) *CircuitBreakerTargeter {
+	cfg := DefaultCircuitBreakerConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	cfg.init()
+	return &CircuitBreakerTargeter{
+		ProxyTargeter: t,
+		config:        &cfg,
+		breaker:       cfg.breaker(t.GetName()),
+	}
+}
+
+// State 熔断器的当前状态
+func (t *CircuitBreakerTargeter) State() CircuitBreakerState {
+	return t.breaker.currentState(time.Now())
+}
+
+// Available 是否可以转发请求到该目标
+func (t *CircuitBreakerTargeter) Available() bool {
+	return t.State() != CircuitBreakerOpen
+}
+
+// GetWeight 返回被包装的目标的权重
+func (t *CircuitBreakerTargeter) GetWeight() int {
+	return proxyTargetWeight(t.ProxyTargeter)
+}
+
+// GetTLSConfig 返回被包装的目标的 TLS 配置
+func (t *CircuitBreakerTargeter) GetTLSConfig() *tls.Config {
+	return proxyTLSConfig(t.ProxyTargeter)
+}
+
+// GetIdleTimeout 返回被包装的目标的空闲超时时间
+func (t *CircuitBreakerTargeter) GetIdleTimeout() time.Duration {
+	return proxyIdleTimeout(t.ProxyTargeter)
+}
+
+// ProxyHandle implements ProxyTargetHandler.
+func (t *CircuitBreakerTargeter) ProxyHandle(h ProxyHandler, c echo.Context) error {
+	return t.config.execute(c, t.breaker, func() error {
+		return h(t, c)
+	})
+}
+
+func (config *CircuitBreakerConfig) init() {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCircuitBreakerConfig.Skipper
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = DefaultCircuitBreakerConfig.KeyFunc
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = DefaultCircuitBreakerConfig.FailureThreshold
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = DefaultCircuitBreakerConfig.MinRequests
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = DefaultCircuitBreakerConfig.CoolDown
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = DefaultCircuitBreakerConfig.HalfOpenRequests
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = DefaultCircuitBreakerConfig.IsFailure
+	}
+	if config.StatusCode == 0 {
+		config.StatusCode = DefaultCircuitBreakerConfig.StatusCode
+	}
+	if len(config.ErrorMessage) == 0 {
+		config.ErrorMessage = http.StatusText(config.StatusCode)
+	}
+	if len(config.EventName) == 0 {
+		config.EventName = DefaultCircuitBreakerConfig.EventName
+	}
+	config.breakers = &sync.Map{}
+}
+
+func (config *CircuitBreakerConfig) breaker(key string) *circuitBreaker {
+	if v, ok := config.breakers.Load(key); ok {
+		return v.(*circuitBreaker)
+	}
+	v, _ := config.breakers.LoadOrStore(key, &circuitBreaker{key: key, config: config})
+	return v.(*circuitBreaker)
+}
+
+func (config *CircuitBreakerConfig) execute(c echo.Context, b *circuitBreaker, fn func() error) error {
+	generation, retryAfter, err := b.allow(c)
+	if err != nil {
+		if retryAfter > 0 {
+			c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		return echo.NewHTTPError(config.StatusCode, config.ErrorMessage).SetRaw(err)
+	}
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			b.done(c, generation, true)
+			panic(r)
+		}
+	}()
+	err = fn()
+	failed := config.IsFailure(c, err)
+	if !failed && config.SlowThreshold > 0 && time.Since(start) > config.SlowThreshold {
+		failed = true
+	}
+	b.done(c, generation, failed)
+	return err
+}
+
+func (config *CircuitBreakerConfig) emit(c echo.Context, changes []circuitBreakerChange) {
+	if len(changes) == 0 {
+		return
+	}
+	emitter := config.Emitter
+	if emitter == nil {
+		emitter = c.Emitterer()
+	}
+	if emitter == nil {
+		return
+	}
+	for _, change := range changes {
+		err := emitter.FireByNameWithMap(config.EventName, events.Map{
+			`key`:  change.key,
+			`from`: change.from.String(),
+			`to`:   change.to.String(),
+		})
+		if err != nil {
+			c.Logger().Errorf(`circuit breaker: failed to fire event %s: %v`, config.EventName, err)
+		}
+	}
+}
+
+// allow 判断是否允许请求通过。不允许时返回距离进入半开状态的剩余时间和错误
+func (b *circuitBreaker) allow(c echo.Context) (generation uint64, retryAfter time.Duration, err error) {
+	var changes []circuitBreakerChange
+	defer func() {
+		b.config.emit(c, changes)
+	}()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	changes = b.refresh(now, changes)
+	switch b.state {
+	case CircuitBreakerOpen:
+		return b.generation, b.expiry.Sub(now), ErrCircuitBreakerOpen
+	case CircuitBreakerHalfOpen:
+		if b.requests >= b.config.HalfOpenRequests {
+			return b.generation, 0, ErrCircuitBreakerTooManyRequests
+		}
+	}
+	b.requests++
+	return b.generation, 0, nil
+}
+
+// done 记录请求结果。状态已经改变(generation 不同)时忽略
+func (b *circuitBreaker) done(c echo.Context, generation uint64, failed bool) {
+	var changes []circuitBreakerChange
+	defer func() {
+		b.config.emit(c, changes)
+	}()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	changes = b.refresh(now, changes)
+	if generation != b.generation {
+		return
+	}
+	if !failed {
+		b.successes++
+		b.sequential = 0
+		if b.state == CircuitBreakerHalfOpen && b.successes >= b.config.HalfOpenRequests {
+			changes = b.setState(CircuitBreakerClosed, now, changes)
+		}
+		return
+	}
+	b.failures++
+	b.sequential++
+	switch b.state {
+	case CircuitBreakerHalfOpen:
+		changes = b.setState(CircuitBreakerOpen, now, changes)
+	case CircuitBreakerClosed:
+		if b.shouldTrip() {
+			changes = b.setState(CircuitBreakerOpen, now, changes)
+		}
+	}
+}
+
+func (b *circuitBreaker) shouldTrip() bool {
+	if b.sequential >= b.config.FailureThreshold {
+		return true
+	}
+	return b.config.FailureRatio > 0 && b.requests >= b.config.MinRequests &&
+		float64(b.failures)/float64(b.requests) >= b.config.FailureRatio
+}
+
+func (b *circuitBreaker) currentState(now time.Time) CircuitBreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitBreakerOpen && !now.Before(b.expiry) {
+		return CircuitBreakerHalfOpen
+	}
+	return b.state
+}
+
+// refresh 处理到期的状态：关闭状态下清零计数，打开状态下进入半开状态
+func (b *circuitBreaker) refresh(now time.Time, changes []circuitBreakerChange) []circuitBreakerChange {
+	if b.expiry.IsZero() || now.Before(b.expiry) {
+		return changes
+	}
+	switch b.state {
+	case CircuitBreakerClosed:
+		b.reset(now)
+	case CircuitBreakerOpen:
+		changes = b.setState(CircuitBreakerHalfOpen, now, changes)
+	}
+	return changes
+}
+
+func (b *circuitBreaker) setState(state CircuitBreakerState, now time.Time, changes []circuitBreakerChange) []circuitBreakerChange {
+	if b.state == state {
+		return changes
+	}
+	changes = append(changes, circuitBreakerChange{key: b.key, from: b.state, to: state})
+	b.state = state
+	b.reset(now)
+	return changes
+}
+
+func (b *circuitBreaker) reset(now time.Time) {
+	b.generation++
+	b.requests = 0
+	b.failures = 0
+	b.successes = 0
+	b.sequential = 0
+	switch b.state {
+	case CircuitBreakerClosed:
+		if b.config.Interval > 0 {
+			b.expiry = now.Add(b.config.Interval)
+		} else {
+			b.expiry = time.Time{}
+		}
+	case CircuitBreakerOpen:
+		b.expiry = now.Add(b.config.CoolDown)
+	default:
+		b.expiry = time.Time{}
+	}
+}
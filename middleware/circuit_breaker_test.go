@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/admpub/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/code"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	e := echo.New()
+	var (
+		mu      sync.Mutex
+		changes []string
+	)
+	emitter := events.NewEmitter()
+	emitter.On(`echo.circuitBreaker.stateChange`, events.Callback(func(ev events.Event) error {
+		mu.Lock()
+		changes = append(changes, ev.Context.String(`key`)+`:`+ev.Context.String(`from`)+`>`+ev.Context.String(`to`))
+		mu.Unlock()
+		return nil
+	}))
+	config := DefaultCircuitBreakerConfig
+	config.FailureThreshold = 2
+	config.CoolDown = 50 * time.Millisecond
+	config.Emitter = emitter
+	e.Use(CircuitBreakerWithConfig(config))
+	var fail bool
+	var calls int
+	e.Get(`/downstream`, func(c echo.Context) error {
+		calls++
+		if fail {
+			return errors.New(`downstream unavailable`)
+		}
+		return c.String(`ok`)
+	}).SetName(`downstream`)
+	e.Get(`/bad`, func(c echo.Context) error {
+		return echo.ErrBadRequest
+	})
+	e.RebuildRouter()
+
+	// 客户端错误不计入失败
+	for i := 0; i < 3; i++ {
+		rec := myTesting.Request(http.MethodGet, `/bad`, e)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+
+	fail = true
+	for i := 0; i < 2; i++ {
+		rec := myTesting.Request(http.MethodGet, `/downstream`, e)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	}
+	rec := myTesting.Request(http.MethodGet, `/downstream`, e)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, `1`, rec.Header().Get(echo.HeaderRetryAfter))
+	assert.Equal(t, 2, calls)
+
+	// 半开状态试探失败后重新熔断
+	time.Sleep(60 * time.Millisecond)
+	rec = myTesting.Request(http.MethodGet, `/downstream`, e)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	rec = myTesting.Request(http.MethodGet, `/downstream`, e)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, 3, calls)
+
+	// 半开状态试探成功后关闭
+	time.Sleep(60 * time.Millisecond)
+	fail = false
+	for i := 0; i < 2; i++ {
+		rec = myTesting.Request(http.MethodGet, `/downstream`, e)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, 5, calls)
+
+	mu.Lock()
+	assert.Equal(t, []string{
+		`downstream:closed>open`,
+		`downstream:open>half-open`,
+		`downstream:half-open>open`,
+		`downstream:open>half-open`,
+		`downstream:half-open>closed`,
+	}, changes)
+	mu.Unlock()
+}
+
+func TestDefaultCircuitBreakerIsFailure(t *testing.T) {
+	e := echo.New()
+	newContext := func() echo.Context {
+		req, resp := myTesting.NewRequestAndResponse(http.MethodGet, `/`)
+		return e.NewContext(req, resp)
+	}
+	failures := []error{
+		errors.New(`boom`),
+		echo.NewHTTPError(http.StatusServiceUnavailable),
+		echo.NewError(`failure`, code.Failure),
+		context.DeadlineExceeded,
+		http.ErrHandlerTimeout,
+		fmt.Errorf(`dial: %w`, &net.OpError{Op: `dial`, Net: `tcp`, Err: errors.New(`connection refused`)}),
+	}
+	for _, err := range failures {
+		assert.True(t, DefaultCircuitBreakerIsFailure(newContext(), err), err.Error())
+	}
+	others := []error{
+		nil,
+		context.Canceled,
+		echo.ErrBadRequest,
+		echo.NewError(`not found`, code.DataNotFound),
+		echo.NewError(`login required`, code.Unauthenticated),
+	}
+	for _, err := range others {
+		assert.False(t, DefaultCircuitBreakerIsFailure(newContext(), err), fmt.Sprint(err))
+	}
+
+	// 已输出响应时以响应状态码为准
+	c := newContext()
+	c.NoContent(http.StatusBadGateway)
+	assert.True(t, DefaultCircuitBreakerIsFailure(c, nil))
+	c = newContext()
+	c.NoContent(http.StatusOK)
+	assert.False(t, DefaultCircuitBreakerIsFailure(c, errors.New(`logged after response`)))
+}
+
+func TestCircuitBreakerSlowAndRatio(t *testing.T) {
+	e := echo.New()
+	config := DefaultCircuitBreakerConfig
+	config.FailureThreshold = 100
+	config.FailureRatio = 0.5
+	config.MinRequests = 4
+	config.SlowThreshold = 10 * time.Millisecond
+	config.KeyFunc = func(c echo.Context) string {
+		return c.Query(`tenant`)
+	}
+	e.Use(CircuitBreakerWithConfig(config))
+	e.Get(`/`, func(c echo.Context) error {
+		if c.Query(`slow`) == `1` {
+			time.Sleep(15 * time.Millisecond)
+		}
+		return c.String(`ok`)
+	})
+	e.RebuildRouter()
+
+	for _, slow := range []string{`0`, `1`, `0`, `1`} {
+		rec := myTesting.Request(http.MethodGet, `/?tenant=a&slow=`+slow, e)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	rec := myTesting.Request(http.MethodGet, `/?tenant=a`, e)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	rec = myTesting.Request(http.MethodGet, `/?tenant=b`, e)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCircuitBreakerTargeter(t *testing.T) {
+	var hits int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	config := DefaultCircuitBreakerConfig
+	config.FailureThreshold = 1
+	config.CoolDown = time.Minute
+	target := NewCircuitBreakerTargeter(&ProxyTarget{Name: `upstream`, URL: u}, config)
+	assert.Equal(t, CircuitBreakerClosed, target.State())
+
+	e := echo.New()
+	e.Use(Proxy(NewRoundRobinBalancer([]ProxyTargeter{target})))
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Equal(t, CircuitBreakerOpen, target.State())
+	assert.False(t, target.Available())
+
+	rec = myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, 1, hits)
+}
@@ -0,0 +1,501 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
+)
+
+type (
+	// CompressConfig defines the config for Compress middleware.
+	CompressConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// Encodings 支持的编码。客户端 Accept-Encoding 中的 q 值相同时，排在前面的优先。
+		// 默认为 br、zstd、gzip、deflate
+		Encodings []string `json:"encodings"`
+
+		// Levels 各编码的压缩级别。未指定或为 0 时使用该编码的默认级别
+		Levels map[string]int `json:"levels"`
+
+		// MinLength 最小压缩长度(字节)，小于此长度的响应不压缩。默认为 1024，小于 0 时不限制
+		MinLength int `json:"minLength"`
+
+		// ExcludedContentTypes 不压缩的内容类型(前缀匹配，以 +xml 或 +json 结尾的类型除外)。
+		// 默认为 DefaultCompressExcludedContentTypes
+		ExcludedContentTypes []string `json:"excludedContentTypes"`
+
+		pools map[string]*sync.Pool
+	}
+
+	compressEncoder interface {
+		io.WriteCloser
+		Flush() error
+		Reset(io.Writer)
+	}
+
+	compressResponse struct {
+		engine.Response
+		ctx         context.Context
+		config      *CompressConfig
+		encoding    string
+		head        bool
+		status      int
+		wroteHeader bool
+		committed   bool
+		buf         []byte
+		encoder     compressEncoder
+		size        int64
+		keepBody    bool
+		body        []byte
+	}
+)
+
+const (
+	EncodingBrotli  = `br`
+	EncodingZstd    = `zstd`
+	EncodingGzip    = `gzip`
+	EncodingDeflate = `deflate`
+)
+
+var (
+	// DefaultCompressExcludedContentTypes 默认不压缩的内容类型(本身已经是压缩格式)
+	DefaultCompressExcludedContentTypes = []string{
+		`image/`, `video/`, `audio/`, `font/woff`,
+		`application/zip`, `application/gzip`, `application/x-gzip`,
+		`application/x-bzip2`, `application/x-7z-compressed`, `application/x-rar-compressed`,
+		`application/x-xz`, `application/zstd`, `application/wasm`,
+	}
+
+	// DefaultCompressConfig is the default Compress middleware config.
+	DefaultCompressConfig = &CompressConfig{
+		Skipper:              echo.DefaultSkipper,
+		Encodings:            []string{EncodingBrotli, EncodingZstd, EncodingGzip, EncodingDeflate},
+		MinLength:            1024,
+		ExcludedContentTypes: DefaultCompressExcludedContentTypes,
+	}
+
+	compressEncoderFactories = map[string]func(level int) (compressEncoder, error){
+		EncodingBrotli: func(level int) (compressEncoder, error) {
+			if level == 0 {
+				level = brotli.DefaultCompression
+			}
+			return brotli.NewWriterLevel(io.Discard, level), nil
+		},
+		EncodingZstd: func(level int) (compressEncoder, error) {
+			opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+			if level != 0 {
+				opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+			}
+			return zstd.NewWriter(io.Discard, opts...)
+		},
+		EncodingGzip: func(level int) (compressEncoder, error) {
+			if level == 0 {
+				level = gzip.DefaultCompression
+			}
+			return gzip.NewWriterLevel(io.Discard, level)
+		},
+		EncodingDeflate: func(level int) (compressEncoder, error) {
+			if level == 0 {
+				level = flate.DefaultCompression
+			}
+			return flate.NewWriter(io.Discard, level)
+		},
+	}
+)
+
+// Compress returns a middleware which compresses HTTP response using the encoding
+// negotiated from the `Accept-Encoding` request header (br, zstd, gzip or deflate).
+func Compress(config ...*CompressConfig) echo.MiddlewareFunc {
+	if len(config) < 1 || config[0] == nil {
+		c := *DefaultCompressConfig
+		return CompressWithConfig(&c)
+	}
+	return CompressWithConfig(config[0])
+}
+
+// CompressWithConfig return Compress middleware with config.
+// See: `Compress()`.
+//
+// 响应正文会先缓存到 MinLength 字节后再决定是否压缩。Context.Stream(包括 SSE)的响应不压缩，
+// 以保证数据能够及时发送；调用 Flush 时会立即开始压缩输出
+func CompressWithConfig(config *CompressConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCompressConfig.Skipper
+	}
+	if len(config.Encodings) == 0 {
+		config.Encodings = DefaultCompressConfig.Encodings
+	}
+	if config.MinLength == 0 {
+		config.MinLength = DefaultCompressConfig.MinLength
+	}
+	if config.ExcludedContentTypes == nil {
+		config.ExcludedContentTypes = DefaultCompressConfig.ExcludedContentTypes
+	}
+	encodings := make([]string, 0, len(config.Encodings))
+	config.pools = make(map[string]*sync.Pool, len(config.Encodings))
+	for _, encoding := range config.Encodings {
+		encoding = strings.ToLower(encoding)
+		factory, ok := compressEncoderFactories[encoding]
+		if !ok {
+			panic(`compress: unsupported encoding: ` + encoding)
+		}
+		level := config.Levels[encoding]
+		if _, err := factory(level); err != nil {
+			panic(`compress: invalid ` + encoding + ` level: ` + err.Error())
+		}
+		encodings = append(encodings, encoding)
+		config.pools[encoding] = &sync.Pool{
+			New: func() any {
+				w, _ := factory(level)
+				return w
+			},
+		}
+	}
+	config.Encodings = encodings
+	return func(h echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return h.Handle(c)
+			}
+			resp := c.Response()
+			resp.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			encoding := NegotiateEncoding(c.Request().Header().Get(echo.HeaderAcceptEncoding), config.Encodings)
+			if len(encoding) == 0 {
+				return h.Handle(c)
+			}
+			w := &compressResponse{
+				Response: resp,
+				ctx:      c,
+				config:   config,
+				encoding: encoding,
+				head:     c.Request().Method() == echo.HEAD,
+			}
+			xc := c.Object()
+			xc.SetResponse(w)
+			defer func() {
+				if err := w.close(); err != nil {
+					c.Logger().Error(`compress: `, err)
+				}
+				xc.SetResponse(resp)
+			}()
+			err := h.Handle(c)
+			if err != nil && !w.committed {
+				// 尚未发送的输出由错误处理函数重新生成
+				w.discard()
+			}
+			return err
+		})
+	}
+}
+
+// NegotiateEncoding 根据 Accept-Encoding(包括 q 值)从 encodings 中选择编码。
+// q 值相同时按 encodings 的顺序优先，没有可用的编码时返回空字符串
+func NegotiateEncoding(acceptEncoding string, encodings []string) string {
+	if len(acceptEncoding) == 0 {
+		return ``
+	}
+	qvalues := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, `,`) {
+		name, params, _ := strings.Cut(part, `;`)
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, `;`) {
+			k, v, ok := strings.Cut(param, `=`)
+			if !ok || !strings.EqualFold(strings.TrimSpace(k), `q`) {
+				continue
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+		if name == `x-gzip` {
+			name = EncodingGzip
+		}
+		if old, ok := qvalues[name]; !ok || q > old {
+			qvalues[name] = q
+		}
+	}
+	var (
+		best  string
+		bestQ float64
+	)
+	for _, encoding := range encodings {
+		q, ok := qvalues[encoding]
+		if !ok {
+			q, ok = qvalues[`*`]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+func (config *CompressConfig) excluded(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if strings.HasSuffix(mediaType, `+xml`) || strings.HasSuffix(mediaType, `+json`) {
+		return false
+	}
+	for _, prefix := range config.ExcludedContentTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *compressResponse) compressible() bool {
+	if w.head {
+		return false
+	}
+	switch {
+	case w.status >= 100 && w.status < 200, w.status == http.StatusNoContent, w.status == http.StatusNotModified:
+		return false
+	}
+	header := w.Response.Header()
+	if len(header.Get(echo.HeaderContentEncoding)) > 0 {
+		return false
+	}
+	if w.config.excluded(header.Get(echo.HeaderContentType)) {
+		return false
+	}
+	if w.config.MinLength > 0 {
+		if size, err := strconv.Atoi(header.Get(echo.HeaderContentLength)); err == nil && size < w.config.MinLength {
+			return false
+		}
+	}
+	return true
+}
+
+// commit 发送响应头和已缓存的数据。compress 为 false 时不压缩
+func (w *compressResponse) commit(compress bool) error {
+	w.committed = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	header := w.Response.Header()
+	if len(w.buf) > 0 && len(header.Get(echo.HeaderContentType)) == 0 {
+		header.Set(echo.HeaderContentType, http.DetectContentType(w.buf))
+	}
+	if compress && w.compressible() {
+		header.Set(echo.HeaderContentEncoding, w.encoding)
+		header.Del(echo.HeaderContentLength)
+		w.encoder = w.config.pools[w.encoding].Get().(compressEncoder)
+		w.encoder.Reset(w.Response)
+	}
+	w.Response.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	_, err := w.write(buf)
+	return err
+}
+
+func (w *compressResponse) write(b []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.Response.Write(b)
+}
+
+func (w *compressResponse) close() error {
+	if !w.committed {
+		if !w.wroteHeader && len(w.buf) == 0 {
+			return nil
+		}
+		if err := w.commit(w.config.MinLength <= 0 || len(w.buf) >= w.config.MinLength); err != nil {
+			return err
+		}
+	}
+	if w.encoder == nil {
+		return nil
+	}
+	err := w.encoder.Close()
+	w.encoder.Reset(io.Discard)
+	w.config.pools[w.encoding].Put(w.encoder)
+	w.encoder = nil
+	return err
+}
+
+// discard 丢弃尚未发送的响应
+func (w *compressResponse) discard() {
+	w.wroteHeader = false
+	w.status = 0
+	w.buf = nil
+	w.size = 0
+	w.body = nil
+}
+
+func (w *compressResponse) WriteHeader(code int) {
+	if w.committed {
+		w.Response.WriteHeader(code)
+		return
+	}
+	// 尚未发送，丢弃已缓存的响应
+	w.discard()
+	w.wroteHeader = true
+	w.status = code
+	if !w.compressible() {
+		w.commit(false)
+	}
+}
+
+func (w *compressResponse) Write(b []byte) (int, error) {
+	if w.keepBody {
+		w.body = append(w.body, b...)
+	}
+	w.size += int64(len(b))
+	if w.committed {
+		return w.write(b)
+	}
+	if len(w.buf) == 0 && !w.compressible() {
+		if err := w.commit(false); err != nil {
+			return 0, err
+		}
+		return w.write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if w.config.MinLength > 0 && len(w.buf) < w.config.MinLength {
+		return len(b), nil
+	}
+	return len(b), w.commit(true)
+}
+
+func (w *compressResponse) Status() int {
+	if w.status != 0 {
+		return w.status
+	}
+	return w.Response.Status()
+}
+
+func (w *compressResponse) Size() int64 {
+	return w.size
+}
+
+// Committed 缓存中的数据尚未发送时返回 false
+func (w *compressResponse) Committed() bool {
+	return w.committed || w.Response.Committed()
+}
+
+func (w *compressResponse) KeepBody(on bool) {
+	w.keepBody = on
+}
+
+// Body 未压缩的响应正文
+func (w *compressResponse) Body() []byte {
+	return w.body
+}
+
+func (w *compressResponse) Stream(step func(context.Context, io.Writer) (bool, error)) error {
+	if !w.committed {
+		w.committed = true
+		if w.wroteHeader {
+			w.Response.WriteHeader(w.status)
+		}
+		if len(w.buf) > 0 {
+			buf := w.buf
+			w.buf = nil
+			if _, err := w.Response.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	if w.encoder == nil {
+		return w.Response.Stream(step)
+	}
+	// 已经开始压缩输出(调用过 Flush)
+	for {
+		keepOpen, err := step(w.ctx, w)
+		if err != nil {
+			if err == context.Canceled {
+				return nil
+			}
+			return err
+		}
+		w.Flush()
+		if !keepOpen {
+			return nil
+		}
+	}
+}
+
+func (w *compressResponse) Flush() {
+	if !w.committed {
+		w.commit(true)
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	if flusher, ok := w.Response.(http.Flusher); ok {
+		flusher.Flush()
+		return
+	}
+	if flusher, ok := w.Response.StdResponseWriter().(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.Response.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	hijacker, ok := w.Response.StdResponseWriter().(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
+}
+
+// StdResponseWriter 返回的 http.ResponseWriter 同样经过缓存和压缩
+func (w *compressResponse) StdResponseWriter() http.ResponseWriter {
+	return &compressStdResponseWriter{w: w}
+}
+
+type compressStdResponseWriter struct {
+	w *compressResponse
+}
+
+func (s *compressStdResponseWriter) Header() http.Header {
+	return s.w.Header().Std()
+}
+
+func (s *compressStdResponseWriter) Write(b []byte) (int, error) {
+	return s.w.Write(b)
+}
+
+func (s *compressStdResponseWriter) WriteHeader(code int) {
+	s.w.WriteHeader(code)
+}
+
+func (s *compressStdResponseWriter) Flush() {
+	s.w.Flush()
+}
+
+func (s *compressStdResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return s.w.Hijack()
+}
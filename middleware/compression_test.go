@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	encodings := DefaultCompressConfig.Encodings
+	assert.Equal(t, ``, NegotiateEncoding(``, encodings))
+	assert.Equal(t, `br`, NegotiateEncoding(`gzip, deflate, br, zstd`, encodings))
+	assert.Equal(t, `gzip`, NegotiateEncoding(`gzip;q=1.0, br;q=0.5`, encodings))
+	assert.Equal(t, `zstd`, NegotiateEncoding(`br;q=0, *`, encodings))
+	assert.Equal(t, `gzip`, NegotiateEncoding(`x-gzip`, encodings))
+	assert.Equal(t, ``, NegotiateEncoding(`identity, br;q=0`, encodings))
+	assert.Equal(t, `deflate`, NegotiateEncoding(`deflate;q=0.8, gzip;q=0.2`, encodings))
+}
+
+func decompress(t *testing.T, encoding string, b []byte) string {
+	var r io.Reader
+	switch encoding {
+	case `br`:
+		r = brotli.NewReader(bytes.NewReader(b))
+	case `zstd`:
+		d, err := zstd.NewReader(bytes.NewReader(b))
+		if !assert.NoError(t, err) {
+			return ``
+		}
+		defer d.Close()
+		r = d
+	case `gzip`:
+		g, err := gzip.NewReader(bytes.NewReader(b))
+		if !assert.NoError(t, err) {
+			return ``
+		}
+		r = g
+	default:
+		return string(b)
+	}
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestCompress(t *testing.T) {
+	e := echo.New()
+	e.Use(Compress())
+	large := strings.Repeat(`{"name":"catalog","price":100}`, 200)
+	e.Get(`/large`, func(c echo.Context) error {
+		return c.JSONBlob([]byte(large))
+	})
+	e.Get(`/small`, func(c echo.Context) error {
+		return c.String(`small`)
+	})
+	e.Get(`/image`, func(c echo.Context) error {
+		return c.Blob([]byte(large), http.StatusOK)
+	}, func(h echo.Handler) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderContentType, `image/png`)
+			return h.Handle(c)
+		}
+	})
+	e.Get(`/chunks`, func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlain)
+		for i := 0; i < 300; i++ {
+			c.Response().Write([]byte(`chunk `))
+		}
+		return nil
+	})
+	e.Get(`/stream`, func(c echo.Context) error {
+		n := 0
+		return c.Stream(func(_ context.Context, w io.Writer) (bool, error) {
+			n++
+			_, err := w.Write([]byte(`data `))
+			return n < 3, err
+		})
+	})
+	e.RebuildRouter()
+
+	for _, encoding := range []string{`br`, `zstd`, `gzip`} {
+		rec := myTesting.Request(http.MethodGet, `/large`, e, func(r *http.Request) {
+			r.Header.Set(echo.HeaderAcceptEncoding, encoding)
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, encoding, rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Equal(t, echo.HeaderAcceptEncoding, rec.Header().Get(echo.HeaderVary))
+		assert.Less(t, rec.Body.Len(), len(large))
+		assert.Equal(t, large, decompress(t, encoding, rec.Body.Bytes()))
+	}
+
+	rec := myTesting.Request(http.MethodGet, `/large`, e)
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, large, rec.Body.String())
+
+	rec = myTesting.Request(http.MethodGet, `/small`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderAcceptEncoding, `br`)
+	})
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, `small`, rec.Body.String())
+
+	rec = myTesting.Request(http.MethodGet, `/image`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderAcceptEncoding, `br`)
+	})
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, large, rec.Body.String())
+
+	rec = myTesting.Request(http.MethodGet, `/chunks`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderAcceptEncoding, `gzip`)
+	})
+	assert.Equal(t, `gzip`, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, strings.Repeat(`chunk `, 300), decompress(t, `gzip`, rec.Body.Bytes()))
+
+	rec = myTesting.Request(http.MethodGet, `/stream`, e, func(r *http.Request) {
+		r.Header.Set(echo.HeaderAcceptEncoding, `br`)
+	})
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, `data data data `, rec.Body.String())
+	assert.True(t, rec.Flushed)
+}
+
+func TestCompressWithCache(t *testing.T) {
+	e := echo.New()
+	e.Use(Compress(), Cache(time.Minute))
+	large := strings.Repeat(`cached `, 500)
+	var calls int
+	e.Get(`/`, func(c echo.Context) error {
+		calls++
+		return c.String(large)
+	})
+	e.RebuildRouter()
+	for _, encoding := range []string{`br`, `gzip`, ``} {
+		rec := myTesting.Request(http.MethodGet, `/`, e, func(r *http.Request) {
+			r.Header.Set(echo.HeaderAcceptEncoding, encoding)
+		})
+		assert.Equal(t, encoding, rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Equal(t, large, decompress(t, encoding, rec.Body.Bytes()))
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestCompressHijackNotSupported(t *testing.T) {
+	_, resp := myTesting.NewRequestAndResponse(http.MethodGet, `/`)
+	// 仅保留 engine.Response 接口的方法，不支持 http.Hijacker
+	w := &compressResponse{Response: struct{ engine.Response }{resp}}
+	conn, rw, err := w.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+	assert.Nil(t, conn)
+	assert.Nil(t, rw)
+}
+
+func TestCompressBuffered(t *testing.T) {
+	e := echo.New()
+	e.Use(Compress())
+	large := strings.Repeat(`std `, 500)
+	e.Get(`/error`, func(c echo.Context) error {
+		c.String(`partial`)
+		assert.False(t, c.Response().Committed())
+		return echo.ErrForbidden
+	})
+	e.Get(`/flush`, func(c echo.Context) error {
+		c.String(`flushed`)
+		c.Response().(http.Flusher).Flush()
+		assert.True(t, c.Response().Committed())
+		return nil
+	})
+	e.Get(`/std`, func(c echo.Context) error {
+		w := c.Response().StdResponseWriter()
+		w.Header().Set(echo.HeaderContentType, echo.MIMETextPlain)
+		_, err := io.WriteString(w, large)
+		return err
+	})
+	e.RebuildRouter()
+
+	setEncoding := func(r *http.Request) {
+		r.Header.Set(echo.HeaderAcceptEncoding, `gzip`)
+	}
+	rec := myTesting.Request(http.MethodGet, `/error`, e, setEncoding)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, http.StatusText(http.StatusForbidden), rec.Body.String())
+
+	rec = myTesting.Request(http.MethodGet, `/flush`, e, setEncoding)
+	assert.Equal(t, `flushed`, decompress(t, rec.Header().Get(echo.HeaderContentEncoding), rec.Body.Bytes()))
+
+	rec = myTesting.Request(http.MethodGet, `/std`, e, setEncoding)
+	assert.Equal(t, `gzip`, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, large, decompress(t, `gzip`, rec.Body.Bytes()))
+}
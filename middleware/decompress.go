@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware/bytes"
+)
+
+type (
+	// DecompressConfig defines the config for Decompress middleware.
+	DecompressConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// Limit 解压后请求正文的最大长度，格式与 BodyLimitConfig.Limit 相同(例如：4M)。
+		// 默认为 Request.MaxSize()(即 Echo 的 MaxRequestBodySize)
+		Limit string `json:"limit"`
+		limit int64
+	}
+
+	decompressReader struct {
+		io.Reader
+		closers []io.Closer
+		limit   int64
+		read    int64
+	}
+)
+
+var (
+	// DefaultDecompressConfig is the default Decompress middleware config.
+	DefaultDecompressConfig = DecompressConfig{
+		Skipper: echo.DefaultSkipper,
+	}
+
+	requestDecoders = map[string]func(r io.Reader, limit int64) (io.Reader, io.Closer, error){
+		EncodingGzip: func(r io.Reader, _ int64) (io.Reader, io.Closer, error) {
+			zr, err := gzip.NewReader(r)
+			return zr, zr, err
+		},
+		`x-gzip`: func(r io.Reader, _ int64) (io.Reader, io.Closer, error) {
+			zr, err := gzip.NewReader(r)
+			return zr, zr, err
+		},
+		EncodingDeflate: func(r io.Reader, _ int64) (io.Reader, io.Closer, error) {
+			zr, err := zlib.NewReader(r)
+			return zr, zr, err
+		},
+		EncodingBrotli: func(r io.Reader, _ int64) (io.Reader, io.Closer, error) {
+			return brotli.NewReader(r), nil, nil
+		},
+		EncodingZstd: func(r io.Reader, limit int64) (io.Reader, io.Closer, error) {
+			opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+			if limit > 0 {
+				opts = append(opts, zstd.WithDecoderMaxMemory(uint64(limit)))
+			}
+			zr, err := zstd.NewReader(r, opts...)
+			if err != nil {
+				return nil, nil, err
+			}
+			return zr, zr.IOReadCloser(), nil
+		},
+	}
+)
+
+// Decompress returns a middleware which decompresses request body
+// according to the `Content-Encoding` request header (gzip, deflate, br or zstd).
+func Decompress(limit ...string) echo.MiddlewareFunc {
+	config := DefaultDecompressConfig
+	if len(limit) > 0 {
+		config.Limit = limit[0]
+	}
+	return DecompressWithConfig(config)
+}
+
+// DecompressWithConfig returns a Decompress middleware with config.
+// See: `Decompress()`.
+//
+// 解压后的长度超过限制时返回 413，不支持的编码返回 415。
+// 在 Decompress 之前注册的 BodyLimit 限制的是压缩后的长度，之后注册的限制的是解压后的长度
+func DecompressWithConfig(config DecompressConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultDecompressConfig.Skipper
+	}
+	if len(config.Limit) > 0 {
+		limit, err := bytes.Parse(config.Limit)
+		if err != nil {
+			panic(fmt.Errorf("invalid decompress limit=%s", config.Limit))
+		}
+		config.limit = limit
+	}
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			req := c.Request()
+			contentEncoding := req.Header().Get(echo.HeaderContentEncoding)
+			if len(contentEncoding) == 0 {
+				return next.Handle(c)
+			}
+			var encodings []string
+			for _, encoding := range strings.Split(contentEncoding, `,`) {
+				encoding = strings.ToLower(strings.TrimSpace(encoding))
+				if len(encoding) == 0 || encoding == `identity` {
+					continue
+				}
+				if _, ok := requestDecoders[encoding]; !ok {
+					return echo.NewHTTPError(http.StatusUnsupportedMediaType, `Unsupported Content-Encoding: `+encoding)
+				}
+				encodings = append(encodings, encoding)
+			}
+			body := req.Body()
+			if len(encodings) == 0 || body == nil || body == http.NoBody || req.Size() == 0 {
+				return next.Handle(c)
+			}
+			limit := config.limit
+			if limit <= 0 {
+				limit = int64(req.MaxSize())
+			}
+			r := &decompressReader{Reader: body, closers: []io.Closer{body}, limit: limit}
+			// 按照与编码相反的顺序解码
+			for i := len(encodings) - 1; i >= 0; i-- {
+				reader, closer, err := requestDecoders[encodings[i]](r.Reader, limit)
+				if err != nil {
+					r.Close()
+					return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+				}
+				r.Reader = reader
+				if closer != nil {
+					r.closers = append(r.closers, closer)
+				}
+			}
+			req.SetBody(r)
+			req.Header().Del(echo.HeaderContentEncoding)
+			req.Header().Del(echo.HeaderContentLength)
+			return next.Handle(c)
+		})
+	}
+}
+
+func (r *decompressReader) Read(b []byte) (n int, err error) {
+	n, err = r.Reader.Read(b)
+	r.read += int64(n)
+	if r.limit > 0 && r.read > r.limit {
+		n -= int(r.read - r.limit)
+		r.read = r.limit
+		return n, echo.ErrStatusRequestEntityTooLarge
+	}
+	return
+}
+
+func (r *decompressReader) Close() error {
+	var err error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if cerr := r.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	r.closers = nil
+	return err
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func compressBody(t *testing.T, encoding string, data []byte) []byte {
+	buf := new(bytes.Buffer)
+	var w io.WriteCloser
+	switch encoding {
+	case `gzip`:
+		w = gzip.NewWriter(buf)
+	case `br`:
+		w = brotli.NewWriter(buf)
+	case `zstd`:
+		zw, err := zstd.NewWriter(buf)
+		assert.NoError(t, err)
+		w = zw
+	}
+	_, err := w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	e := echo.New()
+	e.Use(Decompress(`1K`))
+	e.Post(`/`, func(c echo.Context) error {
+		m := map[string]string{}
+		if err := c.MustBind(&m); err != nil {
+			return err
+		}
+		return c.String(m[`name`] + `:` + c.Header(echo.HeaderContentEncoding))
+	})
+	e.RebuildRouter()
+
+	request := func(encoding string, body []byte) (int, string) {
+		rec := myTesting.Request(http.MethodPost, `/`, e, func(r *http.Request) {
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			r.Header.Set(echo.HeaderContentEncoding, encoding)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		})
+		return rec.Code, rec.Body.String()
+	}
+
+	data := []byte(`{"name":"webx"}`)
+	for _, encoding := range []string{`gzip`, `br`, `zstd`} {
+		code, body := request(encoding, compressBody(t, encoding, data))
+		assert.Equal(t, http.StatusOK, code, encoding)
+		assert.Equal(t, `webx:`, body, encoding)
+	}
+
+	// 多重编码
+	code, body := request(`br, gzip`, compressBody(t, `gzip`, compressBody(t, `br`, data)))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `webx:`, body)
+
+	code, _ = request(`compress`, data)
+	assert.Equal(t, http.StatusUnsupportedMediaType, code)
+
+	code, _ = request(`gzip`, data)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 解压后超过限制
+	bomb := []byte(`{"name":"` + strings.Repeat(`a`, 4096) + `"}`)
+	code, _ = request(`gzip`, compressBody(t, `gzip`, bomb))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+}
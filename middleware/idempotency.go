@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/webx-top/echo"
+)
+
+type (
+	// IdempotencyConfig defines the config for Idempotency middleware.
+	IdempotencyConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// Store 幂等记录存储。默认为容量 10000 的内存存储
+		Store IdempotencyStore `json:"-"`
+
+		// TTL 已完成请求的响应保存时长。默认 24 小时
+		TTL time.Duration `json:"ttl"`
+
+		// LockTTL 处理中记录的有效期，超过后视为处理失败(例如进程崩溃)，允许使用相同的键重试。默认 1 分钟
+		LockTTL time.Duration `json:"lockTTL"`
+
+		// Methods 需要进行幂等处理的请求方法。默认为 POST 和 PATCH
+		Methods []string `json:"methods"`
+
+		// Required 对于需要处理的请求方法，是否必须提供 Idempotency-Key 请求头(否则返回 400)
+		Required bool `json:"required"`
+
+		// MaxKeyLength Idempotency-Key 的最大长度。默认 255
+		MaxKeyLength int `json:"maxKeyLength"`
+
+		// MaxBodySize 计算请求指纹时读取的最大请求正文字节数，超出时返回 413。默认 1MB
+		MaxBodySize int64 `json:"maxBodySize"`
+
+		// KeyGenerator 根据 Idempotency-Key 生成存储键，用于按用户等进行隔离。默认为 DefaultIdempotencyKeyGenerator
+		KeyGenerator func(c echo.Context, key string) string `json:"-"`
+
+		// Fingerprint 生成请求指纹。默认为请求方法、路径、网址查询参数和请求正文的 SHA-256
+		Fingerprint func(c echo.Context, body []byte) string `json:"-"`
+
+		methods map[string]struct{}
+	}
+
+	// IdempotencyStore 幂等记录存储
+	IdempotencyStore interface {
+		// Lock 键不存在(或已过期)时保存 record 并返回 nil, true；否则返回已有的记录和 false
+		Lock(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error)
+		// Set 保存处理完成的记录
+		Set(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error
+		// Delete 删除记录(处理失败时调用，以允许客户端重试)
+		Delete(ctx context.Context, key string) error
+	}
+
+	// IdempotencyRecord 幂等记录
+	IdempotencyRecord struct {
+		Fingerprint string      `json:"fingerprint"`
+		Completed   bool        `json:"completed"`
+		Status      int         `json:"status"`
+		Header      http.Header `json:"header"`
+		Body        []byte      `json:"body"`
+		CreatedAt   time.Time   `json:"createdAt"`
+	}
+)
+
+const (
+	// HeaderIdempotencyKey 幂等键请求头
+	HeaderIdempotencyKey = `Idempotency-Key`
+	// HeaderIdempotentReplayed 重放已保存的响应时添加的响应头
+	HeaderIdempotentReplayed = `Idempotent-Replayed`
+)
+
+var (
+	ErrIdempotencyKeyMissing   = errors.New("missing idempotency key")
+	ErrIdempotencyKeyTooLong   = errors.New("idempotency key is too long")
+	ErrIdempotencyInProgress   = errors.New("a request with the same idempotency key is in progress")
+	ErrIdempotencyKeyReused    = errors.New("idempotency key has been used with a different request")
+	ErrIdempotencyBodyTooLarge = errors.New("request body is too large")
+	ErrIdempotencyStoreFailure = errors.New("idempotency store failure")
+
+	// DefaultIdempotencyConfig is the default Idempotency middleware config.
+	DefaultIdempotencyConfig = IdempotencyConfig{
+		Skipper:      echo.DefaultSkipper,
+		TTL:          24 * time.Hour,
+		LockTTL:      time.Minute,
+		Methods:      []string{echo.POST, echo.PATCH},
+		MaxKeyLength: 255,
+		MaxBodySize:  1 << 20,
+		KeyGenerator: DefaultIdempotencyKeyGenerator,
+		Fingerprint:  DefaultIdempotencyFingerprint,
+	}
+)
+
+// DefaultIdempotencyKeyGenerator 默认的存储键生成函数。
+// 按客户端身份(Authorization 请求头，没有时依次使用 Cookie 请求头和客户端 IP)的 SHA-256 隔离，
+// 避免不同的客户端使用相同的 Idempotency-Key 时读取到对方的响应
+func DefaultIdempotencyKeyGenerator(c echo.Context, key string) string {
+	header := c.Request().Header()
+	var identity string
+	if v := header.Get(echo.HeaderAuthorization); len(v) > 0 {
+		identity = `authorization:` + v
+	} else if v = header.Get(echo.HeaderCookie); len(v) > 0 {
+		identity = `cookie:` + v
+	} else {
+		identity = `ip:` + c.RealIP()
+	}
+	sum := sha256.Sum256([]byte(identity))
+	return hex.EncodeToString(sum[:16]) + `:` + key
+}
+
+// DefaultIdempotencyFingerprint 默认的请求指纹生成函数
+func DefaultIdempotencyFingerprint(c echo.Context, body []byte) string {
+	req := c.Request()
+	h := sha256.New()
+	h.Write([]byte(req.Method() + "\n" + req.URL().Path() + "\n" + req.URL().Query().Encode() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Idempotency returns a middleware which makes POST and PATCH requests carrying
+// an `Idempotency-Key` header safe to retry.
+func Idempotency() echo.MiddlewareFunc {
+	return IdempotencyWithConfig(DefaultIdempotencyConfig)
+}
+
+// IdempotencyWithConfig returns an Idempotency middleware with config.
+// See: `Idempotency()`.
+//
+// 第一个请求执行处理函数并保存响应的状态码、响应头和正文，此后相同键和相同请求指纹的请求直接重放保存的响应
+// (带有 Idempotent-Replayed: true 响应头)；第一个请求尚在处理中时返回 409，相同的键用于不同的请求时返回 422。
+// 处理函数返回错误或响应状态码为 5xx 时不保存响应，客户端可以使用相同的键重试。
+// 响应正文通过 engine.Response 的 KeepBody 获取，所以压缩中间件需要在 Idempotency 之前注册(位于外层)
+func IdempotencyWithConfig(config IdempotencyConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultIdempotencyConfig.Skipper
+	}
+	if config.Store == nil {
+		config.Store = NewIdempotencyMemoryStore(10000)
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultIdempotencyConfig.TTL
+	}
+	if config.LockTTL <= 0 {
+		config.LockTTL = DefaultIdempotencyConfig.LockTTL
+	}
+	if len(config.Methods) == 0 {
+		config.Methods = DefaultIdempotencyConfig.Methods
+	}
+	if config.MaxKeyLength <= 0 {
+		config.MaxKeyLength = DefaultIdempotencyConfig.MaxKeyLength
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultIdempotencyConfig.MaxBodySize
+	}
+	if config.KeyGenerator == nil {
+		config.KeyGenerator = DefaultIdempotencyKeyGenerator
+	}
+	if config.Fingerprint == nil {
+		config.Fingerprint = DefaultIdempotencyConfig.Fingerprint
+	}
+	config.methods = make(map[string]struct{}, len(config.Methods))
+	for _, method := range config.Methods {
+		config.methods[strings.ToUpper(method)] = struct{}{}
+	}
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			req := c.Request()
+			if _, ok := config.methods[req.Method()]; !ok {
+				return next.Handle(c)
+			}
+			key := req.Header().Get(HeaderIdempotencyKey)
+			if len(key) == 0 {
+				if config.Required {
+					return echo.NewHTTPError(http.StatusBadRequest, ErrIdempotencyKeyMissing.Error()).SetRaw(ErrIdempotencyKeyMissing)
+				}
+				return next.Handle(c)
+			}
+			if len(key) > config.MaxKeyLength {
+				return echo.NewHTTPError(http.StatusBadRequest, ErrIdempotencyKeyTooLong.Error()).SetRaw(ErrIdempotencyKeyTooLong)
+			}
+			body, err := io.ReadAll(io.LimitReader(req.Body(), config.MaxBodySize+1))
+			if err != nil {
+				return err
+			}
+			if int64(len(body)) > config.MaxBodySize {
+				return echo.NewHTTPError(http.StatusRequestEntityTooLarge, ErrIdempotencyBodyTooLarge.Error()).SetRaw(ErrIdempotencyBodyTooLarge)
+			}
+			req.SetBody(bytes.NewReader(body))
+			key = config.KeyGenerator(c, key)
+			record := &IdempotencyRecord{
+				Fingerprint: config.Fingerprint(c, body),
+				CreatedAt:   time.Now(),
+			}
+			existing, acquired, err := config.Store.Lock(c, key, record, config.LockTTL)
+			if err != nil {
+				c.Logger().Warnf(`idempotency: failed to lock %q: %v`, key, err)
+				return echo.NewHTTPError(http.StatusServiceUnavailable, ErrIdempotencyStoreFailure.Error()).SetRaw(err)
+			}
+			if !acquired {
+				switch {
+				case existing.Fingerprint != record.Fingerprint:
+					return echo.NewHTTPError(http.StatusUnprocessableEntity, ErrIdempotencyKeyReused.Error()).SetRaw(ErrIdempotencyKeyReused)
+				case !existing.Completed:
+					return echo.NewHTTPError(http.StatusConflict, ErrIdempotencyInProgress.Error()).SetRaw(ErrIdempotencyInProgress)
+				}
+				return config.replay(c, existing)
+			}
+			return config.capture(c, next, key, record)
+		})
+	}
+}
+
+// capture 执行处理函数并保存响应。无法保存时删除处理中的记录
+func (config *IdempotencyConfig) capture(c echo.Context, next echo.Handler, key string, record *IdempotencyRecord) (err error) {
+	resp := c.Response()
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		// 使用独立的 context，避免请求已取消时无法删除
+		if delErr := config.Store.Delete(context.Background(), key); delErr != nil {
+			c.Logger().Warnf(`idempotency: failed to delete %q: %v`, key, delErr)
+		}
+	}()
+	resp.KeepBody(true)
+	err = next.Handle(c)
+	resp.KeepBody(false)
+	if err != nil || (!resp.Committed() && resp.Size() == 0) || resp.Status() >= http.StatusInternalServerError {
+		return
+	}
+	body := resp.Body()
+	if int64(len(body)) != resp.Size() { // 未能获取完整的响应正文(例如：ServeFile)
+		return
+	}
+	header := resp.Header().Std().Clone()
+	header.Del(echo.HeaderContentLength)
+	header.Del(echo.HeaderContentEncoding) // KeepBody 获取的是压缩前的内容
+	header.Del(echo.HeaderSetCookie)
+	// 处理中的记录可能正在被其它请求读取，所以保存新的记录
+	done := &IdempotencyRecord{
+		Fingerprint: record.Fingerprint,
+		Completed:   true,
+		Status:      resp.Status(),
+		Header:      header,
+		Body:        bytes.Clone(body),
+		CreatedAt:   record.CreatedAt,
+	}
+	if setErr := config.Store.Set(context.Background(), key, done, config.TTL); setErr != nil {
+		c.Logger().Warnf(`idempotency: failed to set %q: %v`, key, setErr)
+		return
+	}
+	completed = true
+	return
+}
+
+func (config *IdempotencyConfig) replay(c echo.Context, record *IdempotencyRecord) error {
+	resp := c.Response()
+	header := resp.Header()
+	for k, v := range record.Header {
+		header.Del(k)
+		for _, vv := range v {
+			header.Add(k, vv)
+		}
+	}
+	header.Set(HeaderIdempotentReplayed, `true`)
+	resp.WriteHeader(record.Status)
+	_, err := resp.Write(record.Body)
+	return err
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// NewIdempotencyMemoryStore 创建内存幂等记录存储。capacity 为最多保存的条目数(小于等于 0 时为 10000)，
+// 超出时淘汰最早写入的记录。仅适用于单实例部署，多实例部署需要使用共享的存储(如 Redis)
+func NewIdempotencyMemoryStore(capacity int) *IdempotencyMemoryStore {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &IdempotencyMemoryStore{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		fifo:     list.New(),
+	}
+}
+
+// IdempotencyMemoryStore 内存幂等记录存储
+type IdempotencyMemoryStore struct {
+	capacity int
+	items    map[string]*list.Element
+	fifo     *list.List
+	mu       sync.Mutex
+}
+
+type idempotencyMemoryItem struct {
+	key      string
+	value    *IdempotencyRecord
+	expireAt time.Time
+}
+
+func (s *IdempotencyMemoryStore) Lock(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		item := elem.Value.(*idempotencyMemoryItem)
+		if item.expireAt.IsZero() || now.Before(item.expireAt) {
+			return item.value, false, nil
+		}
+		s.remove(elem)
+	}
+	s.set(key, record, now, ttl)
+	return nil, true, nil
+}
+
+func (s *IdempotencyMemoryStore) Set(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+	s.set(key, record, time.Now(), ttl)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *IdempotencyMemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *IdempotencyMemoryStore) set(key string, record *IdempotencyRecord, now time.Time, ttl time.Duration) {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = now.Add(ttl)
+	}
+	s.items[key] = s.fifo.PushBack(&idempotencyMemoryItem{key: key, value: record, expireAt: expireAt})
+	for s.fifo.Len() > s.capacity {
+		s.remove(s.fifo.Front())
+	}
+}
+
+func (s *IdempotencyMemoryStore) remove(elem *list.Element) {
+	s.fifo.Remove(elem)
+	delete(s.items, elem.Value.(*idempotencyMemoryItem).key)
+}
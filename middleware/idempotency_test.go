@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func idempotencyRequest(key string, body string) func(*http.Request) {
+	return func(r *http.Request) {
+		if len(key) > 0 {
+			r.Header.Set(HeaderIdempotencyKey, key)
+		}
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		r.Body = io.NopCloser(strings.NewReader(body))
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	e := echo.New()
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e.Use(Idempotency())
+	e.Post(`/payments`, func(c echo.Context) error {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(c.Request().Body())
+		if c.Query(`slow`) == `1` {
+			close(started)
+			<-release
+		}
+		if c.Query(`fail`) == `1` {
+			return echo.NewHTTPError(http.StatusBadGateway)
+		}
+		c.Response().Header().Set(`X-Calls`, strconv.Itoa(int(n)))
+		return c.JSONBlob(body, http.StatusCreated)
+	})
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodPost, `/payments`, e, idempotencyRequest(`k1`, `{"amount":1}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"amount":1}`, rec.Body.String())
+	assert.Empty(t, rec.Header().Get(HeaderIdempotentReplayed))
+
+	// 重放
+	rec = myTesting.Request(http.MethodPost, `/payments`, e, idempotencyRequest(`k1`, `{"amount":1}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"amount":1}`, rec.Body.String())
+	assert.Equal(t, `1`, rec.Header().Get(`X-Calls`))
+	assert.Equal(t, `true`, rec.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 相同的键用于不同的请求
+	rec = myTesting.Request(http.MethodPost, `/payments`, e, idempotencyRequest(`k1`, `{"amount":2}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 没有键时不处理
+	myTesting.Request(http.MethodPost, `/payments`, e, idempotencyRequest(``, `{"amount":1}`))
+	myTesting.Request(http.MethodPost, `/payments`, e, idempotencyRequest(``, `{"amount":1}`))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// 处理失败时不保存，可以重试
+	rec = myTesting.Request(http.MethodPost, `/payments?fail=1`, e, idempotencyRequest(`k2`, `{}`))
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	rec = myTesting.Request(http.MethodPost, `/payments?fail=1`, e, idempotencyRequest(`k2`, `{}`))
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+
+	// 处理中的重复请求
+	done := make(chan int)
+	go func() {
+		rec := myTesting.Request(http.MethodPost, `/payments?slow=1`, e, idempotencyRequest(`k3`, `{}`))
+		done <- rec.Code
+	}()
+	<-started
+	rec = myTesting.Request(http.MethodPost, `/payments?slow=1`, e, idempotencyRequest(`k3`, `{}`))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	close(release)
+	assert.Equal(t, http.StatusCreated, <-done)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyClients(t *testing.T) {
+	e := echo.New()
+	var calls int32
+	e.Use(Idempotency())
+	e.Post(`/orders`, func(c echo.Context) error {
+		n := atomic.AddInt32(&calls, 1)
+		return c.String(c.Request().Header().Get(echo.HeaderAuthorization) + `#` + strconv.Itoa(int(n)))
+	})
+	e.RebuildRouter()
+
+	as := func(auth string) func(*http.Request) {
+		return func(r *http.Request) {
+			idempotencyRequest(`same-key`, `{}`)(r)
+			r.Header.Set(echo.HeaderAuthorization, auth)
+		}
+	}
+	rec := myTesting.Request(http.MethodPost, `/orders`, e, as(`Bearer alice`))
+	assert.Equal(t, `Bearer alice#1`, rec.Body.String())
+	// 不同的客户端使用相同的键互不影响
+	rec = myTesting.Request(http.MethodPost, `/orders`, e, as(`Bearer bob`))
+	assert.Equal(t, `Bearer bob#2`, rec.Body.String())
+	assert.Empty(t, rec.Header().Get(HeaderIdempotentReplayed))
+
+	rec = myTesting.Request(http.MethodPost, `/orders`, e, as(`Bearer alice`))
+	assert.Equal(t, `Bearer alice#1`, rec.Body.String())
+	assert.Equal(t, `true`, rec.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyRequired(t *testing.T) {
+	e := echo.New()
+	e.Use(IdempotencyWithConfig(IdempotencyConfig{Required: true, MaxKeyLength: 4}))
+	e.Post(`/`, func(c echo.Context) error {
+		return c.String(`ok`)
+	})
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodPost, `/`, e, idempotencyRequest(``, `{}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = myTesting.Request(http.MethodPost, `/`, e, idempotencyRequest(`12345`, `{}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = myTesting.Request(http.MethodPost, `/`, e, idempotencyRequest(`1234`, `{}`))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -214,6 +214,9 @@ func JWTWithConfig(config JWTConfig) echo.MiddlewareFuncd {
 			if err == nil && token.Valid {
 				// Store user information from token into context.
 				c.Internal().Set(config.ContextKey, token)
+				if sub, _ := token.Claims.GetSubject(); len(sub) > 0 {
+					c.Object().WithLogFields(`user`, sub)
+				}
 				return next.Handle(c)
 			}
 			if config.errorHandler != nil {
@@ -3,10 +3,13 @@ package middleware
 import (
 	"io"
 	std "log"
+	"math/rand/v2"
+	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/webx-top/echo"
 )
 
@@ -18,11 +21,18 @@ type VisitorInfo struct {
 	Host         string
 	URI          string
 	Method       string
+	Protocol     string
 	UserAgent    string
 	Referer      string
 	RequestSize  int64
 	ResponseSize int64
 	ResponseCode int
+
+	RouteName string // 路由名称
+	RoutePath string // 路由规则(如 /user/:id)
+	RequestID string
+	User      string // JWT 中的 subject
+	Upstream  string // 反向代理的目标
 }
 
 var emptyTime = time.Time{}
@@ -35,11 +45,17 @@ func (v *VisitorInfo) reset() {
 	v.Host = ``
 	v.URI = ``
 	v.Method = ``
+	v.Protocol = ``
 	v.UserAgent = ``
 	v.Referer = ``
 	v.RequestSize = 0
 	v.ResponseSize = 0
 	v.ResponseCode = 0
+	v.RouteName = ``
+	v.RoutePath = ``
+	v.RequestID = ``
+	v.User = ``
+	v.Upstream = ``
 }
 
 func (v *VisitorInfo) SetFromContext(c echo.Context) {
@@ -51,11 +67,38 @@ func (v *VisitorInfo) SetFromContext(c echo.Context) {
 	v.RequestSize = req.Size()
 	v.Elapsed = time.Since(v.Time)
 	v.Method = req.Method()
+	v.Protocol = req.Proto()
 	v.Host = req.Host()
 	v.Scheme = req.Scheme()
 	v.URI = req.URI()
 	v.ResponseSize = res.Size()
 	v.ResponseCode = res.Status()
+	if route := c.Route(); !echo.IsEmptyRoute(route) {
+		v.RouteName = route.Name
+		v.RoutePath = route.Path
+	}
+	v.RequestID = res.Header().Get(echo.HeaderXRequestID)
+	if len(v.RequestID) == 0 {
+		v.RequestID = req.Header().Get(echo.HeaderXRequestID)
+	}
+}
+
+// setExtra 从 JWT 和反向代理中间件保存在 Context 中的数据获取用户和目标
+func (v *VisitorInfo) setExtra(c echo.Context, jwtContextKey string, proxyContextKey string) {
+	if len(jwtContextKey) > 0 {
+		if token, ok := c.Internal().Get(jwtContextKey).(*jwt.Token); ok && token.Claims != nil {
+			v.User, _ = token.Claims.GetSubject()
+		}
+	}
+	if len(proxyContextKey) > 0 {
+		if t, ok := c.Get(proxyContextKey).(ProxyTargeter); ok {
+			if v.Upstream = t.GetName(); len(v.Upstream) == 0 {
+				if u := t.GetURL(c); u != nil {
+					v.Upstream = u.Host
+				}
+			}
+		}
+	}
 }
 
 var DefaultLogWriter = GetDefaultLogWriter()
@@ -98,15 +141,56 @@ type LogConfig struct {
 	Skipper echo.Skipper       `json:"-"`
 	Writer  io.Writer          `json:"-"`
 	Execute func(*VisitorInfo) `json:"-"`
+
+	// Format 日志格式：json、combined(Apache/NCSA combined)、common 或包含 ${字段名} 的模板(如 `${remote_ip} ${route} ${latency_ms}`)，
+	// 可用的字段见 LogTemplateFields。为空时使用原来的格式。设置了 Execute 时忽略
+	Format string `json:"format"`
+
+	// SampleRate 采样比例(0~1)，0 或 1 记录全部请求。5xx 错误总是记录
+	SampleRate float64 `json:"sampleRate"`
+
+	// SlowThreshold 大于 0 时只记录耗时不少于该值的请求
+	SlowThreshold time.Duration `json:"slowThreshold"`
+
+	// JWTContextKey JWT 中间件保存 token 的键(见 jwt.JWTConfig.ContextKey)，用于记录 user 字段。默认为 jwtUser
+	JWTContextKey string `json:"jwtContextKey"`
+
+	// ProxyContextKey 反向代理中间件保存目标的键(见 ProxyConfig.ContextKey)，用于记录 upstream 字段。默认为 target
+	ProxyContextKey string `json:"proxyContextKey"`
+}
+
+// DefaultLogConfig is the default Log middleware config.
+var DefaultLogConfig = LogConfig{
+	Skipper:         echo.DefaultSkipper,
+	JWTContextKey:   `jwtUser`,
+	ProxyContextKey: DefaultProxyConfig.ContextKey,
 }
 
 func LogWithConfig(config LogConfig) echo.MiddlewareFunc {
 	if config.Skipper == nil {
-		config.Skipper = echo.DefaultSkipper
+		config.Skipper = DefaultLogConfig.Skipper
 	}
 	if config.Writer == nil {
 		config.Writer = DefaultLogWriter
 	}
+	if len(config.JWTContextKey) == 0 {
+		config.JWTContextKey = DefaultLogConfig.JWTContextKey
+	}
+	if len(config.ProxyContextKey) == 0 {
+		config.ProxyContextKey = DefaultLogConfig.ProxyContextKey
+	}
+	if config.Execute == nil && len(config.Format) > 0 {
+		format, err := NewLogFormatter(config.Format)
+		if err != nil {
+			panic(err)
+		}
+		config.Execute = func(v *VisitorInfo) {
+			buf := acquireLogBuffer()
+			format(buf, v)
+			config.Writer.Write(buf.Bytes())
+			releaseLogBuffer(buf)
+		}
+	}
 	if config.Execute == nil {
 		logger := std.New(config.Writer, ``, 0)
 		config.Execute = func(v *VisitorInfo) {
@@ -124,9 +208,23 @@ func LogWithConfig(config LogConfig) echo.MiddlewareFunc {
 				c.Error(err)
 			}
 			info.SetFromContext(c)
-			config.Execute(info)
+			if config.shouldLog(info) {
+				info.setExtra(c, config.JWTContextKey, config.ProxyContextKey)
+				config.Execute(info)
+			}
 			ReleaseVisitorInfo(info)
 			return nil
 		})
 	}
 }
+
+// shouldLog 慢请求模式和采样
+func (config *LogConfig) shouldLog(v *VisitorInfo) bool {
+	if config.SlowThreshold > 0 && v.Elapsed < config.SlowThreshold {
+		return false
+	}
+	if config.SampleRate <= 0 || config.SampleRate >= 1 || v.ResponseCode >= http.StatusInternalServerError {
+		return true
+	}
+	return rand.Float64() < config.SampleRate
+}
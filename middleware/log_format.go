@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFormatter 将访问信息格式化为一行日志(包含换行符)写入 buf
+type LogFormatter func(buf *bytes.Buffer, v *VisitorInfo)
+
+// 预定义的日志格式
+const (
+	LogFormatJSON     = `json`
+	LogFormatCombined = `combined`
+	LogFormatCommon   = `common`
+)
+
+const logTimeFormatApache = `02/Jan/2006:15:04:05 -0700`
+
+// LogTemplateFields 模板中可以使用的字段(${name})
+var LogTemplateFields = map[string]func(buf *bytes.Buffer, v *VisitorInfo){
+	`remote_ip`:  func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.RealIP) },
+	`time`:       func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.Time.Format(time.RFC3339)) },
+	`time_unix`:  func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(strconv.FormatInt(v.Time.Unix(), 10)) },
+	`method`:     func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.Method) },
+	`scheme`:     func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.Scheme) },
+	`host`:       func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.Host) },
+	`uri`:        func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.URI) },
+	`protocol`:   func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.Protocol) },
+	`status`:     func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(strconv.Itoa(v.ResponseCode)) },
+	`latency`:    func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.Elapsed.String()) },
+	`latency_ms`: func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(formatLatencyMS(v.Elapsed)) },
+	`bytes_in`:   func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(strconv.FormatInt(v.RequestSize, 10)) },
+	`bytes_out`:  func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(strconv.FormatInt(v.ResponseSize, 10)) },
+	`user_agent`: func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.UserAgent) },
+	`referer`:    func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.Referer) },
+	`route`:      func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.RouteName) },
+	`route_path`: func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.RoutePath) },
+	`request_id`: func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.RequestID) },
+	`user`:       func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.User) },
+	`upstream`:   func(buf *bytes.Buffer, v *VisitorInfo) { buf.WriteString(v.Upstream) },
+}
+
+var logBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+func acquireLogBuffer() *bytes.Buffer {
+	return logBufferPool.Get().(*bytes.Buffer)
+}
+
+func releaseLogBuffer(buf *bytes.Buffer) {
+	buf.Reset()
+	logBufferPool.Put(buf)
+}
+
+// NewLogFormatter 根据格式名称(json、combined、common)或模板创建 LogFormatter
+func NewLogFormatter(format string) (LogFormatter, error) {
+	switch format {
+	case LogFormatJSON:
+		return LogFormatterJSON, nil
+	case LogFormatCombined:
+		return LogFormatterCombined, nil
+	case LogFormatCommon:
+		return LogFormatterCommon, nil
+	}
+	return NewLogTemplate(format)
+}
+
+// NewLogTemplate 解析包含 ${字段名} 的模板
+func NewLogTemplate(tmpl string) (LogFormatter, error) {
+	var parts []func(buf *bytes.Buffer, v *VisitorInfo)
+	for len(tmpl) > 0 {
+		start := strings.Index(tmpl, `${`)
+		if start < 0 {
+			parts = append(parts, logTemplateText(tmpl))
+			break
+		}
+		end := strings.Index(tmpl[start:], `}`)
+		if end < 0 {
+			return nil, fmt.Errorf(`log template: unclosed tag %q`, tmpl[start:])
+		}
+		end += start
+		if start > 0 {
+			parts = append(parts, logTemplateText(tmpl[:start]))
+		}
+		name := tmpl[start+2 : end]
+		field, ok := LogTemplateFields[name]
+		if !ok {
+			return nil, fmt.Errorf(`log template: unknown field %q`, name)
+		}
+		parts = append(parts, field)
+		tmpl = tmpl[end+1:]
+	}
+	return func(buf *bytes.Buffer, v *VisitorInfo) {
+		for _, part := range parts {
+			part(buf, v)
+		}
+		buf.WriteByte('\n')
+	}, nil
+}
+
+func logTemplateText(text string) func(buf *bytes.Buffer, v *VisitorInfo) {
+	return func(buf *bytes.Buffer, _ *VisitorInfo) {
+		buf.WriteString(text)
+	}
+}
+
+type logEntryJSON struct {
+	Time      string  `json:"time"`
+	RemoteIP  string  `json:"remote_ip"`
+	Method    string  `json:"method"`
+	Scheme    string  `json:"scheme"`
+	Host      string  `json:"host"`
+	URI       string  `json:"uri"`
+	Protocol  string  `json:"protocol"`
+	Status    int     `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	BytesIn   int64   `json:"bytes_in"`
+	BytesOut  int64   `json:"bytes_out"`
+	UserAgent string  `json:"user_agent,omitempty"`
+	Referer   string  `json:"referer,omitempty"`
+	Route     string  `json:"route,omitempty"`
+	RoutePath string  `json:"route_path,omitempty"`
+	RequestID string  `json:"request_id,omitempty"`
+	User      string  `json:"user,omitempty"`
+	Upstream  string  `json:"upstream,omitempty"`
+}
+
+// LogFormatterJSON 每行一个 JSON 对象
+func LogFormatterJSON(buf *bytes.Buffer, v *VisitorInfo) {
+	json.NewEncoder(buf).Encode(logEntryJSON{
+		Time:      v.Time.Format(time.RFC3339Nano),
+		RemoteIP:  v.RealIP,
+		Method:    v.Method,
+		Scheme:    v.Scheme,
+		Host:      v.Host,
+		URI:       v.URI,
+		Protocol:  v.Protocol,
+		Status:    v.ResponseCode,
+		LatencyMS: float64(v.Elapsed) / float64(time.Millisecond),
+		BytesIn:   v.RequestSize,
+		BytesOut:  v.ResponseSize,
+		UserAgent: v.UserAgent,
+		Referer:   v.Referer,
+		Route:     v.RouteName,
+		RoutePath: v.RoutePath,
+		RequestID: v.RequestID,
+		User:      v.User,
+		Upstream:  v.Upstream,
+	})
+}
+
+// LogFormatterCommon Apache/NCSA common 格式
+func LogFormatterCommon(buf *bytes.Buffer, v *VisitorInfo) {
+	writeLogCommon(buf, v)
+	buf.WriteByte('\n')
+}
+
+// LogFormatterCombined Apache/NCSA combined 格式
+func LogFormatterCombined(buf *bytes.Buffer, v *VisitorInfo) {
+	writeLogCommon(buf, v)
+	buf.WriteString(` "`)
+	writeLogQuoted(buf, logOrDash(v.Referer))
+	buf.WriteString(`" "`)
+	writeLogQuoted(buf, logOrDash(v.UserAgent))
+	buf.WriteString("\"\n")
+}
+
+func writeLogCommon(buf *bytes.Buffer, v *VisitorInfo) {
+	buf.WriteString(logOrDash(v.RealIP))
+	buf.WriteString(` - `)
+	buf.WriteString(logOrDash(strings.ReplaceAll(v.User, ` `, `_`)))
+	buf.WriteString(` [`)
+	buf.WriteString(v.Time.Format(logTimeFormatApache))
+	buf.WriteString(`] "`)
+	writeLogQuoted(buf, v.Method+` `+v.URI+` `+v.Protocol)
+	buf.WriteString(`" `)
+	buf.WriteString(strconv.Itoa(v.ResponseCode))
+	buf.WriteByte(' ')
+	if v.ResponseSize > 0 {
+		buf.WriteString(strconv.FormatInt(v.ResponseSize, 10))
+	} else {
+		buf.WriteByte('-')
+	}
+}
+
+// writeLogQuoted 转义双引号、反斜杠和控制字符
+func writeLogQuoted(buf *bytes.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case b == '"' || b == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		case b < 0x20 || b == 0x7f:
+			fmt.Fprintf(buf, `\x%02x`, b)
+		default:
+			buf.WriteByte(b)
+		}
+	}
+}
+
+func logOrDash(s string) string {
+	if len(s) == 0 {
+		return `-`
+	}
+	return s
+}
+
+func formatLatencyMS(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
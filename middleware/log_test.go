@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/webx-top/echo"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func newLogTestEcho(config LogConfig) *echo.Echo {
+	e := echo.New()
+	e.Use(RequestIDWithConfig(RequestIDConfig{Generator: func() string { return `rid-1` }}))
+	e.Use(LogWithConfig(config))
+	e.Get(`/user/:id`, func(c echo.Context) error {
+		c.Internal().Set(`jwtUser`, &jwt.Token{Claims: jwt.MapClaims{`sub`: `alice`}})
+		c.Set(`target`, &ProxyTarget{Name: `backend`})
+		return c.String(`hello`)
+	}).SetName(`user.detail`)
+	e.Get(`/slow`, func(c echo.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return c.String(`slow`)
+	})
+	e.Get(`/fail`, func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	})
+	e.RebuildRouter()
+	return e
+}
+
+// logRequest 模拟服务器设置 RequestURI 和 RemoteAddr
+func logRequest(e *echo.Echo, path string, reqRewrite ...func(*http.Request)) {
+	myTesting.Request(http.MethodGet, path, e, append([]func(*http.Request){func(r *http.Request) {
+		r.RequestURI = r.URL.RequestURI()
+		r.RemoteAddr = `192.0.2.1:1234`
+	}}, reqRewrite...)...)
+}
+
+func TestLogFormats(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := newLogTestEcho(LogConfig{Writer: buf, Format: LogFormatJSON})
+	logRequest(e, `/user/1?a=1`, func(r *http.Request) {
+		r.Header.Set(`User-Agent`, `test-agent`)
+	})
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, `GET`, entry[`method`])
+	assert.Equal(t, `/user/1?a=1`, entry[`uri`])
+	assert.Equal(t, float64(200), entry[`status`])
+	assert.Equal(t, float64(5), entry[`bytes_out`])
+	assert.Equal(t, `user.detail`, entry[`route`])
+	assert.Equal(t, `/user/:id`, entry[`route_path`])
+	assert.Equal(t, `rid-1`, entry[`request_id`])
+	assert.Equal(t, `alice`, entry[`user`])
+	assert.Equal(t, `backend`, entry[`upstream`])
+	assert.Equal(t, `test-agent`, entry[`user_agent`])
+
+	buf.Reset()
+	e = newLogTestEcho(LogConfig{Writer: buf, Format: LogFormatCombined})
+	logRequest(e, `/user/1`, func(r *http.Request) {
+		r.Header.Set(`User-Agent`, `agent "x"`)
+		r.Header.Set(`Referer`, `http://example.com/`)
+	})
+	line := buf.String()
+	assert.True(t, strings.HasPrefix(line, `192.0.2.1 - alice [`), line)
+	assert.True(t, strings.HasSuffix(line, `] "GET /user/1 HTTP/1.1" 200 5 "http://example.com/" "agent \"x\""`+"\n"), line)
+
+	buf.Reset()
+	e = newLogTestEcho(LogConfig{Writer: buf, Format: `${remote_ip} ${route} ${status} ${upstream} ${latency_ms}`})
+	logRequest(e, `/user/1`)
+	assert.Regexp(t, `^192\.0\.2\.1 user\.detail 200 backend \d+\.\d{3}\n$`, buf.String())
+
+	_, err := NewLogFormatter(`${unknown}`)
+	assert.EqualError(t, err, `log template: unknown field "unknown"`)
+	_, err = NewLogFormatter(`${remote_ip`)
+	assert.Error(t, err)
+}
+
+func TestLogSlowAndSampling(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := newLogTestEcho(LogConfig{Writer: buf, Format: `${uri}`, SlowThreshold: 10 * time.Millisecond})
+	logRequest(e, `/user/1`)
+	logRequest(e, `/slow`)
+	assert.Equal(t, "/slow\n", buf.String())
+
+	buf.Reset()
+	e = newLogTestEcho(LogConfig{Writer: buf, Format: `${uri}`, SampleRate: 0.000001})
+	for i := 0; i < 10; i++ {
+		logRequest(e, `/user/1`)
+	}
+	// 5xx 错误不参与采样
+	logRequest(e, `/fail`)
+	assert.Equal(t, "/fail\n", buf.String())
+}
+
+func TestLogAsyncWriterAndReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, `access.log`)
+	file, err := OpenLogFile(path)
+	require.NoError(t, err)
+	defer file.Close()
+	w := NewAsyncLogWriter(file, 10, time.Hour)
+	e := newLogTestEcho(LogConfig{Writer: w, Format: `${uri}`})
+
+	logRequest(e, `/user/1`)
+	w.Flush()
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "/user/1\n", string(b))
+
+	// 模拟 logrotate：移走文件后发送 SIGHUP
+	require.NoError(t, os.Rename(path, path+`.1`))
+	stop := file.ReopenOnSignal(nil)
+	defer stop()
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skip(err)
+	}
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	logRequest(e, `/user/2`)
+	require.NoError(t, w.Close())
+	b, _ = os.ReadFile(path)
+	assert.Equal(t, "/user/2\n", string(b))
+	b, _ = os.ReadFile(path + `.1`)
+	assert.Equal(t, "/user/1\n", string(b))
+	_, err = w.Write([]byte(`x`))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
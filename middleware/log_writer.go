@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// AsyncLogWriter 异步缓冲写入。Write 只是把数据放入队列，由后台 goroutine 批量写入；队列已满时丢弃
+type AsyncLogWriter struct {
+	w             io.Writer
+	ch            chan []byte
+	flushCh       chan chan struct{}
+	done          chan struct{}
+	flushInterval time.Duration
+	dropped       atomic.Int64
+	closeOnce     sync.Once
+	mu            sync.RWMutex
+	closed        bool
+}
+
+// NewAsyncLogWriter bufferSize 为队列长度(默认 1024)，flushInterval 为定时刷新间隔(默认 1 秒)
+func NewAsyncLogWriter(w io.Writer, bufferSize int, flushInterval time.Duration) *AsyncLogWriter {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	a := &AsyncLogWriter{
+		w:             w,
+		ch:            make(chan []byte, bufferSize),
+		flushCh:       make(chan chan struct{}),
+		done:          make(chan struct{}),
+		flushInterval: flushInterval,
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncLogWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return 0, os.ErrClosed
+	}
+	b := make([]byte, len(p))
+	copy(b, p)
+	select {
+	case a.ch <- b:
+	default:
+		a.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Dropped 因队列已满而丢弃的条数
+func (a *AsyncLogWriter) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Flush 写入队列中的所有数据
+func (a *AsyncLogWriter) Flush() {
+	ack := make(chan struct{})
+	select {
+	case a.flushCh <- ack:
+		<-ack
+	case <-a.done:
+	}
+}
+
+// Close 写入队列中的所有数据后停止后台 goroutine。不会关闭底层的 io.Writer
+func (a *AsyncLogWriter) Close() error {
+	a.closeOnce.Do(func() {
+		a.mu.Lock()
+		a.closed = true
+		close(a.ch)
+		a.mu.Unlock()
+	})
+	<-a.done
+	return nil
+}
+
+func (a *AsyncLogWriter) run() {
+	bw := bufio.NewWriterSize(a.w, 64*1024)
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case b, ok := <-a.ch:
+			if !ok {
+				bw.Flush()
+				close(a.done)
+				return
+			}
+			bw.Write(b)
+		case ack := <-a.flushCh:
+			a.drain(bw)
+			bw.Flush()
+			close(ack)
+		case <-ticker.C:
+			bw.Flush()
+		}
+	}
+}
+
+func (a *AsyncLogWriter) drain(bw *bufio.Writer) {
+	for {
+		select {
+		case b, ok := <-a.ch:
+			if !ok {
+				return
+			}
+			bw.Write(b)
+		default:
+			return
+		}
+	}
+}
+
+// LogFile 可以重新打开的日志文件。日志文件被外部工具(如 logrotate)移走后，调用 Reopen 或发送 SIGHUP 信号(见 ReopenOnSignal)创建新文件
+type LogFile struct {
+	path string
+	mu   sync.RWMutex
+	file *os.File
+}
+
+// OpenLogFile 以追加方式打开日志文件
+func OpenLogFile(path string) (*LogFile, error) {
+	f := &LogFile{path: path}
+	if err := f.Reopen(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *LogFile) Write(p []byte) (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	return f.file.Write(p)
+}
+
+// Reopen 关闭并重新打开日志文件
+func (f *LogFile) Reopen() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	old := f.file
+	f.file = file
+	f.mu.Unlock()
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+func (f *LogFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// ReopenOnSignal 收到信号(默认为 SIGHUP)时重新打开日志文件。返回的函数用于停止监听
+func (f *LogFile) ReopenOnSignal(onError func(error), sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := f.Reopen(); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
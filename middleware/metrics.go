@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/webx-top/echo"
+)
+
+type (
+	// MetricsConfig defines the config for Metrics middleware.
+	MetricsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// Registry 指标注册表。默认为 DefaultMetricsRegistry
+		Registry *MetricsRegistry `json:"-"`
+
+		// DurationBuckets 请求耗时(秒)直方图的桶
+		DurationBuckets []float64 `json:"durationBuckets"`
+
+		// SizeBuckets 响应大小(字节)直方图的桶
+		SizeBuckets []float64 `json:"sizeBuckets"`
+
+		// RouteLabel 生成 route 标签的值。默认为 DefaultMetricsRouteLabel
+		RouteLabel func(echo.Context) string `json:"-"`
+
+		// MaxRoutes route 标签值的最大数量，超出后记为 other，避免指标数量无限增长。默认为 500
+		MaxRoutes int `json:"maxRoutes"`
+	}
+
+	metricsCollector struct {
+		requests  *MetricsCounter
+		inFlight  *MetricsGauge
+		duration  *MetricsHistogram
+		size      *MetricsHistogram
+		routes    sync.Map
+		numRoute  int64
+		maxRoutes int64
+	}
+)
+
+const (
+	// MetricsRouteUnmatched 没有匹配到路由的请求(404 等)的 route 标签值
+	MetricsRouteUnmatched = `unmatched`
+	// MetricsRouteOther 超出 MaxRoutes 后的 route 标签值
+	MetricsRouteOther = `other`
+)
+
+var (
+	// DefaultMetricsConfig is the default Metrics middleware config.
+	DefaultMetricsConfig = MetricsConfig{
+		Skipper:         echo.DefaultSkipper,
+		DurationBuckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		SizeBuckets:     []float64{100, 1000, 10000, 100000, 1000000, 10000000, 100000000},
+		RouteLabel:      DefaultMetricsRouteLabel,
+		MaxRoutes:       500,
+	}
+
+	metricsMethods = map[string]struct{}{
+		http.MethodGet: {}, http.MethodHead: {}, http.MethodPost: {}, http.MethodPut: {}, http.MethodPatch: {},
+		http.MethodDelete: {}, http.MethodConnect: {}, http.MethodOptions: {}, http.MethodTrace: {},
+	}
+)
+
+// DefaultMetricsRouteLabel 默认使用路由名称，没有名称(或为根据处理函数自动生成的名称)时使用路由规则(如 /user/:id)，
+// 没有匹配到路由时为 unmatched
+func DefaultMetricsRouteLabel(c echo.Context) string {
+	route := c.Route()
+	if echo.IsEmptyRoute(route) {
+		return MetricsRouteUnmatched
+	}
+	if len(route.Name) > 0 && route.Name != echo.HandlerName(route.RawHandler()) {
+		return route.Name
+	}
+	return route.Path
+}
+
+// Metrics returns a middleware which collects request metrics into DefaultMetricsRegistry.
+func Metrics() echo.MiddlewareFunc {
+	return MetricsWithConfig(DefaultMetricsConfig)
+}
+
+// MetricsWithConfig returns a Metrics middleware from config.
+// See `Metrics()`.
+func MetricsWithConfig(config MetricsConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultMetricsConfig.Skipper
+	}
+	if config.Registry == nil {
+		config.Registry = DefaultMetricsRegistry
+	}
+	if len(config.DurationBuckets) == 0 {
+		config.DurationBuckets = DefaultMetricsConfig.DurationBuckets
+	}
+	if len(config.SizeBuckets) == 0 {
+		config.SizeBuckets = DefaultMetricsConfig.SizeBuckets
+	}
+	if config.RouteLabel == nil {
+		config.RouteLabel = DefaultMetricsConfig.RouteLabel
+	}
+	if config.MaxRoutes <= 0 {
+		config.MaxRoutes = DefaultMetricsConfig.MaxRoutes
+	}
+	m := &metricsCollector{
+		requests:  config.Registry.Counter(`http_requests_total`, `Total number of HTTP requests.`, `route`, `method`, `status`),
+		inFlight:  config.Registry.Gauge(`http_requests_in_flight`, `Number of HTTP requests currently being served.`),
+		duration:  config.Registry.Histogram(`http_request_duration_seconds`, `HTTP request latency in seconds.`, config.DurationBuckets, `route`, `method`, `status`),
+		size:      config.Registry.Histogram(`http_response_size_bytes`, `HTTP response size in bytes.`, config.SizeBuckets, `route`, `method`, `status`),
+		maxRoutes: int64(config.MaxRoutes),
+	}
+
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			m.inFlight.Inc()
+			defer m.inFlight.Dec()
+			start := time.Now()
+			err := next.Handle(c)
+			elapsed := time.Since(start)
+
+			route := m.route(config.RouteLabel(c))
+			method := c.Request().Method()
+			if _, ok := metricsMethods[method]; !ok {
+				method = `OTHER`
+			}
+			status := strconv.Itoa(echo.ResponseStatus(c, err))
+			m.requests.Inc(route, method, status)
+			m.duration.Observe(elapsed.Seconds(), route, method, status)
+			m.size.Observe(float64(c.Response().Size()), route, method, status)
+			return err
+		})
+	}
+}
+
+// route 返回 route 标签的值。不同值的数量超过 MaxRoutes 后返回 other
+func (m *metricsCollector) route(route string) string {
+	if _, ok := m.routes.Load(route); ok {
+		return route
+	}
+	if atomic.AddInt64(&m.numRoute, 1) > m.maxRoutes {
+		atomic.AddInt64(&m.numRoute, -1)
+		return MetricsRouteOther
+	}
+	if _, loaded := m.routes.LoadOrStore(route, struct{}{}); loaded {
+		atomic.AddInt64(&m.numRoute, -1)
+	}
+	return route
+}
+
+// NewMetricsListener 包装 net.Listener，统计连接总数和当前活动连接数。
+// 通过 engine.Config 的 Listener 字段传给服务器引擎
+func NewMetricsListener(ln net.Listener, registry ...*MetricsRegistry) net.Listener {
+	r := DefaultMetricsRegistry
+	if len(registry) > 0 && registry[0] != nil {
+		r = registry[0]
+	}
+	return &metricsListener{
+		Listener: ln,
+		total:    r.Counter(`http_connections_total`, `Total number of accepted connections.`),
+		active:   r.Gauge(`http_active_connections`, `Number of currently open connections.`),
+	}
+}
+
+type metricsListener struct {
+	net.Listener
+	total  *MetricsCounter
+	active *MetricsGauge
+}
+
+func (l *metricsListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return conn, err
+	}
+	l.total.Inc()
+	l.active.Inc()
+	return &metricsConn{Conn: conn, active: l.active}, nil
+}
+
+type metricsConn struct {
+	net.Conn
+	active *MetricsGauge
+	once   sync.Once
+}
+
+func (c *metricsConn) Close() error {
+	c.once.Do(func() { c.active.Dec() })
+	return c.Conn.Close()
+}
@@ -0,0 +1,323 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	metricsKindCounter   = `counter`
+	metricsKindGauge     = `gauge`
+	metricsKindHistogram = `histogram`
+)
+
+type (
+	// MetricsRegistry 指标注册表。可以按 Prometheus 文本格式输出(见 WriteTo)
+	MetricsRegistry struct {
+		// Namespace 指标名称前缀。不为空时指标名称为 Namespace + `_` + name
+		Namespace string
+
+		mu    sync.RWMutex
+		vecs  map[string]*metricsVec
+		names []string
+	}
+
+	// MetricsCounter 只增不减的计数器
+	MetricsCounter struct {
+		*metricsVec
+	}
+
+	// MetricsGauge 可增可减的仪表
+	MetricsGauge struct {
+		*metricsVec
+	}
+
+	// MetricsHistogram 直方图
+	MetricsHistogram struct {
+		*metricsVec
+	}
+
+	metricsVec struct {
+		name       string
+		help       string
+		kind       string
+		labelNames []string
+		buckets    []float64
+		fn         func() float64
+
+		mu     sync.RWMutex
+		series map[string]*metricsSeries
+	}
+
+	metricsSeries struct {
+		labelValues []string
+
+		mu     sync.Mutex
+		value  float64
+		counts []uint64
+		sum    float64
+		count  uint64
+	}
+)
+
+// DefaultMetricsRegistry 默认的指标注册表
+var DefaultMetricsRegistry = NewMetricsRegistry(`echo`)
+
+// NewMetricsRegistry 创建指标注册表
+func NewMetricsRegistry(namespace string) *MetricsRegistry {
+	return &MetricsRegistry{
+		Namespace: namespace,
+		vecs:      map[string]*metricsVec{},
+	}
+}
+
+// Counter 注册计数器(名称应以 _total 结尾)。同名指标已存在时返回已有的指标(类型或标签名称不同时 panic)
+func (r *MetricsRegistry) Counter(name string, help string, labelNames ...string) *MetricsCounter {
+	return &MetricsCounter{r.register(name, help, metricsKindCounter, nil, labelNames)}
+}
+
+// Gauge 注册仪表。同名指标已存在时返回已有的指标(类型或标签名称不同时 panic)
+func (r *MetricsRegistry) Gauge(name string, help string, labelNames ...string) *MetricsGauge {
+	return &MetricsGauge{r.register(name, help, metricsKindGauge, nil, labelNames)}
+}
+
+// GaugeFunc 注册在输出时才调用 fn 取值的仪表
+func (r *MetricsRegistry) GaugeFunc(name string, help string, fn func() float64) {
+	v := r.register(name, help, metricsKindGauge, nil, nil)
+	v.mu.Lock()
+	v.fn = fn
+	v.mu.Unlock()
+}
+
+// Histogram 注册直方图。buckets 为各个桶的上限(升序)
+func (r *MetricsRegistry) Histogram(name string, help string, buckets []float64, labelNames ...string) *MetricsHistogram {
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	return &MetricsHistogram{r.register(name, help, metricsKindHistogram, buckets, labelNames)}
+}
+
+func (r *MetricsRegistry) register(name string, help string, kind string, buckets []float64, labelNames []string) *metricsVec {
+	if len(r.Namespace) > 0 {
+		name = r.Namespace + `_` + name
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v, ok := r.vecs[name]; ok {
+		if v.kind != kind || !slices.Equal(v.labelNames, labelNames) {
+			panic(fmt.Sprintf(`metrics: %s is already registered as %s%v, cannot register it as %s%v`, name, v.kind, v.labelNames, kind, labelNames))
+		}
+		return v
+	}
+	v := &metricsVec{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     map[string]*metricsSeries{},
+	}
+	r.vecs[name] = v
+	r.names = append(r.names, name)
+	return v
+}
+
+// WriteTo 按 Prometheus 文本格式(0.0.4)输出所有指标
+func (r *MetricsRegistry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	vecs := make([]*metricsVec, len(r.names))
+	for i, name := range r.names {
+		vecs[i] = r.vecs[name]
+	}
+	r.mu.RUnlock()
+	cw := &metricsCountWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, v := range vecs {
+		v.writeTo(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func (v *metricsVec) with(labelValues []string) *metricsSeries {
+	values := make([]string, len(v.labelNames))
+	copy(values, labelValues)
+	key := strings.Join(values, "\xff")
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok = v.series[key]; ok {
+		return s
+	}
+	s = &metricsSeries{labelValues: values}
+	if v.kind == metricsKindHistogram {
+		s.counts = make([]uint64, len(v.buckets))
+	}
+	v.series[key] = s
+	return s
+}
+
+func (v *metricsVec) writeTo(w *bufio.Writer) {
+	v.mu.RLock()
+	fn := v.fn
+	series := make([]*metricsSeries, 0, len(v.series))
+	for _, s := range v.series {
+		series = append(series, s)
+	}
+	v.mu.RUnlock()
+	if fn == nil && len(series) == 0 {
+		return
+	}
+	sort.Slice(series, func(i, j int) bool {
+		a, b := series[i].labelValues, series[j].labelValues
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+	if len(v.help) > 0 {
+		w.WriteString(`# HELP ` + v.name + ` ` + metricsHelpReplacer.Replace(v.help) + "\n")
+	}
+	w.WriteString(`# TYPE ` + v.name + ` ` + v.kind + "\n")
+	if fn != nil {
+		w.WriteString(v.name + ` ` + formatMetricsValue(fn()) + "\n")
+		return
+	}
+	for _, s := range series {
+		s.mu.Lock()
+		value, sum, count := s.value, s.sum, s.count
+		counts := append([]uint64{}, s.counts...)
+		s.mu.Unlock()
+		labels := v.formatLabels(s.labelValues)
+		if v.kind != metricsKindHistogram {
+			w.WriteString(v.name + wrapMetricsLabels(labels) + ` ` + formatMetricsValue(value) + "\n")
+			continue
+		}
+		var cumulative uint64
+		for i, upper := range v.buckets {
+			cumulative += counts[i]
+			w.WriteString(v.name + `_bucket` + wrapMetricsLabels(joinMetricsLabels(labels, `le="`+formatMetricsValue(upper)+`"`)) + ` ` + strconv.FormatUint(cumulative, 10) + "\n")
+		}
+		w.WriteString(v.name + `_bucket` + wrapMetricsLabels(joinMetricsLabels(labels, `le="+Inf"`)) + ` ` + strconv.FormatUint(count, 10) + "\n")
+		w.WriteString(v.name + `_sum` + wrapMetricsLabels(labels) + ` ` + formatMetricsValue(sum) + "\n")
+		w.WriteString(v.name + `_count` + wrapMetricsLabels(labels) + ` ` + strconv.FormatUint(count, 10) + "\n")
+	}
+}
+
+func (v *metricsVec) formatLabels(values []string) string {
+	parts := make([]string, len(v.labelNames))
+	for i, name := range v.labelNames {
+		parts[i] = name + `="` + metricsLabelReplacer.Replace(values[i]) + `"`
+	}
+	return strings.Join(parts, `,`)
+}
+
+// Inc 加 1
+func (c *MetricsCounter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 增加 delta(不能为负数)
+func (c *MetricsCounter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.with(labelValues).add(delta)
+}
+
+// Set 设置为 value
+func (g *MetricsGauge) Set(value float64, labelValues ...string) {
+	s := g.with(labelValues)
+	s.mu.Lock()
+	s.value = value
+	s.mu.Unlock()
+}
+
+// Add 增加 delta(可以为负数)
+func (g *MetricsGauge) Add(delta float64, labelValues ...string) {
+	g.with(labelValues).add(delta)
+}
+
+// Inc 加 1
+func (g *MetricsGauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec 减 1
+func (g *MetricsGauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Observe 记录一次观测值
+func (h *MetricsHistogram) Observe(value float64, labelValues ...string) {
+	s := h.with(labelValues)
+	i := sort.SearchFloat64s(h.buckets, value)
+	s.mu.Lock()
+	if i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.sum += value
+	s.count++
+	s.mu.Unlock()
+}
+
+func (s *metricsSeries) add(delta float64) {
+	s.mu.Lock()
+	s.value += delta
+	s.mu.Unlock()
+}
+
+type metricsCountWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *metricsCountWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+var (
+	metricsHelpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	metricsLabelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatMetricsValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return `+Inf`
+	case math.IsInf(v, -1):
+		return `-Inf`
+	case math.IsNaN(v):
+		return `NaN`
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func joinMetricsLabels(labels string, label string) string {
+	if len(labels) == 0 {
+		return label
+	}
+	return labels + `,` + label
+}
+
+func wrapMetricsLabels(labels string) string {
+	if len(labels) == 0 {
+		return ``
+	}
+	return `{` + labels + `}`
+}
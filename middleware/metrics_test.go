@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/code"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func metricsText(t *testing.T, r *MetricsRegistry) string {
+	buf := new(bytes.Buffer)
+	_, err := r.WriteTo(buf)
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestMetrics(t *testing.T) {
+	registry := NewMetricsRegistry(`test`)
+	e := echo.New()
+	e.Use(MetricsWithConfig(MetricsConfig{
+		Registry:        registry,
+		DurationBuckets: []float64{0.5, 1},
+		SizeBuckets:     []float64{5, 100},
+		MaxRoutes:       4,
+	}))
+	e.Get(`/user/:id`, func(c echo.Context) error {
+		return c.String(`user`)
+	}).SetName(`user.detail`)
+	e.Get(`/post/:id`, func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusForbidden)
+	})
+	e.Get(`/data/:id`, func(c echo.Context) error {
+		return echo.NewError(`not found`, code.DataNotFound)
+	})
+	e.Get(`/a`, func(c echo.Context) error { return c.String(`a`) })
+	e.Get(`/b`, func(c echo.Context) error { return c.String(`b`) })
+	e.Get(`/panic`, func(c echo.Context) error { panic(`boom`) })
+	e.RebuildRouter()
+
+	myTesting.Request(http.MethodGet, `/user/1`, e)
+	myTesting.Request(http.MethodGet, `/user/2`, e)
+	myTesting.Request(http.MethodGet, `/post/1`, e)
+	myTesting.Request(http.MethodGet, `/not-found`, e)
+	myTesting.Request(http.MethodGet, `/data/1`, e)
+	myTesting.Request(http.MethodGet, `/a`, e)
+	myTesting.Request(http.MethodGet, `/b`, e)
+	// 处理函数 panic 时也要减少 in_flight
+	assert.Panics(t, func() {
+		myTesting.Request(http.MethodGet, `/panic`, e)
+	})
+
+	text := metricsText(t, registry)
+	assert.Contains(t, text, "# TYPE test_http_requests_total counter\n")
+	assert.Contains(t, text, `test_http_requests_total{route="user.detail",method="GET",status="200"} 2`+"\n")
+	assert.Contains(t, text, `test_http_requests_total{route="/post/:id",method="GET",status="403"} 1`+"\n")
+	assert.Contains(t, text, `test_http_requests_total{route="/data/:id",method="GET",status="404"} 1`+"\n")
+	assert.Contains(t, text, `test_http_requests_total{route="unmatched",method="GET",status="404"} 1`+"\n")
+	// 超出 MaxRoutes 后统一记为 other
+	assert.Contains(t, text, `test_http_requests_total{route="other",method="GET",status="200"} 2`+"\n")
+	assert.Contains(t, text, `test_http_requests_in_flight 0`+"\n")
+	assert.Contains(t, text, `test_http_response_size_bytes_bucket{route="user.detail",method="GET",status="200",le="5"} 2`+"\n")
+	assert.Contains(t, text, `test_http_response_size_bytes_bucket{route="user.detail",method="GET",status="200",le="+Inf"} 2`+"\n")
+	assert.Contains(t, text, `test_http_response_size_bytes_sum{route="user.detail",method="GET",status="200"} 8`+"\n")
+	assert.Contains(t, text, `test_http_request_duration_seconds_count{route="user.detail",method="GET",status="200"} 2`+"\n")
+}
+
+func TestMetricsRegistry(t *testing.T) {
+	registry := NewMetricsRegistry(``)
+	counter := registry.Counter(`jobs_total`, "Jobs\nprocessed.", `name`)
+	counter.Inc("a\"b")
+	counter.Add(-1, `ignored`)
+	assert.Same(t, counter.metricsVec, registry.Counter(`jobs_total`, ``, `name`).metricsVec)
+	assert.Panics(t, func() {
+		registry.Gauge(`jobs_total`, ``, `name`)
+	})
+	assert.Panics(t, func() {
+		registry.Counter(`jobs_total`, ``, `kind`)
+	})
+	registry.GaugeFunc(`answer`, ``, func() float64 { return 42 })
+	h := registry.Histogram(`latency`, ``, []float64{1, 0.1})
+	h.Observe(0.1)
+	h.Observe(3)
+	assert.Equal(t, `# HELP jobs_total Jobs\nprocessed.
+# TYPE jobs_total counter
+jobs_total{name="a\"b"} 1
+# TYPE answer gauge
+answer 42
+# TYPE latency histogram
+latency_bucket{le="0.1"} 1
+latency_bucket{le="1"} 1
+latency_bucket{le="+Inf"} 2
+latency_sum 3.1
+latency_count 2
+`, metricsText(t, registry))
+}
+
+func TestMetricsQueueAndListener(t *testing.T) {
+	registry := NewMetricsRegistry(`test`)
+	ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	require.NoError(t, err)
+	mln := NewMetricsListener(ln, registry)
+	defer mln.Close()
+	go func() {
+		for {
+			conn, err := mln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 1)
+				conn.Read(buf)
+				conn.Close()
+			}()
+		}
+	}()
+	conn, err := net.Dial(`tcp`, ln.Addr().String())
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(metricsText(t, registry), "test_http_active_connections 1\n")
+	}, time.Second, 5*time.Millisecond)
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		return strings.Contains(metricsText(t, registry), "test_http_active_connections 0\n")
+	}, time.Second, 5*time.Millisecond)
+	assert.Contains(t, metricsText(t, registry), "test_http_connections_total 1\n")
+
+	e := echo.New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e.Use(QueueWithConfig(QueueConfig{
+		QueueSize:     2,
+		Workers:       1,
+		QueueTimeout:  time.Second,
+		WorkerTimeout: time.Second,
+		Metrics:       registry,
+		Name:          `api`,
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+	e.RebuildRouter()
+	done := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			myTesting.Request(http.MethodGet, `/`, e)
+			done <- struct{}{}
+		}()
+	}
+	<-started
+	assert.Eventually(t, func() bool {
+		text := metricsText(t, registry)
+		return strings.Contains(text, `test_queue_waiting{queue="api"} 1`+"\n") &&
+			strings.Contains(text, `test_queue_processing{queue="api"} 1`+"\n")
+	}, time.Second, 5*time.Millisecond)
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	<-done
+	<-done
+	text := metricsText(t, registry)
+	assert.Contains(t, text, `test_queue_waiting{queue="api"} 0`+"\n")
+	assert.Contains(t, text, `test_queue_processing{queue="api"} 0`+"\n")
+}
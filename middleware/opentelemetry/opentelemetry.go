@@ -0,0 +1,232 @@
+package opentelemetry
+
+import (
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
+	"github.com/webx-top/echo/middleware"
+)
+
+// ScopeName 创建 Tracer 和 Meter 时使用的名称
+const ScopeName = `github.com/webx-top/echo/middleware/opentelemetry`
+
+type (
+	// Config defines the config for OpenTelemetry middleware.
+	Config struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper
+
+		// TracerProvider 默认为 otel.GetTracerProvider()
+		TracerProvider trace.TracerProvider
+
+		// MeterProvider 默认为 otel.GetMeterProvider()
+		MeterProvider metric.MeterProvider
+
+		// Propagators 默认为 W3C Trace Context(traceparent、tracestate)和 Baggage
+		Propagators propagation.TextMapPropagator
+
+		// SpanNameFormatter 生成 span 名称。默认为 DefaultSpanNameFormatter
+		SpanNameFormatter func(c echo.Context) string
+
+		// DisableChildSpans 不为 Render、Bind 和保存会话创建子 span
+		DisableChildSpans bool
+
+		// InjectRequest 把当前 span 写入请求头(会覆盖客户端传入的 traceparent 等)，默认不写入。
+		// 反向代理可以使用 ProxyRequestRewriter 只把链路信息写入发往目标的请求
+		InjectRequest bool
+	}
+
+	// headerCarrier 以 engine.Header 作为 propagation.TextMapCarrier(同时支持 standard 和 fasthttp 引擎)
+	headerCarrier struct {
+		engine.Header
+	}
+)
+
+var (
+	// DefaultConfig is the default OpenTelemetry middleware config.
+	DefaultConfig = Config{
+		Skipper:           echo.DefaultSkipper,
+		SpanNameFormatter: DefaultSpanNameFormatter,
+	}
+)
+
+func (h headerCarrier) Keys() []string {
+	std := h.Std()
+	keys := make([]string, 0, len(std))
+	for k := range std {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// DefaultSpanNameFormatter 使用路由名称，没有名称(或为根据处理函数自动生成的名称)时为 `方法 路由规则`(如 GET /user/:id)，
+// 没有匹配到路由时为请求方法
+func DefaultSpanNameFormatter(c echo.Context) string {
+	route := c.Route()
+	if echo.IsEmptyRoute(route) {
+		return c.Request().Method()
+	}
+	if len(route.Name) > 0 && route.Name != echo.HandlerName(route.RawHandler()) {
+		return route.Name
+	}
+	return c.Request().Method() + ` ` + route.Path
+}
+
+// ProxyRequestRewriter 用作 middleware.ProxyConfig 的 RequestRewriter，把当前 span 写入发往目标的请求头。
+// propagators 默认为 W3C Trace Context 和 Baggage
+func ProxyRequestRewriter(propagators ...propagation.TextMapPropagator) func(c echo.Context, t middleware.ProxyTargeter, req *http.Request) {
+	var propagator propagation.TextMapPropagator
+	if len(propagators) > 0 && propagators[0] != nil {
+		propagator = propagators[0]
+	} else {
+		propagator = defaultPropagators()
+	}
+	return func(c echo.Context, _ middleware.ProxyTargeter, req *http.Request) {
+		propagator.Inject(c.StdContext(), propagation.HeaderCarrier(req.Header))
+	}
+}
+
+func defaultPropagators() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// Middleware returns an OpenTelemetry middleware using the global providers.
+//
+// Middleware traces http requests, records request metrics and propagates W3C trace context.
+func Middleware() echo.MiddlewareFunc {
+	return MiddlewareWithConfig(DefaultConfig)
+}
+
+// MiddlewareWithConfig returns an OpenTelemetry middleware with config.
+// See: `Middleware()`.
+func MiddlewareWithConfig(config Config) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultConfig.Skipper
+	}
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	if config.MeterProvider == nil {
+		config.MeterProvider = otel.GetMeterProvider()
+	}
+	if config.Propagators == nil {
+		config.Propagators = defaultPropagators()
+	}
+	if config.SpanNameFormatter == nil {
+		config.SpanNameFormatter = DefaultConfig.SpanNameFormatter
+	}
+	tracer := config.TracerProvider.Tracer(ScopeName)
+	meter := config.MeterProvider.Meter(ScopeName)
+	duration, err := meter.Float64Histogram(`http.server.request.duration`,
+		metric.WithUnit(`s`),
+		metric.WithDescription(`Duration of HTTP server requests.`),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	activeRequests, err := meter.Int64UpDownCounter(`http.server.active_requests`,
+		metric.WithUnit(`{request}`),
+		metric.WithDescription(`Number of active HTTP server requests.`),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	responseSize, err := meter.Int64Histogram(`http.server.response.body.size`,
+		metric.WithUnit(`By`),
+		metric.WithDescription(`Size of HTTP server response bodies.`),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	childSpan := func(c echo.Context, name string) func(error) {
+		_, span := tracer.Start(c.StdContext(), name, trace.WithSpanKind(trace.SpanKindInternal))
+		return func(err error) {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	}
+
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			req := c.Request()
+			carrier := headerCarrier{Header: req.Header()}
+			parent := c.StdContext()
+			ctx := config.Propagators.Extract(parent, carrier)
+			method := semconv.HTTPRequestMethodKey.String(req.Method())
+			scheme := semconv.URLScheme(req.Scheme())
+			attrs := []attribute.KeyValue{
+				method,
+				scheme,
+				semconv.URLPath(req.URL().Path()),
+				semconv.ServerAddress(req.Host()),
+				semconv.ClientAddress(c.RealIP()),
+			}
+			if ua := req.UserAgent(); len(ua) > 0 {
+				attrs = append(attrs, semconv.UserAgentOriginal(ua))
+			}
+			var route attribute.KeyValue
+			if r := c.Route(); !echo.IsEmptyRoute(r) {
+				route = semconv.HTTPRoute(r.Path)
+				attrs = append(attrs, route)
+			}
+			ctx, span := tracer.Start(ctx, config.SpanNameFormatter(c),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+			if config.InjectRequest {
+				config.Propagators.Inject(ctx, carrier)
+			}
+			c.Object().SetStdContext(ctx)
+			defer c.Object().SetStdContext(parent)
+			if !config.DisableChildSpans {
+				c.Object().SetSpanStarter(childSpan)
+			}
+
+			metricAttrs := []attribute.KeyValue{method, scheme}
+			if route.Valid() {
+				metricAttrs = append(metricAttrs, route)
+			}
+			activeAttrs := metric.WithAttributes(metricAttrs...)
+			activeRequests.Add(ctx, 1, activeAttrs)
+			start := time.Now()
+
+			err := next.Handle(c)
+
+			elapsed := time.Since(start)
+			activeRequests.Add(ctx, -1, activeAttrs)
+			status := echo.ResponseStatus(c, err)
+			size := c.Response().Size()
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status), semconv.HTTPResponseBodySize(int(size)))
+			if err != nil {
+				span.RecordError(err)
+			}
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			metricAttrs = append(metricAttrs, semconv.HTTPResponseStatusCode(status))
+			recordAttrs := metric.WithAttributes(metricAttrs...)
+			duration.Record(ctx, elapsed.Seconds(), recordAttrs)
+			responseSize.Record(ctx, size, recordAttrs)
+			return err
+		})
+	}
+}
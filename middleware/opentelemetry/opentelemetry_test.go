@@ -0,0 +1,202 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/code"
+	"github.com/webx-top/echo/middleware"
+	te "github.com/webx-top/echo/testing"
+)
+
+type testRenderer struct{}
+
+func (testRenderer) Render(w io.Writer, name string, data any, c echo.Context) error {
+	_, err := io.WriteString(w, `<p>`+name+`</p>`)
+	return err
+}
+
+func (testRenderer) RenderBy(w io.Writer, name string, content func(string) ([]byte, error), data any, c echo.Context) error {
+	return errors.New(`not implemented`)
+}
+
+const testTraceParent = `00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01`
+
+func newTestEcho(recorder *tracetest.SpanRecorder, reader sdkmetric.Reader) *echo.Echo {
+	e := echo.New()
+	e.SetRenderer(testRenderer{})
+	e.Use(MiddlewareWithConfig(Config{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}))
+	return e
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	e := newTestEcho(recorder, reader)
+	var member string
+	e.Post(`/user/:id`, func(c echo.Context) error {
+		member = baggage.FromContext(c.StdContext()).Member(`tenant`).Value()
+		var data struct{ Name string }
+		if err := c.Bind(&data); err != nil {
+			return err
+		}
+		return c.Render(`user`, data)
+	}).SetName(`user.update`)
+	e.Get(`/fail`, func(c echo.Context) error {
+		return errors.New(`boom`)
+	})
+	e.Get(`/secret`, func(c echo.Context) error {
+		return echo.NewError(`login required`, code.Unauthenticated)
+	})
+	e.RebuildRouter()
+
+	rec := te.Request(http.MethodPost, `/user/1`, e, func(r *http.Request) {
+		r.Header.Set(`Traceparent`, testTraceParent)
+		r.Header.Set(`Baggage`, `tenant=acme`)
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		r.Body = io.NopCloser(strings.NewReader(`{"Name":"alice"}`))
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `acme`, member)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	bind, render, server := spans[0], spans[1], spans[2]
+	assert.Equal(t, echo.SpanNameBind, bind.Name())
+	assert.Equal(t, echo.SpanNameRender, render.Name())
+	assert.Equal(t, `user.update`, server.Name())
+	assert.Equal(t, `0af7651916cd43dd8448eb211c80319c`, server.SpanContext().TraceID().String())
+	assert.Equal(t, `b7ad6b7169203331`, server.Parent().SpanID().String())
+	assert.True(t, server.Parent().IsRemote())
+	assert.Equal(t, server.SpanContext().SpanID(), bind.Parent().SpanID())
+	assert.Equal(t, server.SpanContext().SpanID(), render.Parent().SpanID())
+	assert.Contains(t, server.Attributes(), semconv.HTTPRoute(`/user/:id`))
+	assert.Contains(t, server.Attributes(), semconv.HTTPResponseStatusCode(http.StatusOK))
+	assert.Contains(t, server.Attributes(), semconv.HTTPResponseBodySize(len(`<p>user</p>`)))
+
+	te.Request(http.MethodGet, `/fail`, e)
+	spans = recorder.Ended()
+	server = spans[len(spans)-1]
+	assert.Equal(t, `GET /fail`, server.Name())
+	assert.Equal(t, codes.Error, server.Status().Code)
+	assert.Contains(t, server.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+	require.Len(t, server.Events(), 1)
+	assert.Equal(t, `exception`, server.Events()[0].Name)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	found := map[string]bool{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		found[m.Name] = true
+		if m.Name != `http.server.request.duration` {
+			continue
+		}
+		hist := m.Data.(metricdata.Histogram[float64])
+		require.Len(t, hist.DataPoints, 2)
+		for _, dp := range hist.DataPoints {
+			assert.Equal(t, uint64(1), dp.Count)
+			status, _ := dp.Attributes.Value(semconv.HTTPResponseStatusCodeKey)
+			route, _ := dp.Attributes.Value(semconv.HTTPRouteKey)
+			assert.Contains(t, []attribute.Value{attribute.IntValue(200), attribute.IntValue(500)}, status)
+			assert.Contains(t, []string{`/user/:id`, `/fail`}, route.AsString())
+		}
+	}
+	assert.True(t, found[`http.server.request.duration`])
+	assert.True(t, found[`http.server.active_requests`])
+	assert.True(t, found[`http.server.response.body.size`])
+
+	// echo.Error 的状态码为其 Code 对应的 HTTP 状态码
+	te.Request(http.MethodGet, `/secret`, e)
+	spans = recorder.Ended()
+	server = spans[len(spans)-1]
+	assert.Contains(t, server.Attributes(), semconv.HTTPResponseStatusCode(http.StatusUnauthorized))
+	assert.Equal(t, codes.Unset, server.Status().Code)
+}
+
+func TestMiddlewareProxyPropagation(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+
+	recorder := tracetest.NewSpanRecorder()
+	e := newTestEcho(recorder, sdkmetric.NewManualReader())
+	var inbound string
+	e.Use(func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			err := next.Handle(c)
+			inbound = c.Request().Header().Get(`Traceparent`)
+			return err
+		})
+	})
+	config := middleware.DefaultProxyConfig
+	config.Balancer = middleware.NewRoundRobinBalancer([]middleware.ProxyTargeter{&middleware.ProxyTarget{Name: `backend`, URL: u}})
+	config.RequestRewriter = ProxyRequestRewriter()
+	e.Use(middleware.ProxyWithConfig(config))
+	e.RebuildRouter()
+
+	te.Request(http.MethodGet, `/api`, e, func(r *http.Request) {
+		r.Header.Set(`Traceparent`, testTraceParent)
+		r.Header.Set(`Baggage`, `tenant=acme`)
+	})
+	header := <-headers
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	server := spans[0]
+	assert.Equal(t, `00-0af7651916cd43dd8448eb211c80319c-`+server.SpanContext().SpanID().String()+`-01`, header.Get(`Traceparent`))
+	assert.Equal(t, `tenant=acme`, header.Get(`Baggage`))
+	// 不修改原请求的请求头
+	assert.Equal(t, testTraceParent, inbound)
+}
+
+func TestMiddlewareInjectRequest(t *testing.T) {
+	for _, inject := range []bool{false, true} {
+		recorder := tracetest.NewSpanRecorder()
+		e := echo.New()
+		e.Use(MiddlewareWithConfig(Config{
+			TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+			MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader())),
+			InjectRequest:  inject,
+		}))
+		var traceParent string
+		e.Get(`/`, func(c echo.Context) error {
+			traceParent = c.Request().Header().Get(`Traceparent`)
+			return c.NoContent(http.StatusOK)
+		})
+		e.RebuildRouter()
+		te.Request(http.MethodGet, `/`, e, func(r *http.Request) {
+			r.Header.Set(`Traceparent`, testTraceParent)
+		})
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		if inject {
+			assert.Equal(t, `00-0af7651916cd43dd8448eb211c80319c-`+spans[0].SpanContext().SpanID().String()+`-01`, traceParent)
+		} else {
+			assert.Equal(t, testTraceParent, traceParent)
+		}
+	}
+}
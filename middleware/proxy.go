@@ -1,10 +1,10 @@
 package middleware
 
 import (
-	"fmt"
-	"io"
+	"context"
+	"crypto/tls"
+	"errors"
 	"math/rand"
-	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
 )
 
 // TODO: Handle TLS proxy
@@ -34,6 +35,23 @@ type (
 		// Context key to store selected ProxyTarget into context.
 		// Optional. Default value "target".
 		ContextKey string
+
+		// Retries 代理失败(尚未输出响应)时换用其它目标重试的最大次数。默认为 0(不重试)
+		Retries int
+
+		// RetryFilter 判断是否可以重试。默认只重试没有请求正文的幂等请求(GET、HEAD、OPTIONS、PUT、DELETE、TRACE)
+		RetryFilter func(c echo.Context, err error) bool `json:"-"`
+
+		// Forwarded 是否添加 RFC 7239 Forwarded 请求头(追加到已有的值之后)
+		Forwarded bool
+
+		// HeaderRewriter 在转发之前修改请求头。在设置 X-Real-IP、X-Forwarded-* 和 Forwarded 之后调用，
+		// 重试时会对每个目标调用一次
+		HeaderRewriter func(c echo.Context, t ProxyTargeter, header engine.Header) `json:"-"`
+
+		// RequestRewriter 修改发往目标的请求(不影响原请求)，例如写入链路追踪的请求头。
+		// 由内置的处理函数在 HeaderRewriter 之后调用，重试时会对每个目标调用一次
+		RequestRewriter func(c echo.Context, t ProxyTargeter, req *http.Request) `json:"-"`
 	}
 
 	// ProxyTarget defines the upstream target.
@@ -42,6 +60,15 @@ type (
 		URL           *url.URL
 		FlushInterval time.Duration
 		Meta          echo.Store
+
+		// Weight 权重，用于 WeightedRoundRobinBalancer、LeastConnectionsBalancer 和 ConsistentHashBalancer。默认为 1
+		Weight int
+
+		// TLSConfig 连接 https 或 wss 目标时使用的 TLS 配置
+		TLSConfig *tls.Config
+
+		// IdleTimeout 升级后的连接(WebSocket 等)的空闲超时时间。0 为不限制
+		IdleTimeout time.Duration
 	}
 
 	ProxyTargeter interface {
@@ -52,6 +79,22 @@ type (
 		GetMeta(echo.Context) echo.Store
 	}
 
+	// ProxyTargetWeighter 带权重的 ProxyTargeter
+	ProxyTargetWeighter interface {
+		GetWeight() int
+	}
+
+	// ProxyTargetAvailabler 可以报告自身是否可用的 ProxyTargeter(例如：CircuitBreakerTargeter)，
+	// 负载均衡器会跳过不可用的目标
+	ProxyTargetAvailabler interface {
+		Available() bool
+	}
+
+	// ProxyTargetHandler 可以自行控制代理请求处理的 ProxyTargeter(例如：CircuitBreakerTargeter)
+	ProxyTargetHandler interface {
+		ProxyHandle(ProxyHandler, echo.Context) error
+	}
+
 	// ProxyBalancer defines an interface to implement a load balancing technique.
 	ProxyBalancer interface {
 		AddTarget(ProxyTargeter) bool
@@ -59,12 +102,27 @@ type (
 		Next(echo.Context) ProxyTargeter
 	}
 
+	// ProxyBalancerExcluder 重试时选择尚未尝试过的目标。
+	// 对于同一请求总是选择同一目标的负载均衡器(例如一致性哈希)需要实现此接口才能重试其它目标
+	ProxyBalancerExcluder interface {
+		NextExcluding(c echo.Context, tried []ProxyTargeter) ProxyTargeter
+	}
+
+	// ProxyBalancerTracker 跟踪代理请求(用于统计连接数和被动健康检查)。
+	// Track 在请求转发之前调用，返回的函数在请求结束后调用
+	ProxyBalancerTracker interface {
+		Track(ProxyTargeter, echo.Context) func(error)
+	}
+
 	// ProxyHandler defines an interface to implement a proxy handler.
 	ProxyHandler func(t ProxyTargeter, c echo.Context) error
 
 	commonBalancer struct {
 		targets []ProxyTargeter
 		mutex   sync.RWMutex
+		states  sync.Map // name => *proxyTargetState
+		options proxyBalancerOptions
+		cancel  context.CancelFunc
 	}
 
 	// RandomBalancer implements a random load balancing technique.
@@ -100,20 +158,39 @@ func (t *ProxyTarget) GetMeta(_ echo.Context) echo.Store {
 	return t.Meta
 }
 
+func (t *ProxyTarget) GetWeight() int {
+	return t.Weight
+}
+
+func (t *ProxyTarget) GetTLSConfig() *tls.Config {
+	return t.TLSConfig
+}
+
+func (t *ProxyTarget) GetIdleTimeout() time.Duration {
+	return t.IdleTimeout
+}
+
 var (
-	_ ProxyTargeter = &ProxyTarget{}
+	_ ProxyTargeter           = &ProxyTarget{}
+	_ ProxyTargetWeighter     = &ProxyTarget{}
+	_ ProxyTargetConnConfiger = &ProxyTarget{}
+
+	// ErrProxyNoAvailableTarget 没有可用的代理目标
+	ErrProxyNoAvailableTarget = errors.New(`proxy: no available target`)
+
 	// DefaultProxyConfig is the default Proxy middleware config.
 	DefaultProxyConfig = ProxyConfig{
-		Skipper:    echo.DefaultSkipper,
-		Handler:    DefaultProxyHandler,
-		Rewrite:    DefaultRewriteConfig,
-		ContextKey: "target",
+		Skipper:     echo.DefaultSkipper,
+		Handler:     DefaultProxyHandler,
+		Rewrite:     DefaultRewriteConfig,
+		ContextKey:  "target",
+		RetryFilter: DefaultProxyRetryFilter,
 	}
 	// DefaultProxyHandler Proxy Handler
 	DefaultProxyHandler ProxyHandler = func(t ProxyTargeter, c echo.Context) error {
 		var key string
 		switch {
-		case c.IsWebsocket():
+		case isProxyUpgrade(c):
 			key = `raw`
 		case c.Header(echo.HeaderAccept) == echo.MIMEEventStream:
 			key = `sse`
@@ -124,6 +201,7 @@ var (
 			resp := c.Response().StdResponseWriter()
 			req := c.Request().StdRequest()
 			h(t, c).ServeHTTP(resp, req)
+			return proxyError(c)
 		}
 		return nil
 	}
@@ -131,7 +209,7 @@ var (
 	// DefaultProxyHandlers default preset handlers
 	DefaultProxyHandlers = map[string]func(ProxyTargeter, echo.Context) http.Handler{
 		`raw`: func(t ProxyTargeter, c echo.Context) http.Handler {
-			return proxyRaw(t, c)
+			return proxyUpgradeHandler(t, c)
 		},
 		`sse`: func(t ProxyTargeter, c echo.Context) http.Handler {
 			return proxyHTTPWithFlushInterval(t, c)
@@ -146,22 +224,70 @@ var (
 func proxyHTTPWithFlushInterval(t ProxyTargeter, c echo.Context) http.Handler {
 	proxy := httputil.NewSingleHostReverseProxy(t.GetURL(c))
 	proxy.FlushInterval = t.GetFlushInterval()
+	proxy.Transport = proxyTransport(t)
+	proxy.ErrorHandler = proxyErrorHandler(c)
+	proxy.Director = proxyRequestDirector(c, proxy.Director)
 	return proxy
 }
 
 // http
 func proxyHTTP(t ProxyTargeter, c echo.Context) http.Handler {
-	return httputil.NewSingleHostReverseProxy(t.GetURL(c))
+	proxy := httputil.NewSingleHostReverseProxy(t.GetURL(c))
+	proxy.Transport = proxyTransport(t)
+	proxy.ErrorHandler = proxyErrorHandler(c)
+	proxy.Director = proxyRequestDirector(c, proxy.Director)
+	return proxy
+}
+
+const (
+	proxyErrorKey           = `echo.proxy.error`
+	proxyRequestRewriterKey = `echo.proxy.requestRewriter`
+)
+
+// proxyRequestDirector 在 director 之后调用 ProxyConfig.RequestRewriter
+func proxyRequestDirector(c echo.Context, director func(*http.Request)) func(*http.Request) {
+	return func(req *http.Request) {
+		director(req)
+		proxyRewriteRequest(c, req)
+	}
+}
+
+// proxyRewriteRequest 调用由 ProxyConfig.serve 保存的 RequestRewriter 修改发往目标的请求
+func proxyRewriteRequest(c echo.Context, req *http.Request) {
+	if fn, ok := c.Internal().Get(proxyRequestRewriterKey).(func(*http.Request)); ok {
+		fn(req)
+	}
+}
+
+// proxyErrorHandler 只记录转发错误而不输出响应，由 proxyError 返回给 Proxy 中间件(以便重试或交给 HTTPErrorHandler 处理)
+func proxyErrorHandler(c echo.Context) func(http.ResponseWriter, *http.Request, error) {
+	return func(_ http.ResponseWriter, _ *http.Request, err error) {
+		c.Internal().Set(proxyErrorKey, err)
+	}
+}
+
+func proxyError(c echo.Context) error {
+	err, ok := c.Internal().Get(proxyErrorKey).(error)
+	if !ok {
+		return nil
+	}
+	c.Internal().Delete(proxyErrorKey)
+	if he, ok := err.(*echo.HTTPError); ok {
+		return he
+	}
+	return echo.NewHTTPError(http.StatusBadGateway).SetRaw(err)
 }
 
 // ProxyHTTPCustomHandler 自定义处理(支持传递body)
 func ProxyHTTPCustomHandler(t ProxyTargeter, c echo.Context) http.Handler {
-	return newSingleHostReverseProxy(t.GetURL(c), c)
+	proxy := newSingleHostReverseProxy(t.GetURL(c), c)
+	proxy.Transport = proxyTransport(t)
+	return proxy
 }
 
 func newSingleHostReverseProxy(target *url.URL, c echo.Context) *httputil.ReverseProxy {
-	director := DefaultProxyHTTPDirector(target, c)
-	return &httputil.ReverseProxy{Director: director}
+	director := proxyRequestDirector(c, DefaultProxyHTTPDirector(target, c))
+	return &httputil.ReverseProxy{Director: director, ErrorHandler: proxyErrorHandler(c)}
 }
 
 // DefaultProxyHTTPDirector default director
@@ -221,70 +347,28 @@ func joinURLPath(a, b *url.URL) (path, rawpath string) {
 	return a.Path + b.Path, apath + bpath
 }
 
-// websocket
-func proxyRaw(t ProxyTargeter, c echo.Context) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		in, _, err := w.(http.Hijacker).Hijack()
-		if err != nil {
-			c.Error(fmt.Errorf("proxy raw, hijack error=%v, url=%s", t.GetURL(c), err))
-			return
-		}
-		defer in.Close()
-
-		out, err := net.Dial("tcp", t.GetURL(c).Host)
-		if err != nil {
-			he := echo.NewHTTPError(http.StatusBadGateway, fmt.Sprintf("proxy raw, dial error=%v, url=%s", t.GetURL(c), err)).SetRaw(err)
-			c.Error(he)
-			return
-		}
-		defer out.Close()
-
-		// Write header
-		err = r.Write(out)
-		if err != nil {
-			he := echo.NewHTTPError(http.StatusBadGateway, fmt.Sprintf("proxy raw, request header copy error=%v, url=%s", t.GetURL(c), err)).SetRaw(err)
-			c.Error(he)
-			return
-		}
-
-		errCh := make(chan error, 2)
-		cp := func(dst io.Writer, src io.Reader) {
-			_, err = io.Copy(dst, src)
-			errCh <- err
-		}
-
-		go cp(out, in)
-		go cp(in, out)
-		err = <-errCh
-		if err != nil && err != io.EOF {
-			c.Logger().Errorf("proxy raw, copy body error=%v, url=%s", t.GetURL(c), err)
-		}
-	})
-}
-
 // NewRandomBalancer returns a random proxy balancer.
-func NewRandomBalancer(targets []ProxyTargeter) ProxyBalancer {
-	b := &randomBalancer{commonBalancer: new(commonBalancer)}
-	b.targets = targets
+func NewRandomBalancer(targets []ProxyTargeter, options ...ProxyBalancerOption) ProxyBalancer {
+	b := &randomBalancer{commonBalancer: newCommonBalancer(targets, options)}
+	b.random = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
 	return b
 }
 
 // NewRoundRobinBalancer returns a round-robin proxy balancer.
-func NewRoundRobinBalancer(targets []ProxyTargeter) ProxyBalancer {
-	b := &roundRobinBalancer{commonBalancer: new(commonBalancer)}
-	b.targets = targets
+func NewRoundRobinBalancer(targets []ProxyTargeter, options ...ProxyBalancerOption) ProxyBalancer {
+	b := &roundRobinBalancer{commonBalancer: newCommonBalancer(targets, options)}
 	return b
 }
 
 // AddTarget adds an upstream target to the list.
 func (b *commonBalancer) AddTarget(target ProxyTargeter) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 	for _, t := range b.targets {
 		if t.GetName() == target.GetName() {
 			return false
 		}
 	}
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
 
 	if target.GetFlushInterval() <= 0 {
 		target.SetFlushInterval(100 * time.Millisecond)
@@ -301,6 +385,7 @@ func (b *commonBalancer) RemoveTarget(name string) bool {
 	for i, t := range b.targets {
 		if t.GetName() == name {
 			b.targets = append(b.targets[:i], b.targets[i+1:]...)
+			b.states.Delete(name)
 			return true
 		}
 	}
@@ -309,20 +394,32 @@ func (b *commonBalancer) RemoveTarget(name string) bool {
 
 // Next randomly returns an upstream target.
 func (b *randomBalancer) Next(c echo.Context) ProxyTargeter {
-	if b.random == nil {
-		b.random = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	targets := b.availableTargets()
+	if len(targets) == 0 {
+		return nil
 	}
-	b.mutex.RLock()
-	defer b.mutex.RUnlock()
-	return b.targets[b.random.Intn(len(b.targets))]
+	b.mutex.Lock()
+	i := b.random.Intn(len(targets))
+	b.mutex.Unlock()
+	return targets[i]
 }
 
 // Next returns an upstream target using round-robin technique.
 func (b *roundRobinBalancer) Next(c echo.Context) ProxyTargeter {
-	b.i = b.i % uint32(len(b.targets))
-	t := b.targets[b.i]
-	atomic.AddUint32(&b.i, 1)
-	return t
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	n := uint32(len(b.targets))
+	if n == 0 {
+		return nil
+	}
+	start := atomic.AddUint32(&b.i, 1) - 1
+	for k := uint32(0); k < n; k++ {
+		t := b.targets[(start+k)%n]
+		if b.available(t) {
+			return t
+		}
+	}
+	return nil
 }
 
 // Proxy returns a Proxy middleware.
@@ -347,6 +444,9 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFuncd {
 	if config.Balancer == nil {
 		panic("echo: proxy middleware requires balancer")
 	}
+	if config.RetryFilter == nil {
+		config.RetryFilter = DefaultProxyRetryFilter
+	}
 	config.Rewrite.Init()
 	return func(next echo.Handler) echo.HandlerFunc {
 		return func(c echo.Context) (err error) {
@@ -356,8 +456,8 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFuncd {
 
 			req := c.Request()
 			tgt := config.Balancer.Next(c)
-			if len(config.ContextKey) > 0 {
-				c.Set(config.ContextKey, tgt)
+			if tgt == nil {
+				return echo.NewHTTPError(http.StatusServiceUnavailable).SetRaw(ErrProxyNoAvailableTarget)
 			}
 			req.URL().SetPath(config.Rewrite.Rewrite(req.URL().Path()))
 			// Fix header
@@ -367,11 +467,95 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFuncd {
 			if len(c.Header(echo.HeaderXForwardedProto)) == 0 {
 				req.Header().Set(echo.HeaderXForwardedProto, c.Scheme())
 			}
-			if c.IsWebsocket() && len(c.Header(echo.HeaderXForwardedFor)) == 0 { // For HTTP, it is automatically set by Go HTTP reverse proxy.
-				req.Header().Set(echo.HeaderXForwardedFor, c.RealIP())
+			if len(c.Header(echo.HeaderXForwardedHost)) == 0 {
+				req.Header().Set(echo.HeaderXForwardedHost, req.Host())
+			}
+			if isProxyUpgrade(c) { // For HTTP, it is automatically set by Go HTTP reverse proxy.
+				proxyAppendHeader(c, echo.HeaderXForwardedFor, proxyRemoteIP(c))
+			}
+			if config.Forwarded {
+				proxyAppendHeader(c, echo.HeaderForwarded, proxyForwarded(c))
 			}
 
-			return config.Handler(tgt, c)
+			err = config.serve(tgt, c)
+			if err == nil || config.Retries <= 0 {
+				return
+			}
+			tried := []ProxyTargeter{tgt}
+			for i := 0; i < config.Retries && config.RetryFilter(c, err); i++ {
+				tgt = config.nextUntried(c, tried)
+				if tgt == nil {
+					break
+				}
+				tried = append(tried, tgt)
+				c.Logger().Warnf(`proxy: retry with target %s: %v`, tgt.GetName(), err)
+				err = config.serve(tgt, c)
+				if err == nil {
+					break
+				}
+			}
+			return
 		}
 	}
 }
+
+func (config *ProxyConfig) serve(tgt ProxyTargeter, c echo.Context) (err error) {
+	if len(config.ContextKey) > 0 {
+		c.Set(config.ContextKey, tgt)
+	}
+	if config.HeaderRewriter != nil {
+		config.HeaderRewriter(c, tgt, c.Request().Header())
+	}
+	if config.RequestRewriter != nil {
+		c.Internal().Set(proxyRequestRewriterKey, func(req *http.Request) {
+			config.RequestRewriter(c, tgt, req)
+		})
+	}
+	if tracker, ok := config.Balancer.(ProxyBalancerTracker); ok {
+		done := tracker.Track(tgt, c)
+		defer func() {
+			done(err)
+		}()
+	}
+	if h, ok := tgt.(ProxyTargetHandler); ok {
+		return h.ProxyHandle(config.Handler, c)
+	}
+	return config.Handler(tgt, c)
+}
+
+// nextUntried 从负载均衡器中选择一个尚未尝试过的目标
+func (config *ProxyConfig) nextUntried(c echo.Context, tried []ProxyTargeter) ProxyTargeter {
+	if excluder, ok := config.Balancer.(ProxyBalancerExcluder); ok {
+		return excluder.NextExcluding(c, tried)
+	}
+	for i := 0; i <= len(tried); i++ {
+		tgt := config.Balancer.Next(c)
+		if tgt == nil {
+			return nil
+		}
+		var found bool
+		for _, t := range tried {
+			if t.GetName() == tgt.GetName() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return tgt
+		}
+	}
+	return nil
+}
+
+// DefaultProxyRetryFilter 默认只重试尚未输出响应且没有请求正文的幂等请求
+func DefaultProxyRetryFilter(c echo.Context, err error) bool {
+	if c.Response().Committed() || errors.Is(err, context.Canceled) {
+		return false
+	}
+	req := c.Request()
+	switch req.Method() {
+	case echo.GET, echo.HEAD, echo.OPTIONS, echo.PUT, echo.DELETE, echo.TRACE:
+		return req.Size() == 0
+	}
+	return false
+}
@@ -0,0 +1,544 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/webx-top/echo"
+)
+
+type (
+	// ProxyBalancerOption 负载均衡器选项
+	ProxyBalancerOption func(*proxyBalancerOptions)
+
+	// ProxyHealthCheckConfig 主动健康检查配置。定时向每个目标发送 HTTP 请求，
+	// 连续失败 UnhealthyThreshold 次后标记为不可用，连续成功 HealthyThreshold 次后恢复
+	ProxyHealthCheckConfig struct {
+		// Path 检查的网址路径。默认为 /
+		Path string `json:"path"`
+
+		// Method 检查的请求方法。默认为 GET
+		Method string `json:"method"`
+
+		// Interval 检查间隔。默认为 10 秒
+		Interval time.Duration `json:"interval"`
+
+		// Timeout 检查超时时间。默认为 3 秒
+		Timeout time.Duration `json:"timeout"`
+
+		// HealthyThreshold 连续成功多少次后恢复为可用。默认为 2
+		HealthyThreshold int `json:"healthyThreshold"`
+
+		// UnhealthyThreshold 连续失败多少次后标记为不可用。默认为 3
+		UnhealthyThreshold int `json:"unhealthyThreshold"`
+
+		// StatusCodes 视为健康的响应状态码。默认为 2xx 和 3xx
+		StatusCodes []int `json:"statusCodes"`
+
+		// Client 发送检查请求的客户端。默认为 http.Client{Timeout: Timeout}
+		Client *http.Client `json:"-"`
+	}
+
+	// ProxyPassiveHealthConfig 被动健康检查配置。根据代理请求的结果，
+	// 连续失败 MaxFails 次后在 EjectDuration 时间内不再选择该目标
+	ProxyPassiveHealthConfig struct {
+		// MaxFails 连续失败多少次后剔除。默认为 3
+		MaxFails int `json:"maxFails"`
+
+		// EjectDuration 剔除时长。默认为 30 秒
+		EjectDuration time.Duration `json:"ejectDuration"`
+
+		// IsFailure 判断代理请求是否失败。默认为 DefaultProxyIsFailure
+		IsFailure func(c echo.Context, err error) bool `json:"-"`
+	}
+
+	proxyBalancerOptions struct {
+		healthCheck  *ProxyHealthCheckConfig
+		passiveCheck *ProxyPassiveHealthConfig
+	}
+
+	proxyTargetState struct {
+		active       int64 // 正在处理的请求数
+		mu           sync.Mutex
+		unhealthy    bool
+		successes    int // 主动检查连续成功次数
+		failures     int // 主动检查连续失败次数
+		fails        int // 被动检查连续失败次数
+		ejectedUntil time.Time
+	}
+
+	// weightedRoundRobinBalancer implements a smooth weighted round-robin load balancing technique.
+	weightedRoundRobinBalancer struct {
+		*commonBalancer
+		mu      sync.Mutex
+		current map[string]int
+	}
+
+	// leastConnectionsBalancer selects the target with the fewest active requests relative to its weight.
+	leastConnectionsBalancer struct {
+		*commonBalancer
+		i uint32
+	}
+
+	// consistentHashBalancer selects the target by consistent hashing of a request key.
+	consistentHashBalancer struct {
+		*commonBalancer
+		keyFunc func(echo.Context) string
+		ringMu  sync.RWMutex
+		ring    []uint32
+		nodes   map[uint32]ProxyTargeter
+		count   int // 目标数量
+	}
+)
+
+// proxyHashReplicas 一致性哈希中每个权重对应的虚拟节点数
+const proxyHashReplicas = 100
+
+var (
+	// DefaultProxyHealthCheckConfig 默认的主动健康检查配置
+	DefaultProxyHealthCheckConfig = ProxyHealthCheckConfig{
+		Path:               `/`,
+		Method:             echo.GET,
+		Interval:           10 * time.Second,
+		Timeout:            3 * time.Second,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 3,
+	}
+
+	// DefaultProxyPassiveHealthConfig 默认的被动健康检查配置
+	DefaultProxyPassiveHealthConfig = ProxyPassiveHealthConfig{
+		MaxFails:      3,
+		EjectDuration: 30 * time.Second,
+		IsFailure:     DefaultProxyIsFailure,
+	}
+
+	_ ProxyBalancerTracker = &commonBalancer{}
+	_ io.Closer            = &commonBalancer{}
+)
+
+// DefaultProxyIsFailure 默认的代理失败判断：转发出错(客户端取消除外)或上游返回 502、503、504
+func DefaultProxyIsFailure(c echo.Context, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	switch c.Response().Status() {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// ProxyBalancerOptHealthCheck 启用主动健康检查。
+// 检查的网址由 ProxyTargeter.GetURL(nil) 获得，负载均衡器不再使用时需要调用其 Close() 方法(io.Closer)停止检查
+func ProxyBalancerOptHealthCheck(config ProxyHealthCheckConfig) ProxyBalancerOption {
+	return func(o *proxyBalancerOptions) {
+		o.healthCheck = &config
+	}
+}
+
+// ProxyBalancerOptPassiveHealth 启用被动健康检查(需要配合 Proxy 中间件使用)
+func ProxyBalancerOptPassiveHealth(config ProxyPassiveHealthConfig) ProxyBalancerOption {
+	return func(o *proxyBalancerOptions) {
+		o.passiveCheck = &config
+	}
+}
+
+// ProxyHashByHeader 使用请求头的值作为一致性哈希的键
+func ProxyHashByHeader(name string) func(echo.Context) string {
+	return func(c echo.Context) string {
+		return c.Header(name)
+	}
+}
+
+// ProxyHashByCookie 使用 Cookie 的值作为一致性哈希的键
+func ProxyHashByCookie(name string) func(echo.Context) string {
+	return func(c echo.Context) string {
+		return c.GetCookie(name)
+	}
+}
+
+// ProxyHashByIP 使用客户端 IP 作为一致性哈希的键
+func ProxyHashByIP(c echo.Context) string {
+	return c.RealIP()
+}
+
+// NewWeightedRoundRobinBalancer returns a smooth weighted round-robin proxy balancer.
+// 目标的权重通过 ProxyTargetWeighter 获取
+func NewWeightedRoundRobinBalancer(targets []ProxyTargeter, options ...ProxyBalancerOption) ProxyBalancer {
+	return &weightedRoundRobinBalancer{
+		commonBalancer: newCommonBalancer(targets, options),
+		current:        map[string]int{},
+	}
+}
+
+// NewLeastConnectionsBalancer returns a proxy balancer which selects the target
+// with the fewest active requests relative to its weight.
+// 连接数通过 ProxyBalancerTracker 统计，需要配合 Proxy 中间件使用
+func NewLeastConnectionsBalancer(targets []ProxyTargeter, options ...ProxyBalancerOption) ProxyBalancer {
+	return &leastConnectionsBalancer{commonBalancer: newCommonBalancer(targets, options)}
+}
+
+// NewConsistentHashBalancer returns a proxy balancer which selects the target by consistent hashing
+// of the key returned by keyFunc (e.g. ProxyHashByHeader, ProxyHashByCookie or ProxyHashByIP) for sticky sessions.
+// keyFunc 返回空字符串时使用客户端 IP。目标不可用时依次选择哈希环上的下一个目标
+func NewConsistentHashBalancer(targets []ProxyTargeter, keyFunc func(echo.Context) string, options ...ProxyBalancerOption) ProxyBalancer {
+	if keyFunc == nil {
+		keyFunc = ProxyHashByIP
+	}
+	b := &consistentHashBalancer{
+		commonBalancer: newCommonBalancer(targets, options),
+		keyFunc:        keyFunc,
+	}
+	b.rebuild()
+	return b
+}
+
+func newCommonBalancer(targets []ProxyTargeter, options []ProxyBalancerOption) *commonBalancer {
+	b := &commonBalancer{targets: targets}
+	for _, option := range options {
+		option(&b.options)
+	}
+	if cfg := b.options.passiveCheck; cfg != nil {
+		if cfg.MaxFails <= 0 {
+			cfg.MaxFails = DefaultProxyPassiveHealthConfig.MaxFails
+		}
+		if cfg.EjectDuration <= 0 {
+			cfg.EjectDuration = DefaultProxyPassiveHealthConfig.EjectDuration
+		}
+		if cfg.IsFailure == nil {
+			cfg.IsFailure = DefaultProxyPassiveHealthConfig.IsFailure
+		}
+	}
+	if cfg := b.options.healthCheck; cfg != nil {
+		cfg.init()
+		var ctx context.Context
+		ctx, b.cancel = context.WithCancel(context.Background())
+		go b.healthCheck(ctx, cfg)
+	}
+	return b
+}
+
+// Close 停止主动健康检查
+func (b *commonBalancer) Close() error {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	return nil
+}
+
+// Track implements ProxyBalancerTracker.
+func (b *commonBalancer) Track(t ProxyTargeter, c echo.Context) func(error) {
+	st := b.state(t.GetName())
+	atomic.AddInt64(&st.active, 1)
+	return func(err error) {
+		atomic.AddInt64(&st.active, -1)
+		cfg := b.options.passiveCheck
+		if cfg == nil {
+			return
+		}
+		failed := cfg.IsFailure(c, err)
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		if !failed {
+			st.fails = 0
+			return
+		}
+		st.fails++
+		if st.fails >= cfg.MaxFails {
+			st.fails = 0
+			st.ejectedUntil = time.Now().Add(cfg.EjectDuration)
+			c.Logger().Warnf(`proxy: target %s ejected for %v`, t.GetName(), cfg.EjectDuration)
+		}
+	}
+}
+
+func (b *commonBalancer) state(name string) *proxyTargetState {
+	if v, ok := b.states.Load(name); ok {
+		return v.(*proxyTargetState)
+	}
+	v, _ := b.states.LoadOrStore(name, &proxyTargetState{})
+	return v.(*proxyTargetState)
+}
+
+// available 目标是否可用(健康检查通过、没有被剔除且目标自身报告可用)
+func (b *commonBalancer) available(t ProxyTargeter) bool {
+	if a, ok := t.(ProxyTargetAvailabler); ok && !a.Available() {
+		return false
+	}
+	st := b.state(t.GetName())
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return !st.unhealthy && !time.Now().Before(st.ejectedUntil)
+}
+
+func (b *commonBalancer) availableTargets() []ProxyTargeter {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	targets := make([]ProxyTargeter, 0, len(b.targets))
+	for _, t := range b.targets {
+		if b.available(t) {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+func (b *commonBalancer) healthCheck(ctx context.Context, cfg *ProxyHealthCheckConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		b.mutex.RLock()
+		targets := append([]ProxyTargeter(nil), b.targets...)
+		b.mutex.RUnlock()
+		wg := sync.WaitGroup{}
+		for _, t := range targets {
+			wg.Add(1)
+			go func(t ProxyTargeter) {
+				defer wg.Done()
+				b.report(t, cfg, cfg.probe(ctx, t))
+			}(t)
+		}
+		wg.Wait()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *commonBalancer) report(t ProxyTargeter, cfg *ProxyHealthCheckConfig, healthy bool) {
+	st := b.state(t.GetName())
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if healthy {
+		st.failures = 0
+		st.successes++
+		if st.unhealthy && st.successes >= cfg.HealthyThreshold {
+			st.unhealthy = false
+		}
+		return
+	}
+	st.successes = 0
+	st.failures++
+	if !st.unhealthy && st.failures >= cfg.UnhealthyThreshold {
+		st.unhealthy = true
+	}
+}
+
+func (cfg *ProxyHealthCheckConfig) init() {
+	if len(cfg.Path) == 0 {
+		cfg.Path = DefaultProxyHealthCheckConfig.Path
+	}
+	if len(cfg.Method) == 0 {
+		cfg.Method = DefaultProxyHealthCheckConfig.Method
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultProxyHealthCheckConfig.Interval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultProxyHealthCheckConfig.Timeout
+	}
+	if cfg.HealthyThreshold <= 0 {
+		cfg.HealthyThreshold = DefaultProxyHealthCheckConfig.HealthyThreshold
+	}
+	if cfg.UnhealthyThreshold <= 0 {
+		cfg.UnhealthyThreshold = DefaultProxyHealthCheckConfig.UnhealthyThreshold
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+}
+
+func (cfg *ProxyHealthCheckConfig) probe(ctx context.Context, t ProxyTargeter) bool {
+	u := t.GetURL(nil)
+	if u == nil {
+		return false
+	}
+	target := *u
+	target.Path, target.RawPath = singleJoiningSlash(u.Path, cfg.Path), ``
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, target.String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return false
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if len(cfg.StatusCodes) == 0 {
+		return resp.StatusCode >= 200 && resp.StatusCode < 400
+	}
+	for _, code := range cfg.StatusCodes {
+		if code == resp.StatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func proxyTargetWeight(t ProxyTargeter) int {
+	if w, ok := t.(ProxyTargetWeighter); ok {
+		if weight := w.GetWeight(); weight > 0 {
+			return weight
+		}
+	}
+	return 1
+}
+
+// Next returns an upstream target using smooth weighted round-robin technique.
+func (b *weightedRoundRobinBalancer) Next(c echo.Context) ProxyTargeter {
+	targets := b.availableTargets()
+	if len(targets) == 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var (
+		best  ProxyTargeter
+		total int
+	)
+	for _, t := range targets {
+		name := t.GetName()
+		weight := proxyTargetWeight(t)
+		b.current[name] += weight
+		total += weight
+		if best == nil || b.current[name] > b.current[best.GetName()] {
+			best = t
+		}
+	}
+	b.current[best.GetName()] -= total
+	return best
+}
+
+// RemoveTarget removes an upstream target from the list.
+func (b *weightedRoundRobinBalancer) RemoveTarget(name string) bool {
+	if !b.commonBalancer.RemoveTarget(name) {
+		return false
+	}
+	b.mu.Lock()
+	delete(b.current, name)
+	b.mu.Unlock()
+	return true
+}
+
+// Next returns the upstream target with the fewest active requests relative to its weight.
+func (b *leastConnectionsBalancer) Next(c echo.Context) ProxyTargeter {
+	targets := b.availableTargets()
+	n := uint32(len(targets))
+	if n == 0 {
+		return nil
+	}
+	// 从轮询的位置开始比较，使连接数相同的目标被轮流选择
+	start := atomic.AddUint32(&b.i, 1) - 1
+	var (
+		best       ProxyTargeter
+		bestActive int64
+		bestWeight int64
+	)
+	for k := uint32(0); k < n; k++ {
+		t := targets[(start+k)%n]
+		active := atomic.LoadInt64(&b.state(t.GetName()).active)
+		weight := int64(proxyTargetWeight(t))
+		if best == nil || active*bestWeight < bestActive*weight {
+			best, bestActive, bestWeight = t, active, weight
+		}
+	}
+	return best
+}
+
+// AddTarget adds an upstream target to the list.
+func (b *consistentHashBalancer) AddTarget(target ProxyTargeter) bool {
+	if !b.commonBalancer.AddTarget(target) {
+		return false
+	}
+	b.rebuild()
+	return true
+}
+
+// RemoveTarget removes an upstream target from the list.
+func (b *consistentHashBalancer) RemoveTarget(name string) bool {
+	if !b.commonBalancer.RemoveTarget(name) {
+		return false
+	}
+	b.rebuild()
+	return true
+}
+
+func (b *consistentHashBalancer) rebuild() {
+	b.mutex.RLock()
+	nodes := map[uint32]ProxyTargeter{}
+	ring := make([]uint32, 0, len(b.targets)*proxyHashReplicas)
+	count := len(b.targets)
+	for _, t := range b.targets {
+		name := t.GetName()
+		replicas := proxyTargetWeight(t) * proxyHashReplicas
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + `#` + name))
+			if _, ok := nodes[h]; ok {
+				continue
+			}
+			nodes[h] = t
+			ring = append(ring, h)
+		}
+	}
+	b.mutex.RUnlock()
+	sort.Slice(ring, func(i, j int) bool { return ring[i] < ring[j] })
+	b.ringMu.Lock()
+	b.ring = ring
+	b.nodes = nodes
+	b.count = count
+	b.ringMu.Unlock()
+}
+
+// Next returns the upstream target selected by consistent hashing of the request key.
+func (b *consistentHashBalancer) Next(c echo.Context) ProxyTargeter {
+	return b.NextExcluding(c, nil)
+}
+
+// NextExcluding returns the next available target on the hash ring which is not in tried.
+func (b *consistentHashBalancer) NextExcluding(c echo.Context, tried []ProxyTargeter) ProxyTargeter {
+	key := b.keyFunc(c)
+	if len(key) == 0 {
+		key = c.RealIP()
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	b.ringMu.RLock()
+	defer b.ringMu.RUnlock()
+	n := len(b.ring)
+	if n == 0 {
+		return nil
+	}
+	start := sort.Search(n, func(i int) bool { return b.ring[i] >= h })
+	excluded := make(map[string]struct{}, len(tried))
+	for _, t := range tried {
+		excluded[t.GetName()] = struct{}{}
+	}
+	checked := map[string]struct{}{}
+	for k := 0; k < n; k++ {
+		t := b.nodes[b.ring[(start+k)%n]]
+		name := t.GetName()
+		if _, ok := checked[name]; ok {
+			continue
+		}
+		if _, ok := excluded[name]; !ok && b.available(t) {
+			return t
+		}
+		checked[name] = struct{}{}
+		if len(checked) == b.count {
+			break
+		}
+	}
+	return nil
+}
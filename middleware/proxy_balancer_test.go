@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func newTestProxyTargets(weights ...int) []ProxyTargeter {
+	targets := make([]ProxyTargeter, len(weights))
+	for i, weight := range weights {
+		u, _ := url.Parse(`http://127.0.0.1:` + strconv.Itoa(10000+i))
+		targets[i] = &ProxyTarget{Name: string(rune('a' + i)), URL: u, Weight: weight}
+	}
+	return targets
+}
+
+func TestWeightedRoundRobinBalancer(t *testing.T) {
+	b := NewWeightedRoundRobinBalancer(newTestProxyTargets(5, 1, 1))
+	c := echo.New().NewContext(myTesting.NewRequestAndResponse(http.MethodGet, `/`))
+	var names string
+	for i := 0; i < 7; i++ {
+		names += b.Next(c).GetName()
+	}
+	assert.Equal(t, `aabacaa`, names)
+	assert.True(t, b.RemoveTarget(`a`))
+	names = ``
+	for i := 0; i < 4; i++ {
+		names += b.Next(c).GetName()
+	}
+	assert.Equal(t, `bcbc`, names)
+}
+
+func TestLeastConnectionsBalancer(t *testing.T) {
+	b := NewLeastConnectionsBalancer(newTestProxyTargets(1, 1, 2))
+	c := echo.New().NewContext(myTesting.NewRequestAndResponse(http.MethodGet, `/`))
+	tracker := b.(ProxyBalancerTracker)
+	a := b.Next(c)
+	doneA := tracker.Track(a, c)
+	next := b.Next(c)
+	assert.NotEqual(t, a.GetName(), next.GetName())
+	doneNext := tracker.Track(next, c)
+	// c 的权重为 2，在已有一个连接时仍然优先
+	last := b.Next(c)
+	assert.Equal(t, `c`, last.GetName())
+	doneLast := tracker.Track(last, c)
+	doneA(nil)
+	assert.Equal(t, a.GetName(), b.Next(c).GetName())
+	doneNext(nil)
+	doneLast(nil)
+}
+
+func TestConsistentHashBalancer(t *testing.T) {
+	b := NewConsistentHashBalancer(newTestProxyTargets(1, 1, 1, 1), ProxyHashByHeader(`X-User`))
+	e := echo.New()
+	pick := func(user string) string {
+		req := httptest.NewRequest(http.MethodGet, `/`, nil)
+		req.Header.Set(`X-User`, user)
+		c := e.NewContext(myTesting.WrapRequest(req), nil)
+		return b.Next(c).GetName()
+	}
+	before := map[string]string{}
+	used := map[string]struct{}{}
+	for i := 0; i < 100; i++ {
+		user := `user` + strconv.Itoa(i)
+		before[user] = pick(user)
+		assert.Equal(t, before[user], pick(user))
+		used[before[user]] = struct{}{}
+	}
+	assert.Len(t, used, 4)
+
+	// 移除一个目标后，其它目标上的会话保持不变
+	assert.True(t, b.RemoveTarget(`b`))
+	for user, name := range before {
+		if name != `b` {
+			assert.Equal(t, name, pick(user))
+		} else {
+			assert.NotEqual(t, `b`, pick(user))
+		}
+	}
+}
+
+func TestProxyRetryAndPassiveHealth(t *testing.T) {
+	var hits int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		io.WriteString(w, `upstream`)
+	}))
+	defer upstream.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	downURL, _ := url.Parse(down.URL)
+	upURL, _ := url.Parse(upstream.URL)
+	balancer := NewRoundRobinBalancer([]ProxyTargeter{
+		&ProxyTarget{Name: `down`, URL: downURL},
+		&ProxyTarget{Name: `up`, URL: upURL},
+	}, ProxyBalancerOptPassiveHealth(ProxyPassiveHealthConfig{MaxFails: 1, EjectDuration: time.Minute}))
+
+	e := echo.New()
+	config := DefaultProxyConfig
+	config.Balancer = balancer
+	config.Retries = 1
+	e.Use(ProxyWithConfig(config))
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `upstream`, rec.Body.String())
+
+	// down 已被剔除，不再参与轮询
+	for i := 0; i < 3; i++ {
+		rec = myTesting.Request(http.MethodGet, `/`, e)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, int64(4), atomic.LoadInt64(&hits))
+
+	// 有请求正文的请求不重试
+	balancer = NewRoundRobinBalancer([]ProxyTargeter{
+		&ProxyTarget{Name: `down`, URL: downURL},
+		&ProxyTarget{Name: `up`, URL: upURL},
+	})
+	config.Balancer = balancer
+	e = echo.New()
+	e.Use(ProxyWithConfig(config))
+	e.RebuildRouter()
+	rec = myTesting.Request(http.MethodPost, `/`, e, func(r *http.Request) {
+		r.Body = io.NopCloser(strings.NewReader(`data`))
+		r.ContentLength = 4
+	})
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&hits))
+
+	assert.True(t, balancer.RemoveTarget(`down`))
+	assert.True(t, balancer.RemoveTarget(`up`))
+	rec = myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestProxyRetryConsistentHash(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `upstream`)
+	}))
+	defer upstream.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	downURL, _ := url.Parse(down.URL)
+	upURL, _ := url.Parse(upstream.URL)
+	balancer := NewConsistentHashBalancer([]ProxyTargeter{
+		&ProxyTarget{Name: `down`, URL: downURL},
+		&ProxyTarget{Name: `up`, URL: upURL},
+	}, ProxyHashByHeader(`X-User`))
+
+	e := echo.New()
+	// 找到哈希到 down 的用户
+	var user string
+	for i := 0; len(user) == 0; i++ {
+		req := httptest.NewRequest(http.MethodGet, `/`, nil)
+		req.Header.Set(`X-User`, `user`+strconv.Itoa(i))
+		c := e.NewContext(myTesting.WrapRequest(req), nil)
+		if tgt := balancer.Next(c); tgt.GetName() == `down` {
+			user = req.Header.Get(`X-User`)
+			excluder := balancer.(ProxyBalancerExcluder)
+			assert.Equal(t, `up`, excluder.NextExcluding(c, []ProxyTargeter{tgt}).GetName())
+			assert.Nil(t, excluder.NextExcluding(c, []ProxyTargeter{tgt, excluder.NextExcluding(c, []ProxyTargeter{tgt})}))
+		}
+	}
+
+	config := DefaultProxyConfig
+	config.Balancer = balancer
+	config.Retries = 1
+	e.Use(ProxyWithConfig(config))
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodGet, `/`, e, func(r *http.Request) {
+		r.Header.Set(`X-User`, user)
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `upstream`, rec.Body.String())
+}
+
+func TestProxyActiveHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == `/healthz` && !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, `ok`)
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+	b := NewRoundRobinBalancer([]ProxyTargeter{&ProxyTarget{Name: `up`, URL: u}}, ProxyBalancerOptHealthCheck(ProxyHealthCheckConfig{
+		Path:               `/healthz`,
+		Interval:           10 * time.Millisecond,
+		HealthyThreshold:   1,
+		UnhealthyThreshold: 1,
+	}))
+	defer b.(io.Closer).Close()
+	c := echo.New().NewContext(myTesting.NewRequestAndResponse(http.MethodGet, `/`))
+	assert.Eventually(t, func() bool { return b.Next(c) == nil }, time.Second, 5*time.Millisecond)
+	healthy.Store(true)
+	assert.Eventually(t, func() bool { return b.Next(c) != nil }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,341 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webx-top/echo"
+)
+
+type (
+	// ProxyTargetConnConfiger 可以自定义与目标之间连接参数的 ProxyTargeter
+	ProxyTargetConnConfiger interface {
+		// GetTLSConfig 连接 https 或 wss 目标时使用的 TLS 配置。为 nil 时使用默认配置
+		GetTLSConfig() *tls.Config
+		// GetIdleTimeout 升级后的连接(WebSocket 等)的空闲超时时间。0 为不限制
+		GetIdleTimeout() time.Duration
+	}
+
+	// proxyConn 优先读取已经读取到缓冲区中的数据
+	proxyConn struct {
+		net.Conn
+		reader *bufio.Reader
+	}
+)
+
+// proxyHopHeaders 逐跳请求头，不转发给目标(协议升级时会重新设置 Connection 和 Upgrade)
+var proxyHopHeaders = []string{
+	echo.HeaderConnection,
+	`Proxy-Connection`,
+	`Keep-Alive`,
+	`Proxy-Authenticate`,
+	`Proxy-Authorization`,
+	`Te`,
+	`Trailer`,
+	echo.HeaderTransferEncoding,
+	echo.HeaderUpgrade,
+}
+
+var proxyTransports sync.Map // *tls.Config => *http.Transport
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// isProxyUpgrade 是否为协议升级请求(WebSocket、h2c 等)
+func isProxyUpgrade(c echo.Context) bool {
+	if len(c.Header(echo.HeaderUpgrade)) == 0 {
+		return false
+	}
+	for _, v := range strings.Split(c.Header(echo.HeaderConnection), `,`) {
+		if strings.EqualFold(strings.TrimSpace(v), `upgrade`) {
+			return true
+		}
+	}
+	return c.IsWebsocket()
+}
+
+func proxyTLSConfig(t ProxyTargeter) *tls.Config {
+	if cc, ok := t.(ProxyTargetConnConfiger); ok {
+		return cc.GetTLSConfig()
+	}
+	return nil
+}
+
+func proxyIdleTimeout(t ProxyTargeter) time.Duration {
+	if cc, ok := t.(ProxyTargetConnConfiger); ok {
+		return cc.GetIdleTimeout()
+	}
+	return 0
+}
+
+// proxyTransport 返回使用目标 TLS 配置的 http.Transport(按 TLS 配置缓存)。没有 TLS 配置时返回 nil(使用 http.DefaultTransport)
+func proxyTransport(t ProxyTargeter) http.RoundTripper {
+	cfg := proxyTLSConfig(t)
+	if cfg == nil {
+		return nil
+	}
+	if v, ok := proxyTransports.Load(cfg); ok {
+		return v.(*http.Transport)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg.Clone()
+	transport.ForceAttemptHTTP2 = true
+	v, _ := proxyTransports.LoadOrStore(cfg, transport)
+	return v.(*http.Transport)
+}
+
+func proxyUpgradeHandler(t ProxyTargeter, c echo.Context) http.Handler {
+	return http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		if err := proxyUpgrade(t, c); err != nil {
+			c.Internal().Set(proxyErrorKey, err)
+		}
+	})
+}
+
+// proxyUpgrade 转发协议升级请求，目标同意升级(101)后在客户端与目标之间建立双向隧道。
+// 通过 engine.Response 的 Hijacker 接管客户端连接，所以同时支持 standard 和 fasthttp 引擎
+func proxyUpgrade(t ProxyTargeter, c echo.Context) error {
+	target := t.GetURL(c)
+	req := c.Request()
+	upgrade := req.Header().Get(echo.HeaderUpgrade)
+	outReq := &http.Request{
+		Method: req.Method(),
+		URL:    &url.URL{Scheme: `http`, Host: target.Host},
+		Header: req.Header().Std().Clone(),
+		Host:   req.Host(),
+	}
+	outReq.URL.Path, outReq.URL.RawPath = joinURLPath(target, &url.URL{Path: req.URL().Path(), RawPath: req.URL().RawPath()})
+	rawQuery := req.URL().RawQuery()
+	if len(target.RawQuery) == 0 || len(rawQuery) == 0 {
+		outReq.URL.RawQuery = target.RawQuery + rawQuery
+	} else {
+		outReq.URL.RawQuery = target.RawQuery + `&` + rawQuery
+	}
+	for _, h := range proxyHopHeaders {
+		outReq.Header.Del(h)
+	}
+	outReq.Header.Set(echo.HeaderConnection, `Upgrade`)
+	outReq.Header.Set(echo.HeaderUpgrade, upgrade)
+	if _, ok := outReq.Header[`User-Agent`]; !ok {
+		// explicitly disable User-Agent so it's not set to default value
+		outReq.Header.Set(`User-Agent`, ``)
+	}
+	proxyRewriteRequest(c, outReq)
+
+	conn, err := dialProxyTarget(c.StdContext(), t, target)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway).SetRaw(err)
+	}
+	if err = outReq.Write(conn); err != nil {
+		conn.Close()
+		return echo.NewHTTPError(http.StatusBadGateway).SetRaw(err)
+	}
+	backend := &proxyConn{Conn: conn, reader: bufio.NewReader(conn)}
+	resp, err := http.ReadResponse(backend.reader, outReq)
+	if err != nil {
+		conn.Close()
+		return echo.NewHTTPError(http.StatusBadGateway).SetRaw(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		// 目标拒绝升级时按普通响应转发
+		defer conn.Close()
+		defer resp.Body.Close()
+		return proxyCopyResponse(c, resp)
+	}
+	if !strings.EqualFold(resp.Header.Get(echo.HeaderUpgrade), upgrade) {
+		conn.Close()
+		err = fmt.Errorf(`backend tried to switch protocol %q when %q was requested`, resp.Header.Get(echo.HeaderUpgrade), upgrade)
+		return echo.NewHTTPError(http.StatusBadGateway).SetRaw(err)
+	}
+
+	// 处理函数返回后 Context 可能会被回收(fasthttp 在处理函数返回后才执行 Hijacker 的回调)，回调中不能使用 c
+	logger := c.Logger()
+	idleTimeout := proxyIdleTimeout(t)
+	name := t.GetName()
+	if h, ok := c.Response().Object().(interface{ HijackSetNoResponse(bool) }); ok {
+		h.HijackSetNoResponse(true) // 101 响应由回调输出
+	}
+	err = c.Response().Hijacker(func(client net.Conn) {
+		defer backend.Close()
+		if err := writeSwitchingProtocols(client, resp); err != nil {
+			logger.Errorf(`proxy upgrade, write response error=%v, target=%s`, err, name)
+			return
+		}
+		if err := proxyTunnel(client, backend, idleTimeout); err != nil {
+			logger.Debugf(`proxy upgrade, tunnel closed error=%v, target=%s`, err, name)
+		}
+	})
+	if err != nil {
+		backend.Close()
+		return echo.NewHTTPError(http.StatusInternalServerError).SetRaw(fmt.Errorf(`proxy upgrade, hijack error: %w`, err))
+	}
+	return nil
+}
+
+func dialProxyTarget(ctx context.Context, t ProxyTargeter, target *url.URL) (net.Conn, error) {
+	secure := target.Scheme == `https` || target.Scheme == `wss`
+	addr := target.Host
+	if len(target.Port()) == 0 {
+		if secure {
+			addr = net.JoinHostPort(target.Hostname(), `443`)
+		} else {
+			addr = net.JoinHostPort(target.Hostname(), `80`)
+		}
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !secure {
+		return dialer.DialContext(ctx, `tcp`, addr)
+	}
+	cfg := proxyTLSConfig(t)
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+	if len(cfg.ServerName) == 0 {
+		cfg.ServerName = target.Hostname()
+	}
+	cfg.NextProtos = []string{`http/1.1`} // 协议升级只能使用 HTTP/1.1
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: cfg}
+	return tlsDialer.DialContext(ctx, `tcp`, addr)
+}
+
+func proxyCopyResponse(c echo.Context, resp *http.Response) error {
+	for _, h := range proxyHopHeaders {
+		resp.Header.Del(h)
+	}
+	header := c.Response().Header()
+	for k, v := range resp.Header {
+		header.Del(k)
+		for _, vv := range v {
+			header.Add(k, vv)
+		}
+	}
+	c.Response().WriteHeader(resp.StatusCode)
+	_, err := io.Copy(c.Response(), resp.Body)
+	return err
+}
+
+func writeSwitchingProtocols(w io.Writer, resp *http.Response) error {
+	header := resp.Header.Clone()
+	for _, h := range proxyHopHeaders {
+		header.Del(h)
+	}
+	header.Set(echo.HeaderConnection, `Upgrade`)
+	header.Set(echo.HeaderUpgrade, resp.Header.Get(echo.HeaderUpgrade))
+	bw := bufio.NewWriter(w)
+	bw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	if err := header.Write(bw); err != nil {
+		return err
+	}
+	bw.WriteString("\r\n")
+	return bw.Flush()
+}
+
+// proxyTunnel 在两个连接之间双向复制数据，直到任意一方关闭或空闲超时
+func proxyTunnel(client net.Conn, backend net.Conn, idleTimeout time.Duration) error {
+	touch := func() {
+		if idleTimeout <= 0 {
+			return
+		}
+		deadline := time.Now().Add(idleTimeout)
+		client.SetDeadline(deadline)
+		backend.SetDeadline(deadline)
+	}
+	touch()
+	errCh := make(chan error, 2)
+	cp := func(dst net.Conn, src net.Conn) {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := src.Read(buf)
+			if n > 0 {
+				touch()
+				if _, werr := dst.Write(buf[:n]); werr != nil {
+					errCh <- werr
+					return
+				}
+			}
+			if err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}
+	go cp(backend, client)
+	go cp(client, backend)
+	err := <-errCh
+	client.Close()
+	backend.Close()
+	<-errCh
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// proxyForwarded 生成 RFC 7239 Forwarded 请求头的一个元素
+func proxyForwarded(c echo.Context) string {
+	ip := proxyRemoteIP(c)
+	if len(ip) == 0 {
+		ip = `unknown`
+	} else if strings.Contains(ip, `:`) {
+		ip = `[` + ip + `]`
+	}
+	var b strings.Builder
+	b.WriteString(`for=`)
+	b.WriteString(proxyForwardedValue(ip))
+	if host := c.Request().Host(); len(host) > 0 {
+		b.WriteString(`;host=`)
+		b.WriteString(proxyForwardedValue(host))
+	}
+	b.WriteString(`;proto=`)
+	b.WriteString(c.Scheme())
+	return b.String()
+}
+
+// proxyForwardedValue 不是 token 的值需要加引号
+func proxyForwardedValue(v string) string {
+	for _, r := range v {
+		if !isProxyTokenChar(r) {
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+		}
+	}
+	return v
+}
+
+func isProxyTokenChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
+
+// proxyRemoteIP 直接连接的客户端 IP
+func proxyRemoteIP(c echo.Context) string {
+	addr := c.Request().RemoteAddress()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// proxyAppendHeader 追加到已有的值之后(以逗号分隔)
+func proxyAppendHeader(c echo.Context, key string, value string) {
+	header := c.Request().Header()
+	if prior := header.Get(key); len(prior) > 0 {
+		value = prior + `, ` + value
+	}
+	header.Set(key, value)
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
+	"github.com/webx-top/echo/engine/fasthttp"
+	"github.com/webx-top/echo/engine/standard"
+)
+
+// newUpgradeBackend 返回一个接受 `Upgrade: echo-test` 升级请求并原样返回收到的数据的服务器
+func newUpgradeBackend(headers chan<- http.Header) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if headers != nil {
+			headers <- r.Header.Clone()
+		}
+		if r.Header.Get(echo.HeaderUpgrade) != `echo-test` {
+			io.WriteString(w, `plain:`+r.URL.RequestURI())
+			return
+		}
+		if r.URL.Path == `/reject` {
+			http.Error(w, `rejected`, http.StatusForbidden)
+			return
+		}
+		conn, bufrw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		bufrw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo-test\r\n\r\n")
+		bufrw.Flush()
+		io.Copy(conn, bufrw)
+	})
+}
+
+func startProxyServer(t *testing.T, eng string, config ProxyConfig) (string, func()) {
+	ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	require.NoError(t, err)
+	e := echo.New()
+	e.Use(ProxyWithConfig(config))
+	var server engine.Engine
+	if eng == `fasthttp` {
+		server = fasthttp.NewWithConfig(&engine.Config{Address: ln.Addr().String(), Listener: ln})
+	} else {
+		server = standard.NewWithConfig(&engine.Config{Address: ln.Addr().String(), Listener: ln})
+	}
+	go e.Run(server)
+	return ln.Addr().String(), func() {
+		e.Stop()
+	}
+}
+
+func dialUpgrade(t *testing.T, addr string, path string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial(`tcp`, addr)
+	require.NoError(t, err)
+	_, err = io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: front.example.com\r\nConnection: Upgrade\r\nUpgrade: echo-test\r\n\r\n")
+	require.NoError(t, err)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	return conn, br, resp
+}
+
+func TestProxyUpgrade(t *testing.T) {
+	for _, eng := range []string{`standard`, `fasthttp`} {
+		t.Run(eng, func(t *testing.T) {
+			headers := make(chan http.Header, 10)
+			backend := httptest.NewServer(newUpgradeBackend(headers))
+			defer backend.Close()
+			u, _ := url.Parse(backend.URL)
+			config := DefaultProxyConfig
+			config.Balancer = NewRoundRobinBalancer([]ProxyTargeter{&ProxyTarget{Name: `backend`, URL: u, IdleTimeout: 200 * time.Millisecond}})
+			config.Forwarded = true
+			config.HeaderRewriter = func(c echo.Context, t ProxyTargeter, header engine.Header) {
+				header.Set(`X-Proxy-Target`, t.GetName())
+			}
+			addr, stop := startProxyServer(t, eng, config)
+			defer stop()
+
+			conn, br, resp := dialUpgrade(t, addr, `/ws?room=1`)
+			defer conn.Close()
+			assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+			assert.Equal(t, `echo-test`, resp.Header.Get(echo.HeaderUpgrade))
+			header := <-headers
+			assert.Equal(t, `127.0.0.1`, header.Get(echo.HeaderXForwardedFor))
+			assert.Equal(t, `for=127.0.0.1;host=front.example.com;proto=http`, header.Get(echo.HeaderForwarded))
+			assert.Equal(t, `front.example.com`, header.Get(echo.HeaderXForwardedHost))
+			assert.Equal(t, `backend`, header.Get(`X-Proxy-Target`))
+
+			for _, msg := range []string{`ping`, `pong`} {
+				_, err := io.WriteString(conn, msg)
+				require.NoError(t, err)
+				buf := make([]byte, len(msg))
+				_, err = io.ReadFull(br, buf)
+				require.NoError(t, err)
+				assert.Equal(t, msg, string(buf))
+			}
+
+			// 空闲超时后关闭隧道
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			_, err := br.ReadByte()
+			assert.ErrorIs(t, err, io.EOF)
+
+			// 目标拒绝升级时按普通响应转发
+			conn2, br2, resp := dialUpgrade(t, addr, `/reject`)
+			defer conn2.Close()
+			<-headers
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			body := make([]byte, len("rejected\n"))
+			io.ReadFull(br2, body)
+			assert.Equal(t, "rejected\n", string(body))
+		})
+	}
+}
+
+func TestProxyTargetTLSConfig(t *testing.T) {
+	backend := httptest.NewTLSServer(newUpgradeBackend(nil))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+	tlsConfig := backend.Client().Transport.(*http.Transport).TLSClientConfig
+	config := DefaultProxyConfig
+	config.Balancer = NewRoundRobinBalancer([]ProxyTargeter{&ProxyTarget{Name: `backend`, URL: u, TLSConfig: tlsConfig}})
+	addr, stop := startProxyServer(t, `standard`, config)
+	defer stop()
+
+	resp, err := http.Get(`http://` + addr + `/plain?a=1`)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `plain:/plain?a=1`, string(body))
+
+	conn, br, resp := dialUpgrade(t, addr, `/wss`)
+	defer conn.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	io.WriteString(conn, `secure`)
+	buf := make([]byte, 6)
+	_, err = io.ReadFull(br, buf)
+	require.NoError(t, err)
+	assert.Equal(t, `secure`, string(buf))
+	assert.True(t, strings.HasPrefix(backend.URL, `https://`))
+}
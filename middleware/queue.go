@@ -21,6 +21,10 @@ type (
 
 		QueueTimeout  time.Duration
 		WorkerTimeout time.Duration
+
+		// Metrics 不为空时记录排队中(queue_waiting)和处理中(queue_processing)的请求数，以 Name 作为 queue 标签的值
+		Metrics *MetricsRegistry
+		Name    string
 	}
 )
 
@@ -76,6 +80,14 @@ func QueueWithConfig(config QueueConfig) echo.MiddlewareFunc {
 	queueSemaphore := semaphore.NewWeighted(int64(config.QueueSize))
 	workersSemaphore := semaphore.NewWeighted(int64(config.Workers))
 
+	var waiting, processing *MetricsGauge
+	if config.Metrics != nil {
+		waiting = config.Metrics.Gauge(`queue_waiting`, `Number of requests waiting for a worker.`, `queue`)
+		processing = config.Metrics.Gauge(`queue_processing`, `Number of requests being processed by workers.`, `queue`)
+		waiting.Set(0, config.Name)
+		processing.Set(0, config.Name)
+	}
+
 	return func(next echo.Handler) echo.Handler {
 		return echo.HandlerFunc(func(c echo.Context) error {
 			if config.Skipper(c) {
@@ -101,7 +113,14 @@ func QueueWithConfig(config QueueConfig) echo.MiddlewareFunc {
 				queueCancel()
 			}()
 
-			if err := workersSemaphore.Acquire(ctxWorker, 1); err != nil {
+			if waiting != nil {
+				waiting.Inc(config.Name)
+			}
+			err := workersSemaphore.Acquire(ctxWorker, 1)
+			if waiting != nil {
+				waiting.Dec(config.Name)
+			}
+			if err != nil {
 				queueSemaphore.Release(1)
 
 				if errors.Is(err, context.DeadlineExceeded) {
@@ -111,6 +130,11 @@ func QueueWithConfig(config QueueConfig) echo.MiddlewareFunc {
 				return err
 			}
 
+			if processing != nil {
+				processing.Inc(config.Name)
+				defer processing.Dec(config.Name)
+			}
+
 			defer func() {
 				workersSemaphore.Release(1)
 				queueSemaphore.Release(1)
@@ -48,7 +48,9 @@ type Config struct {
 	DefaultRenderer func(c echo.Context, data echo.H, code int) ([]byte, error)
 	// UsingDefaultRenderer is a function that can be used to determine if the default renderer should be used.
 	UsingDefaultRenderer func(echo.Context) bool
-	errorPageFuncSetter  []echo.HandlerFunc
+	// Problem 不为 nil 时，对于接受 JSON 格式的请求以 RFC 9457 问题详情(application/problem+json)格式输出错误
+	Problem             *echo.ProblemConfig
+	errorPageFuncSetter []echo.HandlerFunc
 }
 
 var DefaultFuncMapSkipper = func(c echo.Context) bool {
@@ -138,6 +140,7 @@ func (t *Config) HTTPErrorHandler() echo.HTTPErrorHandler {
 		DefaultHTTPErrorCode: t.DefaultHTTPErrorCode,
 		DefaultRenderer:      t.DefaultRenderer,
 		UsingDefaultRenderer: t.UsingDefaultRenderer,
+		Problem:              t.Problem,
 	}
 	opt.SetFuncSetter(t.errorPageFuncSetter...)
 	return HTTPErrorHandler(opt)
@@ -46,6 +46,7 @@ type Options struct {
 	SetFuncMap           []echo.HandlerFunc
 	DefaultRenderer      func(c echo.Context, data echo.H, code int) ([]byte, error)
 	UsingDefaultRenderer func(echo.Context) bool
+	Problem              *echo.ProblemConfig // 以 RFC 9457 问题详情格式输出 JSON 错误
 }
 
 func (opt *Options) AddFuncSetter(set ...echo.HandlerFunc) *Options {
@@ -72,6 +73,12 @@ func (opt *Options) SetErrorProcessor(h ...ErrorProcessor) *Options {
 	return opt
 }
 
+// SetProblem 对于接受 JSON 格式的请求以 RFC 9457 问题详情格式输出错误
+func (opt *Options) SetProblem(cfg *echo.ProblemConfig) *Options {
+	opt.Problem = cfg
+	return opt
+}
+
 func (opt *Options) SetDefaultRender(renderer func(c echo.Context, data echo.H, code int) ([]byte, error)) *Options {
 	opt.DefaultRenderer = renderer
 	return opt
@@ -198,6 +205,12 @@ func HTTPErrorHandler(opt *Options) echo.HTTPErrorHandler {
 				break
 			}
 		}
+		if opt.Problem != nil && opt.Problem.Accepts(c) {
+			if writeErr := opt.Problem.Write(c, err); writeErr != nil {
+				c.Logger().Error(writeErr)
+			}
+			return
+		}
 		var links echo.KVList
 		if v, y := c.Get(`links`).(echo.KVList); y {
 			links = v
@@ -54,6 +54,7 @@ func RequestIDWithConfig(config RequestIDConfig) echo.MiddlewareFuncd {
 				rid = config.Generator()
 			}
 			c.Response().Header().Set(echo.HeaderXRequestID, rid)
+			c.Object().WithLogFields(`request_id`, rid)
 			if config.RequestIDHandler != nil {
 				config.RequestIDHandler(c, rid)
 			}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/logger"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func TestRequestIDLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.SetLogger(logger.NewSlogLogger(slog.New(slog.NewJSONHandler(buf, nil))))
+	e.Use(RequestIDWithConfig(RequestIDConfig{Generator: func() string { return `rid-1` }}))
+	e.Get(`/`, func(c echo.Context) error {
+		c.Object().WithLogFields(`route`, c.Route().Name)
+		c.Logger().Info(`in handler`)
+		return c.String(`ok`)
+	}).SetName(`home`)
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, `rid-1`, rec.Header().Get(echo.HeaderXRequestID))
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, `in handler`, entry[`msg`])
+	assert.Equal(t, `rid-1`, entry[`request_id`])
+	assert.Equal(t, `home`, entry[`route`])
+
+	// Context 重置后不再保留上一个请求的字段
+	c := e.NewContext(myTesting.NewRequestAndResponse(http.MethodGet, `/`))
+	c.Object().WithLogFields(`request_id`, `rid-2`)
+	assert.NotSame(t, e.Logger(), c.Logger())
+	c.Reset(myTesting.NewRequestAndResponse(http.MethodGet, `/`))
+	assert.Same(t, e.Logger(), c.Logger())
+}
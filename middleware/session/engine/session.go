@@ -99,6 +99,14 @@ func (s *Session) ID() string {
 	return s.Session().ID
 }
 
+// LoadedID 已加载的会话 ID。会话尚未加载时返回空字符串(不会读取会话存储)
+func (s *Session) LoadedID() string {
+	if s.session == nil {
+		return ``
+	}
+	return s.session.ID
+}
+
 func (s *Session) MustID() string {
 	if len(s.Session().ID) > 0 {
 		return s.Session().ID
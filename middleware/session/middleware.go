@@ -16,9 +16,12 @@ limitations under the License.
 package session
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/logger"
 )
 
 const (
@@ -50,7 +53,10 @@ func RememberExpires(c echo.Context, expires time.Time) {
 }
 
 func saveSession(c echo.Context) {
-	if err := c.Session().Save(); err != nil {
+	end := c.Object().StartSpan(echo.SpanNameSessionSave)
+	err := c.Session().Save()
+	end(err)
+	if err != nil {
 		c.Logger().Error(err)
 	}
 }
@@ -75,6 +81,9 @@ func Sessions(options *echo.SessionOptions, setOpts ...func(echo.Context, *echo.
 		return func(c echo.Context) error {
 			s := newSession(c)
 			c.SetSessioner(s)
+			c.Object().WithLogFields(`session`, logger.Valuer(func() any {
+				return sessionLogID(s)
+			}))
 			s.SetPreSaveHook(func(c echo.Context) error {
 				switch v := s.Get(CookieMaxAgeKey).(type) {
 				case int:
@@ -95,6 +104,21 @@ func Sessions(options *echo.SessionOptions, setOpts ...func(echo.Context, *echo.
 	}
 }
 
+// sessionLogID 日志中使用会话 ID 的摘要，避免泄露会话 ID。
+// 会话尚未加载(例如处理函数没有使用会话)时为空，输出日志时不会读取会话存储
+func sessionLogID(s echo.Sessioner) string {
+	loaded, ok := s.(interface{ LoadedID() string })
+	if !ok {
+		return ``
+	}
+	id := loaded.LoadedID()
+	if len(id) == 0 {
+		return ``
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:6])
+}
+
 func Middleware(options *echo.SessionOptions, setOpts ...func(echo.Context, *echo.SessionOptions)) echo.MiddlewareFuncd {
 	if options == nil {
 		options = DefaultSessionOptions()
@@ -1,14 +1,21 @@
 package session_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"testing"
 
+	"github.com/admpub/sessions"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/logger"
 	"github.com/webx-top/echo/middleware/session"
+	ss "github.com/webx-top/echo/middleware/session/engine"
 	test "github.com/webx-top/echo/testing"
 )
 
@@ -52,3 +59,58 @@ func TestSession(t *testing.T) {
 		assert.Equal(t, strconv.Itoa(i)+`:test-`+strconv.Itoa(i), resp)
 	}
 }
+
+type countingStore struct {
+	sessions.Store
+	gets int
+}
+
+func (s *countingStore) Get(ctx echo.Context, name string) (*sessions.Session, error) {
+	s.gets++
+	sess := sessions.NewSession(s, name)
+	sess.ID = `sid-1`
+	return sess, nil
+}
+
+func (s *countingStore) Save(ctx echo.Context, sess *sessions.Session) error {
+	return nil
+}
+
+func TestSessionLogField(t *testing.T) {
+	store := &countingStore{}
+	ss.Reg(`counting`, store)
+	defer ss.Del(`counting`)
+
+	buf := new(bytes.Buffer)
+	e := echo.New()
+	e.SetLogger(logger.NewSlogLogger(slog.New(slog.NewJSONHandler(buf, nil))))
+	options := session.DefaultSessionOptions()
+	options.Engine = `counting`
+	e.Use(session.Middleware(options))
+	e.Get(`/log`, func(ctx echo.Context) error {
+		ctx.Logger().Info(`no session`)
+		return ctx.String(`ok`)
+	})
+	e.Get(`/session`, func(ctx echo.Context) error {
+		ctx.Session().Get(`count`)
+		ctx.Logger().Info(`with session`)
+		return ctx.String(`ok`)
+	})
+	e.RebuildRouter()
+
+	// 没有使用会话时记录日志不会读取会话存储
+	code, _, _ := request(`GET`, `/log`, e)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 0, store.gets)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, ``, entry[`session`])
+
+	buf.Reset()
+	code, _, _ = request(`GET`, `/session`, e)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, 1, store.gets)
+	entry = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Len(t, entry[`session`], 12)
+}
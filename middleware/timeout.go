@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/webx-top/echo"
+)
+
+type (
+	// TimeoutConfig defines the config for Timeout middleware.
+	TimeoutConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// Timeout 处理超时时间。小于等于 0 时不限制。
+		// 可以通过路由 Meta 中键名为 MetaKey 的值覆盖，例如：SetMetaKV("timeout", "30s")
+		Timeout time.Duration `json:"timeout"`
+
+		// StatusCode 超时后的响应状态码(503 或 504)。默认为 503
+		StatusCode int `json:"statusCode"`
+
+		// ErrorMessage 超时后的错误信息。默认为状态码对应的文本
+		ErrorMessage string `json:"errorMessage"`
+
+		// MetaKey 路由 Meta 中指定超时时间的键名。默认为 timeout
+		MetaKey string `json:"metaKey"`
+	}
+)
+
+var (
+	// DefaultTimeoutConfig is the default Timeout middleware config.
+	DefaultTimeoutConfig = TimeoutConfig{
+		Skipper:    echo.DefaultSkipper,
+		StatusCode: http.StatusServiceUnavailable,
+		MetaKey:    `timeout`,
+	}
+)
+
+// Timeout returns a middleware which cancels the handler's context after the timeout
+// and responds with "503 - Service Unavailable".
+func Timeout(timeout time.Duration) echo.MiddlewareFunc {
+	config := DefaultTimeoutConfig
+	config.Timeout = timeout
+	return TimeoutWithConfig(config)
+}
+
+// TimeoutWithConfig returns a Timeout middleware with config.
+// See: `Timeout()`.
+//
+// 处理函数在新的 goroutine 中执行，其响应会被缓存，在超时前完成时才会输出。
+// 超时后通过 HTTPErrorHandler 输出错误响应，并等待处理函数结束(处理函数应当在 Context 的 Done() 关闭后尽快返回)，
+// 处理函数之后的写入会返回 http.ErrHandlerTimeout。不适用于 Context.Stream、WebSocket 等流式响应
+func TimeoutWithConfig(config TimeoutConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultTimeoutConfig.Skipper
+	}
+	if config.StatusCode == 0 {
+		config.StatusCode = DefaultTimeoutConfig.StatusCode
+	}
+	if len(config.ErrorMessage) == 0 {
+		config.ErrorMessage = http.StatusText(config.StatusCode)
+	}
+	if len(config.MetaKey) == 0 {
+		config.MetaKey = DefaultTimeoutConfig.MetaKey
+	}
+	return func(next echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			timeout := config.timeout(c)
+			if timeout <= 0 {
+				return next.Handle(c)
+			}
+			xc := c.Object()
+			parent := xc.StdContext()
+			ctx, cancel := context.WithTimeout(parent, timeout)
+			defer cancel()
+			resp := c.Response()
+			tw := newBufferedResponse(resp, c.Request(), ctx)
+			xc.SetStdContext(ctx)
+			xc.SetResponse(tw)
+			restore := func() {
+				xc.SetResponse(resp)
+				xc.SetStdContext(parent)
+			}
+
+			done := make(chan error, 1)
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						panicked <- r
+					}
+				}()
+				done <- next.Handle(c)
+			}()
+
+			var finished bool
+			select {
+			case err := <-done:
+				if ctx.Err() != context.DeadlineExceeded {
+					restore()
+					if ferr := tw.flushTo(resp); ferr != nil && err == nil {
+						err = ferr
+					}
+					return err
+				}
+				// 处理函数在超时之后才结束，其响应已被丢弃
+				finished = true
+			case r := <-panicked:
+				restore()
+				panic(r)
+			case <-ctx.Done():
+			}
+
+			tw.expire()
+			// 使用新的 Context 输出错误响应，避免与仍在运行的处理函数产生竞争
+			ew := newBufferedResponse(resp, c.Request(), context.WithoutCancel(parent))
+			ec := c.Echo().NewContext(c.Request(), ew)
+			err := echo.NewHTTPError(config.StatusCode, config.ErrorMessage).SetRaw(ctx.Err())
+			c.Echo().HTTPErrorHandler()(err, ec)
+			if ferr := ew.flushTo(resp); ferr != nil {
+				c.Logger().Error(`timeout: `, ferr)
+			}
+			if flusher, ok := resp.(http.Flusher); ok {
+				flusher.Flush()
+			}
+
+			// 等待处理函数结束，以免 Context 被回收后仍在使用
+			if !finished {
+				select {
+				case <-done:
+				case r := <-panicked:
+					c.Logger().Errorf(`timeout: handler panicked after timeout: %v`, r)
+				}
+			}
+			restore()
+			return nil
+		})
+	}
+}
+
+// timeout 获取超时时间(优先使用路由 Meta 中的设置)
+func (config *TimeoutConfig) timeout(c echo.Context) time.Duration {
+	switch v := c.Route().Get(config.MetaKey).(type) {
+	case nil:
+	case time.Duration:
+		return v
+	case string:
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			return d
+		}
+		c.Logger().Warnf(`timeout: invalid route meta %s=%q: %v`, config.MetaKey, v, err)
+	case int:
+		return time.Duration(v) * time.Second
+	case int64:
+		return time.Duration(v) * time.Second
+	case float64:
+		return time.Duration(v * float64(time.Second))
+	default:
+		c.Logger().Warnf(`timeout: unsupported route meta %s=%v`, config.MetaKey, fmt.Sprint(v))
+	}
+	return config.Timeout
+}
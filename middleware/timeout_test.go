@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/webx-top/echo"
+	myTesting "github.com/webx-top/echo/testing"
+)
+
+func TestTimeout(t *testing.T) {
+	e := echo.New()
+	e.Use(Timeout(50 * time.Millisecond))
+	lateErr := make(chan error, 1)
+	e.Get(`/fast`, func(c echo.Context) error {
+		c.Response().Header().Set(`X-Fast`, `1`)
+		return c.String(`fast`, http.StatusCreated)
+	})
+	e.Get(`/slow`, func(c echo.Context) error {
+		<-c.Done()
+		assert.ErrorIs(t, c.Err(), context.DeadlineExceeded)
+		err := c.String(`late`)
+		lateErr <- err
+		return err
+	})
+	e.Get(`/override`, func(c echo.Context) error {
+		select {
+		case <-c.Done():
+			return c.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+		return c.String(`override`)
+	}).SetMetaKV(`timeout`, `1s`)
+	e.Get(`/error`, func(c echo.Context) error {
+		return echo.ErrForbidden
+	})
+	e.Get(`/panic`, func(c echo.Context) error {
+		panic(`boom`)
+	})
+	e.RebuildRouter()
+
+	rec := myTesting.Request(http.MethodGet, `/fast`, e)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `fast`, rec.Body.String())
+	assert.Equal(t, `1`, rec.Header().Get(`X-Fast`))
+
+	rec = myTesting.Request(http.MethodGet, `/slow`, e)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, http.StatusText(http.StatusServiceUnavailable), rec.Body.String())
+	assert.ErrorIs(t, <-lateErr, http.ErrHandlerTimeout)
+
+	rec = myTesting.Request(http.MethodGet, `/override`, e)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `override`, rec.Body.String())
+
+	rec = myTesting.Request(http.MethodGet, `/error`, e)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	assert.PanicsWithValue(t, `boom`, func() {
+		myTesting.Request(http.MethodGet, `/panic`, e)
+	})
+}
+
+func TestTimeoutLateWrite(t *testing.T) {
+	e := echo.New()
+	e.Use(Timeout(time.Millisecond))
+	e.Get(`/`, func(c echo.Context) error {
+		// 超时后立即写入并返回，与中间件输出超时响应产生竞争
+		<-c.Done()
+		c.Response().Header().Set(`X-Late`, `1`)
+		return c.String(`late`)
+	})
+	e.RebuildRouter()
+	for i := 0; i < 200; i++ {
+		rec := myTesting.Request(http.MethodGet, `/`, e)
+		if !assert.Equal(t, http.StatusServiceUnavailable, rec.Code) ||
+			!assert.Equal(t, http.StatusText(http.StatusServiceUnavailable), rec.Body.String()) ||
+			!assert.Empty(t, rec.Header().Get(`X-Late`)) {
+			return
+		}
+	}
+}
+
+func TestTimeoutStatusCode(t *testing.T) {
+	e := echo.New()
+	e.Use(TimeoutWithConfig(TimeoutConfig{
+		Timeout:      10 * time.Millisecond,
+		StatusCode:   http.StatusGatewayTimeout,
+		ErrorMessage: `upstream timeout`,
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		<-c.Done()
+		return c.Err()
+	})
+	e.RebuildRouter()
+	rec := myTesting.Request(http.MethodGet, `/`, e)
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.Equal(t, `upstream timeout`, rec.Body.String())
+}
@@ -0,0 +1,103 @@
+package echo
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mount 将 http.Handler 或 *Echo 挂载到 prefix 前缀下(以 MethodAny 匹配 prefix 以及 prefix/* 的任意方法的请求)。
+// 请求路径中的前缀会被去掉，并相应地改写 Request.URL 后再交给挂载的 handler 处理。
+// 挂载 *Echo 时默认不经过父级 Echo 通过 Use 添加的中间件(路由组的中间件仍然有效)，inheritMiddleware 为 true 时继承；
+// 挂载 http.Handler 时与普通路由一样经过父级的中间件。
+// 挂载之后添加到 *Echo 中的路由会在下一个请求时自动构建
+func (e *Echo) Mount(prefix string, h any, inheritMiddleware ...bool) IRouter {
+	return mount(e, prefix, h, inheritMiddleware...)
+}
+
+// Mount 将 http.Handler 或 *Echo 挂载到路由组的 prefix 前缀下。详见 Echo.Mount
+func (g *Group) Mount(prefix string, h any, inheritMiddleware ...bool) IRouter {
+	return mount(g, prefix, h, inheritMiddleware...)
+}
+
+func mount(r RouteRegister, prefix string, h any, inheritMiddleware ...bool) IRouter {
+	prefix = strings.TrimSuffix(prefix, `/`)
+	var handler HandlerFunc
+	var isolated bool
+	switch v := h.(type) {
+	case *Echo:
+		handler = mountEcho(v)
+		isolated = len(inheritMiddleware) == 0 || !inheritMiddleware[0]
+	case http.Handler:
+		handler = mountStdHandler(v)
+	case func(http.ResponseWriter, *http.Request):
+		handler = mountStdHandler(http.HandlerFunc(v))
+	default:
+		panic(fmt.Sprintf(`echo: unsupported mount handler type: %T`, h))
+	}
+	paths := []string{prefix + `/*`}
+	if _, ok := r.(*Echo); !ok || len(prefix) > 0 {
+		paths = append(paths, prefix)
+	}
+	routes := Routes{}
+	for _, path := range paths {
+		for _, rt := range r.Match([]string{MethodAny}, path, handler).(Routes) {
+			rt.isolated = isolated
+			routes = append(routes, rt)
+		}
+	}
+	return routes
+}
+
+// mountPath 去掉挂载前缀之后的路径以及被去掉的前缀
+func mountPath(c Context) (path string, prefix string) {
+	rest := c.Param(`*`)
+	path = `/` + rest
+	prefix = strings.TrimSuffix(c.Request().URL().Path(), rest)
+	prefix = strings.TrimSuffix(prefix, `/`)
+	return
+}
+
+func mountEcho(child *Echo) HandlerFunc {
+	return func(c Context) error {
+		child.commitPending()
+		req := c.Request()
+		oldPath, oldURI := req.URL().Path(), req.URI()
+		path, _ := mountPath(c)
+		u := &url.URL{Path: path, RawQuery: req.URL().RawQuery()}
+		req.URL().SetPath(path)
+		req.SetURI(u.RequestURI())
+		defer func() {
+			req.URL().SetPath(oldPath)
+			req.SetURI(oldURI)
+		}()
+		child.serveMounted(c)
+		return nil
+	}
+}
+
+func mountStdHandler(h http.Handler) HandlerFunc {
+	return func(c Context) error {
+		r := c.Request().StdRequest()
+		path, prefix := mountPath(c)
+		r2 := r.WithContext(AsStdContext(c))
+		u := *r.URL
+		u.Path = path
+		u.RawPath = ``
+		if len(r.URL.RawPath) > 0 {
+			escapedPrefix := (&url.URL{Path: prefix}).EscapedPath()
+			if rawPath, ok := strings.CutPrefix(r.URL.RawPath, escapedPrefix); ok {
+				u.RawPath = `/` + strings.TrimPrefix(rawPath, `/`)
+			}
+		}
+		r2.URL = &u
+		r2.RequestURI = u.RequestURI()
+		r2.Header = r.Header.Clone()
+		r2.Header.Set(HeaderXForwardedPrefix, prefix)
+		r2.Header.Set(HeaderXForwardedProto, c.Scheme())
+		r2.Header.Set(HeaderXRealIP, c.RealIP())
+		h.ServeHTTP(c.Response().StdResponseWriter(), r2)
+		return nil
+	}
+}
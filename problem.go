@@ -0,0 +1,280 @@
+package echo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	pkgCode "github.com/webx-top/echo/code"
+)
+
+// ==========================================
+// Problem Details (RFC 9457)
+// ==========================================
+
+type (
+	// Problem RFC 9457 问题详情
+	Problem struct {
+		Type          string
+		Title         string
+		Status        int
+		Detail        string
+		Instance      string
+		InvalidParams []*InvalidParam
+		Extensions    H // 扩展成员
+	}
+
+	// InvalidParam 校验失败的参数
+	InvalidParam struct {
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	}
+
+	// ProblemType 问题类型
+	ProblemType struct {
+		Type  string // URI
+		Title string
+	}
+
+	// InvalidParamsGetter 可提供多个校验失败参数的错误
+	InvalidParamsGetter interface {
+		InvalidParams() []*InvalidParam
+	}
+
+	// ProblemConfig 问题详情的生成配置
+	ProblemConfig struct {
+		// CodeTypes 业务状态码(code.Code)对应的问题类型
+		CodeTypes map[pkgCode.Code]ProblemType
+
+		// StatusTypes HTTP 状态码对应的问题类型
+		StatusTypes map[int]ProblemType
+
+		// Instance 生成 instance 字段(默认使用 X-Request-ID)
+		Instance func(Context, *Problem) string
+
+		// Customize 自定义问题详情
+		Customize func(Context, error, *Problem)
+	}
+)
+
+// reservedProblemMembers 扩展成员不能覆盖的字段
+var reservedProblemMembers = map[string]struct{}{
+	`type`: {}, `title`: {}, `status`: {}, `detail`: {}, `instance`: {}, `invalid-params`: {},
+}
+
+// MarshalJSON 扩展成员与标准成员位于同一层级
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+6)
+	for k, v := range p.Extensions {
+		if _, ok := reservedProblemMembers[k]; !ok {
+			m[k] = v
+		}
+	}
+	if len(p.Type) > 0 {
+		m[`type`] = p.Type
+	}
+	if len(p.Title) > 0 {
+		m[`title`] = p.Title
+	}
+	if p.Status > 0 {
+		m[`status`] = p.Status
+	}
+	if len(p.Detail) > 0 {
+		m[`detail`] = p.Detail
+	}
+	if len(p.Instance) > 0 {
+		m[`instance`] = p.Instance
+	}
+	if len(p.InvalidParams) > 0 {
+		m[`invalid-params`] = p.InvalidParams
+	}
+	return json.Marshal(m)
+}
+
+// Error returns detail.
+func (p *Problem) Error() string {
+	if len(p.Detail) > 0 {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// Set 设置扩展成员
+func (p *Problem) Set(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = H{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// DefaultProblemInstance 使用请求 ID(X-Request-ID)作为 instance
+func DefaultProblemInstance(c Context, _ *Problem) string {
+	rid := c.Response().Header().Get(HeaderXRequestID)
+	if len(rid) == 0 {
+		rid = c.Header(HeaderXRequestID)
+	}
+	if len(rid) == 0 {
+		return ``
+	}
+	return `urn:request-id:` + rid
+}
+
+// NewProblemConfig 创建问题详情配置
+func NewProblemConfig() *ProblemConfig {
+	return &ProblemConfig{
+		CodeTypes:   map[pkgCode.Code]ProblemType{},
+		StatusTypes: map[int]ProblemType{},
+		Instance:    DefaultProblemInstance,
+	}
+}
+
+// SetCodeType 设置业务状态码对应的问题类型
+func (p *ProblemConfig) SetCodeType(code pkgCode.Code, typ string, title string) *ProblemConfig {
+	if p.CodeTypes == nil {
+		p.CodeTypes = map[pkgCode.Code]ProblemType{}
+	}
+	p.CodeTypes[code] = ProblemType{Type: typ, Title: title}
+	return p
+}
+
+// SetStatusType 设置 HTTP 状态码对应的问题类型
+func (p *ProblemConfig) SetStatusType(status int, typ string, title string) *ProblemConfig {
+	if p.StatusTypes == nil {
+		p.StatusTypes = map[int]ProblemType{}
+	}
+	p.StatusTypes[status] = ProblemType{Type: typ, Title: title}
+	return p
+}
+
+// Accepts 客户端是否接受问题详情(JSON)格式
+func (p *ProblemConfig) Accepts(c Context) bool {
+	return c.Format() == ContentTypeJSON
+}
+
+// Problem 根据错误生成问题详情
+func (p *ProblemConfig) Problem(c Context, err error) *Problem {
+	pb := &Problem{Status: http.StatusInternalServerError}
+	var typ *ProblemType
+	switch e := err.(type) {
+	case *Problem:
+		cp := *e
+		pb = &cp
+	case *HTTPError:
+		pb.Status = e.Code
+		pb.Detail = e.Message
+	case *Error:
+		pb.Status = e.Code.HTTPCode()
+		pb.Detail = e.Message
+		pb.Set(`code`, e.Code.Int())
+		for k, v := range e.Extra {
+			pb.Set(k, v)
+		}
+		if len(e.Zone) > 0 {
+			pb.InvalidParams = append(pb.InvalidParams, &InvalidParam{Name: e.Zone, Reason: e.Message})
+		}
+		if v, ok := p.CodeTypes[e.Code]; ok {
+			typ = &v
+		}
+	case ValidateResult:
+		pb.Status = http.StatusBadRequest
+		pb.Detail = e.Error()
+		if len(e.Field()) > 0 {
+			pb.InvalidParams = append(pb.InvalidParams, &InvalidParam{Name: e.Field(), Reason: e.Error()})
+		}
+	case *PanicError:
+		if c.Echo().Debug() {
+			pb.Detail = e.Error()
+		}
+	default:
+		var he *HTTPError
+		if errors.As(err, &he) {
+			return p.Problem(c, he)
+		}
+		var ee *Error
+		if errors.As(err, &ee) {
+			return p.Problem(c, ee)
+		}
+		if c.Echo().Debug() {
+			pb.Detail = err.Error()
+		}
+	}
+	var getter InvalidParamsGetter
+	if errors.As(err, &getter) {
+		pb.InvalidParams = getter.InvalidParams()
+	}
+	if pb.Status <= 0 {
+		pb.Status = http.StatusInternalServerError
+	}
+	if typ == nil {
+		if v, ok := p.StatusTypes[pb.Status]; ok {
+			typ = &v
+		}
+	}
+	if typ != nil {
+		if len(pb.Type) == 0 {
+			pb.Type = typ.Type
+		}
+		if len(pb.Title) == 0 {
+			pb.Title = typ.Title
+		}
+	}
+	if len(pb.Type) == 0 {
+		pb.Type = `about:blank`
+	}
+	if len(pb.Title) == 0 {
+		pb.Title = http.StatusText(pb.Status)
+	}
+	if pb.Detail == pb.Title {
+		pb.Detail = ``
+	}
+	if len(pb.Instance) == 0 && p.Instance != nil {
+		pb.Instance = p.Instance(c, pb)
+	}
+	if p.Customize != nil {
+		p.Customize(c, err, pb)
+	}
+	return pb
+}
+
+// Write 输出问题详情
+func (p *ProblemConfig) Write(c Context, err error) error {
+	pb := p.Problem(c, err)
+	if c.Request().Method() == HEAD {
+		return c.NoContent(pb.Status)
+	}
+	b, jsonErr := json.Marshal(pb)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	c.Response().Header().Set(HeaderContentType, MIMEApplicationProblemJSON)
+	return c.Blob(b, pb.Status)
+}
+
+// ProblemHTTPErrorHandler 返回以问题详情(application/problem+json)格式输出错误的 HTTPErrorHandler。
+// 客户端不接受 JSON 格式(例如浏览器访问页面)时使用 fallback 处理(默认为 Echo.DefaultHTTPErrorHandler)
+func ProblemHTTPErrorHandler(cfg *ProblemConfig, fallback ...HTTPErrorHandler) HTTPErrorHandler {
+	if cfg == nil {
+		cfg = DefaultProblemConfig
+	}
+	var next HTTPErrorHandler
+	if len(fallback) > 0 {
+		next = fallback[0]
+	}
+	return func(err error, c Context) {
+		if !cfg.Accepts(c) {
+			if next != nil {
+				next(err, c)
+			} else {
+				c.Echo().DefaultHTTPErrorHandler(err, c)
+			}
+			return
+		}
+		if !c.Response().Committed() {
+			if writeErr := cfg.Write(c, err); writeErr != nil {
+				c.Logger().Error(writeErr)
+			}
+		}
+		c.Logger().Debug(err, `: `, c.Request().URL().String())
+	}
+}
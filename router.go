@@ -31,6 +31,8 @@ type (
 		routes []*Route
 		nroute map[string][]int
 		echo   *Echo
+
+		diagnostics RouteDiagnostics
 	}
 
 	Rewriter interface {
@@ -50,6 +52,9 @@ type (
 		handler    any   //原始handler
 		middleware []any //中间件
 		group      *Group
+		isolated   bool // 不使用父级 Echo 通过 Use 添加的中间件(见 Echo.Mount)
+
+		paramPatterns map[string]*regexp.Regexp // 参数约束
 	}
 
 	Routes []*Route
@@ -69,7 +74,7 @@ type (
 		pnames         []string
 		methodHandler  *methodHandler
 		regExp         *regexp.Regexp
-		regexChild     *node
+		regexChildren  children // 同一位置不同正则表达式的参数节点(按注册顺序匹配)
 		paramChild     *node
 		anyChild       *node
 		// isLeaf indicates that node does not have child routes
@@ -89,6 +94,11 @@ type (
 		post    *endpoint
 		put     *endpoint
 		trace   *endpoint
+		others  map[string]*endpoint // 其它方法(例如 WebDAV 的 PROPFIND/MKCOL/LOCK 或自定义的 PURGE)
+		any     *endpoint            // 任意方法(MethodAny)
+
+		allowHeader       string
+		notAllowedHandler Handler
 	}
 )
 
@@ -235,6 +245,11 @@ func (r *Route) GetMeta() H {
 	return r.Meta
 }
 
+// RawHandler 返回注册时传入的原始 handler
+func (r *Route) RawHandler() any {
+	return r.handler
+}
+
 func (r *Route) IsZero() bool {
 	return r.Handler == nil
 }
@@ -327,15 +342,73 @@ func (r *Route) GetStore(names ...string) H {
 	return res
 }
 
+// MakeURIWithContext 生成网址。参数不符合路由参数约束时返回空字符串
 func (r *Route) MakeURIWithContext(c Context, params ...any) string {
+	uri, err := r.makeURI(c.Echo(), c, params...)
+	if err != nil {
+		c.Logger().Warn(err)
+	}
+	return uri
+}
+
+// MakeURI 生成网址。参数不符合路由参数约束时返回空字符串
+func (r *Route) MakeURI(e *Echo, params ...any) string {
+	uri, err := r.makeURI(e, nil, params...)
+	if err != nil {
+		e.Logger().Warn(err)
+	}
+	return uri
+}
+
+// BuildURIWithContext 与 MakeURIWithContext 相同，但在参数不符合约束时返回错误
+func (r *Route) BuildURIWithContext(c Context, params ...any) (string, error) {
 	return r.makeURI(c.Echo(), c, params...)
 }
 
-func (r *Route) MakeURI(e *Echo, params ...any) (uri string) {
+// BuildURI 与 MakeURI 相同，但在参数不符合约束时返回错误
+func (r *Route) BuildURI(e *Echo, params ...any) (string, error) {
 	return r.makeURI(e, nil, params...)
 }
 
-func (r *Route) makeURI(e *Echo, c Context, params ...any) (uri string) {
+// ParamPattern 路由参数约束的正则表达式(不含首尾的定位符)
+func (r *Route) ParamPattern(name string) (string, bool) {
+	re, ok := r.paramPatterns[name]
+	if !ok {
+		return ``, false
+	}
+	pattern := strings.TrimPrefix(re.String(), `^(?:`)
+	return strings.TrimSuffix(pattern, `)$`), true
+}
+
+// ValidateParams 检查参数值是否符合路由参数约束(values 的顺序与 Params 一致)
+func (r *Route) ValidateParams(values ...any) error {
+	if len(r.paramPatterns) == 0 {
+		return nil
+	}
+	for index, value := range values {
+		if index >= len(r.Params) {
+			break
+		}
+		name := r.Params[index]
+		re, ok := r.paramPatterns[name]
+		if !ok {
+			continue
+		}
+		if !re.MatchString(param.AsString(value)) {
+			return fmt.Errorf(`%w: %s=%v (route: %s)`, ErrInvalidRouteParam, name, value, r.Path)
+		}
+	}
+	return nil
+}
+
+func (r *Route) format(values ...any) (string, error) {
+	if err := r.ValidateParams(values...); err != nil {
+		return ``, err
+	}
+	return fmt.Sprintf(r.Format, values...), nil
+}
+
+func (r *Route) makeURI(e *Echo, c Context, params ...any) (uri string, err error) {
 	length := len(params)
 	var withoutExt bool
 	if length != 1 {
@@ -350,9 +423,12 @@ func (r *Route) makeURI(e *Echo, c Context, params ...any) (uri string) {
 				params = []any{params[1]}
 				goto END
 			}
-			uri = fmt.Sprintf(r.Format, params[1:]...)
+			uri, err = r.format(params[1:]...)
 		} else {
-			uri = fmt.Sprintf(r.Format, params...)
+			uri, err = r.format(params...)
+		}
+		if err != nil {
+			return
 		}
 		uri = e.wrapURI(c, uri, withoutExt)
 		return
@@ -370,7 +446,10 @@ END:
 				values[index] = val.Get(name)
 				val.Del(name)
 			}
-			uri = fmt.Sprintf(r.Format, values...)
+			uri, err = r.format(values...)
+			if err != nil {
+				return
+			}
 		}
 		uri = e.wrapURI(c, uri, withoutExt)
 		q := val.Encode()
@@ -388,7 +467,10 @@ END:
 					delete(val, name)
 				}
 			}
-			uri = fmt.Sprintf(r.Format, values...)
+			uri, err = r.format(values...)
+			if err != nil {
+				return
+			}
 		}
 		uri = e.wrapURI(c, uri, withoutExt)
 		sep := `?`
@@ -412,7 +494,10 @@ END:
 					delete(val, name)
 				}
 			}
-			uri = fmt.Sprintf(r.Format, values...)
+			uri, err = r.format(values...)
+			if err != nil {
+				return
+			}
 		}
 		uri = e.wrapURI(c, uri, withoutExt)
 		sep := `?`
@@ -436,7 +521,10 @@ END:
 					delete(val, name)
 				}
 			}
-			uri = fmt.Sprintf(r.Format, values...)
+			uri, err = r.format(values...)
+			if err != nil {
+				return
+			}
 		}
 		uri = e.wrapURI(c, uri, withoutExt)
 		sep := `?`
@@ -450,10 +538,16 @@ END:
 			sep = `&`
 		}
 	case []any:
-		uri = fmt.Sprintf(r.Format, val...)
+		uri, err = r.format(val...)
+		if err != nil {
+			return
+		}
 		uri = e.wrapURI(c, uri, withoutExt)
 	default:
-		uri = fmt.Sprintf(r.Format, val)
+		uri, err = r.format(val)
+		if err != nil {
+			return
+		}
 		uri = e.wrapURI(c, uri, withoutExt)
 	}
 	return
@@ -471,6 +565,9 @@ func (r *Route) getMiddlewares() []any {
 }
 
 func (r *Route) apply(e *Echo) *Route {
+	if r.handler == nil && r.Handler != nil { // 直接构造的 Route
+		r.handler = r.Handler
+	}
 	handler := e.WrapHandler(r.handler)
 	if len(r.Name) == 0 {
 		if hn, ok := handler.(Name); ok {
@@ -514,7 +611,9 @@ func (m *methodHandler) isHandler() bool {
 		m.patch != nil ||
 		m.post != nil ||
 		m.put != nil ||
-		m.trace != nil
+		m.trace != nil ||
+		len(m.others) > 0 ||
+		m.any != nil
 }
 
 func (m *methodHandler) Map() H {
@@ -546,31 +645,80 @@ func (m *methodHandler) Map() H {
 	if m.trace != nil {
 		r[`trace`] = m.trace.Map()
 	}
+	for method, endpoint := range m.others {
+		r[strings.ToLower(method)] = endpoint.Map()
+	}
+	if m.any != nil {
+		r[MethodAny] = m.any.Map()
+	}
 	return r
 }
 
 func (m *methodHandler) addHandler(method string, h Handler, rid int) {
-	endpoint := &endpoint{handler: h, rid: rid}
+	ep := &endpoint{handler: h, rid: rid}
 	switch method {
 	case GET:
-		m.get = endpoint
+		m.get = ep
 	case POST:
-		m.post = endpoint
+		m.post = ep
 	case PUT:
-		m.put = endpoint
+		m.put = ep
 	case DELETE:
-		m.delete = endpoint
+		m.delete = ep
 	case PATCH:
-		m.patch = endpoint
+		m.patch = ep
 	case OPTIONS:
-		m.options = endpoint
+		m.options = ep
 	case HEAD:
-		m.head = endpoint
+		m.head = ep
 	case CONNECT:
-		m.connect = endpoint
+		m.connect = ep
 	case TRACE:
-		m.trace = endpoint
+		m.trace = ep
+	case MethodAny:
+		m.any = ep
+	default:
+		// WebDAV (PROPFIND/MKCOL/LOCK...) or custom method (PURGE...)
+		if m.others == nil {
+			m.others = map[string]*endpoint{}
+		}
+		m.others[method] = ep
 	}
+	m.updateAllowed()
+}
+
+// updateAllowed 更新“Allow”响应头的值(用于405响应)
+func (m *methodHandler) updateAllowed() {
+	allowed := make([]string, 0, len(methods)+len(m.others))
+	for _, method := range methods {
+		if m.findMethod(method) != nil {
+			allowed = append(allowed, method)
+		}
+	}
+	if len(m.others) > 0 {
+		others := make([]string, 0, len(m.others))
+		for method := range m.others {
+			others = append(others, method)
+		}
+		sort.Strings(others)
+		allowed = append(allowed, others...)
+	}
+	if len(allowed) == 0 {
+		m.allowHeader = ``
+		m.notAllowedHandler = nil
+		return
+	}
+	allowHeader := strings.Join(allowed, `, `)
+	m.allowHeader = allowHeader
+	m.notAllowedHandler = HandlerFunc(func(c Context) error {
+		c.Response().Header().Set(HeaderAllow, allowHeader)
+		return ErrMethodNotAllowed
+	})
+}
+
+// Allowed returns the value of the "Allow" header
+func (m *methodHandler) Allowed() string {
+	return m.allowHeader
 }
 
 func (m *methodHandler) findHandler(method string) Handler {
@@ -582,6 +730,14 @@ func (m *methodHandler) findHandler(method string) Handler {
 }
 
 func (m *methodHandler) find(method string) *endpoint {
+	if ep := m.findMethod(method); ep != nil {
+		return ep
+	}
+	return m.any
+}
+
+// findMethod 查找指定方法的 endpoint(不包括 MethodAny)
+func (m *methodHandler) findMethod(method string) *endpoint {
 	switch method {
 	case GET:
 		return m.get
@@ -602,15 +758,16 @@ func (m *methodHandler) find(method string) *endpoint {
 	case TRACE:
 		return m.trace
 	default:
-		return nil
+		if m.others == nil {
+			return nil
+		}
+		return m.others[method]
 	}
 }
 
-func (m *methodHandler) checkMethodNotAllowed() HandlerFunc {
-	for _, method := range methods {
-		if r := m.findHandler(method); r != nil {
-			return MethodNotAllowedHandler
-		}
+func (m *methodHandler) checkMethodNotAllowed() Handler {
+	if m.notAllowedHandler != nil {
+		return m.notAllowedHandler
 	}
 	return NotFoundHandler
 }
@@ -661,7 +818,7 @@ func (r *Router) Dispatch(c Context, path string, _method ...string) Handler {
 }
 
 // Add 添加路由
-// method: 方法(GET/POST/PUT/DELETE/PATCH/OPTIONS/HEAD/CONNECT/TRACE)
+// method: 方法(GET/POST/PUT/DELETE/PATCH/OPTIONS/HEAD/CONNECT/TRACE 或 PROPFIND/MKCOL/PURGE 等其它方法)
 // prefix: group前缀
 // path: 路径(含前缀)
 // h: Handler
@@ -672,9 +829,13 @@ func (r *Router) Add(rt *Route, rid int) {
 	ppath := path        // Pristine path
 	pnames := []string{} // Param names
 	uri := new(bytes.Buffer)
+	patterns := map[string]*regexp.Regexp{} // Param constraints
 	defer func() {
 		rt.Format = uri.String()
 		rt.Params = pnames
+		if len(patterns) > 0 {
+			rt.paramPatterns = patterns
+		}
 		//Dump(rt)
 	}()
 	for i, l := 0, len(path); i < l; i++ {
@@ -685,10 +846,36 @@ func (r *Router) Add(rt *Route, rid int) {
 			uri.WriteString(`%v`)
 			j := i + 1
 			r.insert(rt.Method, path[:i], nil, staticKind, "", nil, -1)
-			for ; i < l && path[i] != '/'; i++ {
+			for ; i < l && path[i] != '/' && path[i] != regexLabel; i++ {
 			}
 			pname := path[j:i]
 			pnames = append(pnames, pname)
+			if i < l && path[i] == regexLabel { // :name<constraint>
+				k := strings.IndexByte(path[i:], '>')
+				if k < 0 {
+					panic(`echo: invalid route param constraint: ` + ppath)
+				}
+				k += i
+				constraint := path[i+1 : k]
+				pattern, ok := r.echo.ParamConstraint(constraint)
+				if !ok {
+					panic(`echo: unknown route param constraint "` + constraint + `": ` + ppath)
+				}
+				patterns[pname] = regexp.MustCompile(`^(?:` + pattern + `)$`)
+				regExpr := `(` + pattern + `)`
+				segment := regexSegment(regExpr)
+				path = path[:j-1] + segment + path[k+1:]
+				i, l = j-1+len(segment), len(path)
+				if i == l {
+					r.insert(rt.Method, path[:i], rt.Handler, regexKind, ppath, pnames, rid, regExpr)
+				} else {
+					r.insert(rt.Method, path[:i], nil, regexKind, "", nil, -1, regExpr)
+				}
+				if i < l {
+					uri.WriteByte(path[i])
+				}
+				continue
+			}
 			path = path[:j] + path[i:]
 			i, l = j, len(path)
 
@@ -713,6 +900,7 @@ func (r *Router) Add(rt *Route, rid int) {
 			if len(parts) == 2 {
 				pname = parts[0]
 				regExpr = `(` + parts[1] + `)`
+				patterns[pname] = regexp.MustCompile(`^(?:` + parts[1] + `)$`)
 			} else {
 				regExpr = `([^/]+)`
 			}
@@ -720,12 +908,13 @@ func (r *Router) Add(rt *Route, rid int) {
 			if path[i] == '>' {
 				i++
 			}
+			segment := regexSegment(regExpr)
 			if len(path) > i {
-				path = path[:j] + path[i:]
+				path = path[:j-1] + segment + path[i:]
 			} else {
-				path = path[:j]
+				path = path[:j-1] + segment
 			}
-			i, l = j, len(path)
+			i, l = j-1+len(segment), len(path)
 
 			r.insert(rt.Method, path[:i], rt.Handler, regexKind, ppath, pnames, rid, regExpr)
 
@@ -753,13 +942,16 @@ func (r *Router) Add(rt *Route, rid int) {
 	r.insert(rt.Method, path, rt.Handler, staticKind, ppath, pnames, rid)
 }
 
+// regexSegment 正则参数在路由树路径中的表示。
+// 不同正则表达式的参数节点前缀不同，以便同一位置可以注册多个正则参数(例如 /user/:id<int> 和 /user/:u<uuid>)
+func regexSegment(regExpr string) string {
+	return string(regexLabel) + regExpr + `>`
+}
+
 func (r *Router) insert(method, path string, h Handler, t kind, ppath string, pnames []string, rid int, regExpr ...string) {
 	e := r.echo
 	// Adjust max param
-	paramLen := len(pnames)
-	if *e.maxParam < paramLen {
-		*e.maxParam = paramLen
-	}
+	e.growMaxParam(len(pnames))
 
 	currentNode := r.tree // Current node as root
 	if currentNode == nil {
@@ -803,7 +995,7 @@ func (r *Router) insert(method, path string, h Handler, t kind, ppath string, pn
 				currentNode.methodHandler,
 				currentNode.ppath,
 				currentNode.pnames,
-				currentNode.regexChild,
+				currentNode.regexChildren,
 				currentNode.paramChild,
 				currentNode.anyChild,
 				regExpr...,
@@ -812,6 +1004,9 @@ func (r *Router) insert(method, path string, h Handler, t kind, ppath string, pn
 			for _, child := range currentNode.staticChildren {
 				child.parent = n
 			}
+			for _, child := range currentNode.regexChildren {
+				child.parent = n
+			}
 			if currentNode.paramChild != nil {
 				currentNode.paramChild.parent = n
 			}
@@ -828,6 +1023,7 @@ func (r *Router) insert(method, path string, h Handler, t kind, ppath string, pn
 			currentNode.ppath = ""
 			currentNode.pnames = nil
 			currentNode.regExp = nil
+			currentNode.regexChildren = nil
 			currentNode.paramChild = nil
 			currentNode.anyChild = nil
 			currentNode.isLeaf = false
@@ -851,7 +1047,7 @@ func (r *Router) insert(method, path string, h Handler, t kind, ppath string, pn
 			currentNode.isLeaf = currentNode.IsLeaf()
 		} else if lcpLen < searchLen {
 			search = search[lcpLen:]
-			c := currentNode.findChildWithLabel(search[0])
+			c := currentNode.findChildWithLabel(search)
 			if c != nil {
 				// Go deeper
 				currentNode = c
@@ -864,7 +1060,7 @@ func (r *Router) insert(method, path string, h Handler, t kind, ppath string, pn
 			case staticKind:
 				currentNode.addStaticChild(n)
 			case regexKind:
-				currentNode.regexChild = n
+				currentNode.regexChildren = append(currentNode.regexChildren, n)
 			case paramKind:
 				currentNode.paramChild = n
 			case anyKind:
@@ -885,7 +1081,7 @@ func (r *Router) insert(method, path string, h Handler, t kind, ppath string, pn
 	}
 }
 
-func newNode(t kind, pre string, p *node, sc children, mh *methodHandler, ppath string, pnames []string, regexChildren, paramChildren, anyChildren *node, regExpr ...string) *node {
+func newNode(t kind, pre string, p *node, sc children, mh *methodHandler, ppath string, pnames []string, regexChildren children, paramChildren, anyChildren *node, regExpr ...string) *node {
 	n := &node{
 		kind:           t,
 		label:          pre[0],
@@ -895,7 +1091,7 @@ func newNode(t kind, pre string, p *node, sc children, mh *methodHandler, ppath
 		ppath:          ppath,
 		pnames:         pnames,
 		methodHandler:  mh,
-		regexChild:     regexChildren,
+		regexChildren:  regexChildren,
 		paramChild:     paramChildren,
 		anyChild:       anyChildren,
 		isHandler:      mh.isHandler(),
@@ -916,7 +1112,7 @@ func (n *node) String() string {
 }
 
 func (n *node) IsLeaf() bool {
-	return n.staticChildren == nil && n.regexChild == nil && n.paramChild == nil && n.anyChild == nil
+	return n.staticChildren == nil && n.regexChildren == nil && n.paramChild == nil && n.anyChild == nil
 }
 
 func (n *node) Tree() H {
@@ -924,18 +1120,18 @@ func (n *node) Tree() H {
 	for k, v := range n.staticChildren {
 		children[k] = v.Tree()
 	}
+	regexChildren := make([]H, len(n.regexChildren))
+	for k, v := range n.regexChildren {
+		regexChildren[k] = v.Tree()
+	}
 	var (
 		regExpr    string
-		regexChild H
 		paramChild H
 		anyChild   H
 	)
 	if n.regExp != nil {
 		regExpr = n.regExp.String()
 	}
-	if n.regexChild != nil {
-		regexChild = n.regexChild.Tree()
-	}
 	if n.paramChild != nil {
 		paramChild = n.paramChild.Tree()
 	}
@@ -952,7 +1148,7 @@ func (n *node) Tree() H {
 		"pnames":         n.pnames,
 		"methodHandler":  n.methodHandler.Map(),
 		"regExpr":        regExpr,
-		"regexChild":     regexChild,
+		"regexChildren":  regexChildren,
 		"paramChild":     paramChild,
 		"anyChild":       anyChild,
 		"isLeaf":         n.isLeaf,
@@ -973,14 +1169,20 @@ func (n *node) findStaticChild(l byte) *node {
 	return nil
 }
 
-func (n *node) findChildWithLabel(l byte) *node {
+func (n *node) findChildWithLabel(search string) *node {
+	l := search[0]
 	for _, c := range n.staticChildren {
 		if c.label == l {
 			return c
 		}
 	}
 	if l == regexLabel {
-		return n.regexChild
+		for _, c := range n.regexChildren {
+			if strings.HasPrefix(search, c.prefix) {
+				return c
+			}
+		}
+		return nil
 	}
 	if l == paramLabel {
 		return n.paramChild
@@ -991,24 +1193,33 @@ func (n *node) findChildWithLabel(l byte) *node {
 	return nil
 }
 
-func (n *node) findRegexChild(search string) (*node, []int) {
-	var matchIndex []int
-	c := n.regexChild
-	matchIndex = c.regExp.FindStringSubmatchIndex(search)
-	//fmt.Printf("%s => %s: %#v\n", search, c.regExp.String(), matchIndex)
-	if len(matchIndex) > 3 {
-		return c, matchIndex
+// findRegexChild 从第 from 个正则子节点开始查找匹配的节点
+func (n *node) findRegexChild(search string, from int) (*node, []int) {
+	for _, c := range n.regexChildren[from:] {
+		matchIndex := c.regExp.FindStringSubmatchIndex(search)
+		if len(matchIndex) > 3 {
+			return c, matchIndex
+		}
 	}
-	return nil, matchIndex
+	return nil, nil
+}
+
+// regexChildIndex 子节点在 regexChildren 中的位置
+func (n *node) regexChildIndex(c *node) int {
+	for i, v := range n.regexChildren {
+		if v == c {
+			return i
+		}
+	}
+	return -1
 }
 
 func (n *node) addHandler(method string, h Handler, rid int) {
-	n.methodHandler.addHandler(method, h, rid)
-	if h != nil {
-		n.isHandler = true
-	} else {
-		n.isHandler = n.methodHandler.isHandler()
+	if h == nil { // 插入路由时创建的中间节点，不能注册没有 handler 的 endpoint，否则会被当作匹配的路由
+		return
 	}
+	n.methodHandler.addHandler(method, h, rid)
+	n.isHandler = true
 }
 
 func (n *node) findHandler(method string) Handler {
@@ -1061,6 +1272,7 @@ func (r *Router) Find(method, path string, context Context) (found bool) {
 		paramIndex  int // Param counter
 		paramValues = context.ParamValues()
 		matchIndex  []int
+		regexFrom   int // 回溯时从下一个正则子节点开始匹配
 	)
 
 	// Backtracking is needed when a dead end (leaf node) is reached in the router tree.
@@ -1076,6 +1288,14 @@ func (r *Router) Find(method, path string, context Context) (found bool) {
 		// Next node type by priority
 		if previous.kind == anyKind {
 			nextNodeKind = staticKind
+		} else if previous.kind == regexKind && valid {
+			// 继续尝试同一位置的其它正则节点
+			if next := currentNode.regexChildIndex(previous) + 1; next > 0 && next < len(currentNode.regexChildren) {
+				nextNodeKind = regexKind
+				regexFrom = next
+			} else {
+				nextNodeKind = paramKind
+			}
 		} else {
 			nextNodeKind = previous.kind + 1
 		}
@@ -1168,8 +1388,10 @@ func (r *Router) Find(method, path string, context Context) (found bool) {
 
 	Regex:
 		// Regex node
-		if child := currentNode.regexChild; search != "" && child != nil {
-			child, matchIndex = currentNode.findRegexChild(search)
+		if search != "" && len(currentNode.regexChildren) > regexFrom {
+			var child *node
+			child, matchIndex = currentNode.findRegexChild(search, regexFrom)
+			regexFrom = 0
 			if child != nil {
 				currentNode = child
 				startIndex := matchIndex[2]
@@ -1183,6 +1405,8 @@ func (r *Router) Find(method, path string, context Context) (found bool) {
 			}
 		}
 
+		regexFrom = 0
+
 	Param:
 		// Param node
 		if child := currentNode.paramChild; search != "" && child != nil {
@@ -0,0 +1,351 @@
+package echo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type (
+	// RouteDiagnosticKind 路由诊断类型
+	RouteDiagnosticKind uint8
+
+	// RouteCheckMode 路由检查模式
+	RouteCheckMode uint8
+
+	// RouteDiagnostic 路由诊断信息
+	RouteDiagnostic struct {
+		Kind    RouteDiagnosticKind
+		Route   *Route // 有问题的路由
+		Other   *Route // 与之冲突的路由(可能为 nil)
+		Message string
+	}
+
+	// RouteDiagnostics 路由诊断结果
+	RouteDiagnostics []*RouteDiagnostic
+
+	routeToken struct {
+		kind    kind
+		value   string // static: 文本; param/regex: 参数名
+		pattern string // regex: 正则表达式
+	}
+)
+
+const (
+	// RouteDuplicate 相同的方法和路径被重复注册(前面注册的 handler 会被覆盖)
+	RouteDuplicate RouteDiagnosticKind = iota + 1
+	// RouteShadowed 路由被优先级更高的路由遮蔽
+	RouteShadowed
+	// RouteUnreachable 路由永远无法被匹配
+	RouteUnreachable
+	// RouteAmbiguous 同一位置(树中的同一节点)的参数名称不一致
+	RouteAmbiguous
+)
+
+const (
+	// RouteCheckOff 不检查
+	RouteCheckOff RouteCheckMode = iota
+	// RouteCheckWarn 检查并记录警告日志
+	RouteCheckWarn
+	// RouteCheckStrict 检查并在有问题时返回错误。只有 Echo.Build 和 Echo.RebuildRouterE 返回错误，Commit/RebuildRouter 等只记录错误日志
+	RouteCheckStrict
+)
+
+var routeDiagnosticKindNames = map[RouteDiagnosticKind]string{
+	RouteDuplicate:   `duplicate`,
+	RouteShadowed:    `shadowed`,
+	RouteUnreachable: `unreachable`,
+	RouteAmbiguous:   `ambiguous`,
+}
+
+func (k RouteDiagnosticKind) String() string {
+	if name, ok := routeDiagnosticKindNames[k]; ok {
+		return name
+	}
+	return `unknown`
+}
+
+func (d *RouteDiagnostic) String() string {
+	return fmt.Sprintf(`[%s] %s`, d.Kind, d.Message)
+}
+
+func (d *RouteDiagnostic) Error() string {
+	return d.String()
+}
+
+func (d RouteDiagnostics) Error() string {
+	messages := make([]string, len(d))
+	for i, v := range d {
+		messages[i] = v.String()
+	}
+	return `route conflicts:` + "\n" + strings.Join(messages, "\n")
+}
+
+// Filter 筛选指定类型的诊断信息
+func (d RouteDiagnostics) Filter(kinds ...RouteDiagnosticKind) RouteDiagnostics {
+	var r RouteDiagnostics
+	for _, v := range d {
+		for _, k := range kinds {
+			if v.Kind == k {
+				r = append(r, v)
+				break
+			}
+		}
+	}
+	return r
+}
+
+func routeLabel(r *Route) string {
+	label := r.Method + ` ` + r.Host + r.Path
+	if len(r.Name) > 0 {
+		label += ` (` + r.Name + `)`
+	}
+	return label
+}
+
+// parseRouteTokens 按照 Router.Add 的规则解析路由路径
+func parseRouteTokens(e *Echo, path string) []routeToken {
+	var tokens []routeToken
+	static := new(strings.Builder)
+	flush := func() {
+		if static.Len() > 0 {
+			tokens = append(tokens, routeToken{kind: staticKind, value: static.String()})
+			static.Reset()
+		}
+	}
+	for i, l := 0, len(path); i < l; i++ {
+		switch path[i] {
+		case paramLabel:
+			if i > 0 && path[i-1] == '\\' {
+				break
+			}
+			flush()
+			j := i + 1
+			for ; i < l && path[i] != '/' && path[i] != regexLabel; i++ {
+			}
+			token := routeToken{kind: paramKind, value: path[j:i]}
+			if i < l && path[i] == regexLabel {
+				k := strings.IndexByte(path[i:], '>')
+				if k < 0 {
+					k = l - i - 1
+				}
+				k += i
+				token.kind = regexKind
+				token.pattern, _ = e.ParamConstraint(path[i+1 : k])
+				i = k
+			} else {
+				i--
+			}
+			tokens = append(tokens, token)
+			continue
+		case regexLabel:
+			if i > 0 && path[i-1] == '\\' {
+				break
+			}
+			flush()
+			j := i + 1
+			for ; i < l && path[i] != '>'; i++ {
+			}
+			parts := strings.SplitN(path[j:i], `:`, 2)
+			token := routeToken{kind: regexKind, value: parts[0], pattern: `[^/]+`}
+			if len(parts) == 2 {
+				token.pattern = parts[1]
+			}
+			tokens = append(tokens, token)
+			continue
+		case anyLabel:
+			flush()
+			tokens = append(tokens, routeToken{kind: anyKind, value: `*`})
+			continue
+		}
+		static.WriteByte(path[i])
+	}
+	flush()
+	return tokens
+}
+
+func (t routeToken) equal(o routeToken) bool {
+	if t.kind != o.kind {
+		return false
+	}
+	switch t.kind {
+	case staticKind:
+		return t.value == o.value
+	case regexKind:
+		return t.pattern == o.pattern
+	default:
+		return true
+	}
+}
+
+func tokensEqual(a, b []routeToken) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	regexSegmentProbes = []string{`a`, `Z`, `0`, `a-b_c.d`, `%E4%B8%AD`}
+	regexSpanProbes    = []string{`a/b`, `a/b/c`}
+)
+
+// regexCatchAll 正则表达式是否能匹配任意路径段
+func regexCatchAll(pattern string, probes []string) bool {
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return false
+	}
+	for _, probe := range probes {
+		if !re.MatchString(probe) {
+			return false
+		}
+	}
+	return true
+}
+
+// AnalyzeRoutes 分析路由冲突
+func AnalyzeRoutes(e *Echo, routes []*Route) RouteDiagnostics {
+	var diagnostics RouteDiagnostics
+	ambiguous := map[string]struct{}{}
+	tokens := make([][]routeToken, len(routes))
+	for i, r := range routes {
+		tokens[i] = parseRouteTokens(e, r.Path)
+	}
+	for i, r := range routes {
+		if len(r.Path) > 0 && r.Path[0] != '/' {
+			diagnostics = append(diagnostics, &RouteDiagnostic{
+				Kind:    RouteUnreachable,
+				Route:   r,
+				Message: fmt.Sprintf(`%s: path must begin with "/"`, routeLabel(r)),
+			})
+		}
+		for k, t := range tokens[i] {
+			if t.kind == anyKind && k < len(tokens[i])-1 {
+				diagnostics = append(diagnostics, &RouteDiagnostic{
+					Kind:    RouteUnreachable,
+					Route:   r,
+					Message: fmt.Sprintf(`%s: "*" matches the rest of the path, the following part can never be matched`, routeLabel(r)),
+				})
+				break
+			}
+		}
+		for j := 0; j < i; j++ {
+			o := routes[j]
+			if o.Host != r.Host {
+				continue
+			}
+			if o.Method == r.Method && tokensEqual(tokens[i], tokens[j]) {
+				diagnostics = append(diagnostics, &RouteDiagnostic{
+					Kind:    RouteDuplicate,
+					Route:   r,
+					Other:   o,
+					Message: fmt.Sprintf(`%s overrides %s`, routeLabel(r), routeLabel(o)),
+				})
+				continue
+			}
+			if d, key := checkAmbiguous(r, o, tokens[i], tokens[j]); d != nil {
+				key = r.Host + `|` + key
+				if _, ok := ambiguous[key]; !ok {
+					ambiguous[key] = struct{}{}
+					diagnostics = append(diagnostics, d)
+				}
+			}
+			if o.Method != r.Method {
+				continue
+			}
+			if d := checkShadowed(o, r, tokens[j], tokens[i], tokens); d != nil {
+				diagnostics = append(diagnostics, d)
+			} else if d := checkShadowed(r, o, tokens[i], tokens[j], tokens); d != nil {
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+	return diagnostics
+}
+
+// checkAmbiguous 检查同一位置的参数(树中的同一节点)是否使用了不同的名称
+func checkAmbiguous(r, o *Route, a, b []routeToken) (*RouteDiagnostic, string) {
+	key := new(strings.Builder)
+	for k := 0; k < len(a) && k < len(b); k++ {
+		x, y := a[k], b[k]
+		if x.kind != y.kind {
+			return nil, ``
+		}
+		switch x.kind {
+		case staticKind:
+			if x.value != y.value {
+				return nil, ``
+			}
+			key.WriteString(x.value)
+		case regexKind:
+			if x.pattern != y.pattern {
+				// 不同的正则表达式位于不同的节点，按注册顺序匹配
+				return nil, ``
+			}
+			key.WriteString(`<` + x.pattern + `>`)
+			fallthrough
+		case paramKind:
+			if x.value != y.value {
+				key.WriteString(`:` + x.value + `|` + y.value)
+				return &RouteDiagnostic{
+					Kind:  RouteAmbiguous,
+					Route: r,
+					Other: o,
+					Message: fmt.Sprintf(`%s: param name %q differs from %q of %s at the same position`,
+						routeLabel(r), x.value, y.value, routeLabel(o)),
+				}, key.String()
+			}
+			key.WriteString(`:`)
+		case anyKind:
+			key.WriteString(`*`)
+		}
+	}
+	return nil, ``
+}
+
+// checkShadowed 检查路由 b 是否被优先级更高的路由 a 遮蔽
+func checkShadowed(a, b *Route, x, y []routeToken, all [][]routeToken) *RouteDiagnostic {
+	k := 0
+	for ; k < len(x) && k < len(y); k++ {
+		if !x[k].equal(y[k]) {
+			break
+		}
+	}
+	if k >= len(x) || k >= len(y) {
+		return nil
+	}
+	shadowed := false
+	switch {
+	case x[k].kind == regexKind && y[k].kind == paramKind:
+		if k == len(x)-1 && regexCatchAll(x[k].pattern, regexSpanProbes) {
+			// 正则可以匹配剩余的整个路径
+			shadowed = true
+		} else if regexCatchAll(x[k].pattern, regexSegmentProbes) && tokensEqual(x[k+1:], y[k+1:]) {
+			shadowed = true
+		}
+	case x[k].kind == paramKind && y[k].kind == anyKind && k == len(x)-1 && k == len(y)-1:
+		// 没有子节点的参数节点会匹配剩余的整个路径
+		shadowed = true
+		for _, t := range all {
+			if len(t) > k+1 && tokensEqual(t[:k+1], x) {
+				shadowed = false
+				break
+			}
+		}
+	}
+	if !shadowed {
+		return nil
+	}
+	return &RouteDiagnostic{
+		Kind:    RouteShadowed,
+		Route:   b,
+		Other:   a,
+		Message: fmt.Sprintf(`%s is shadowed by %s`, routeLabel(b), routeLabel(a)),
+	}
+}
@@ -0,0 +1,250 @@
+package echo
+
+import "strings"
+
+// RouteTable 路由表。
+// 路由表发布(Echo.PublishRouteTable)之后是只读的。请求在开始处理时获取当前路由表，
+// 因此替换路由表不会影响正在处理中的请求
+type RouteTable struct {
+	version uint64
+	router  *Router          // 默认路由(其中的 routes 为路由副本)
+	hosts   map[string]*Host // 主机路由
+	sources []*Route         // 构建路由表时使用的原始路由
+}
+
+// Version 路由表版本号(构建时分配，单调递增)
+func (t *RouteTable) Version() uint64 {
+	return t.version
+}
+
+// Router 默认路由
+func (t *RouteTable) Router() *Router {
+	return t.router
+}
+
+// HostRouter 获取指定主机的路由
+func (t *RouteTable) HostRouter(name string) *Router {
+	if h, ok := t.hosts[name]; ok {
+		return h.Router
+	}
+	return nil
+}
+
+// Routes 路由表中的路由
+func (t *RouteTable) Routes() []*Route {
+	return t.router.routes
+}
+
+// Diagnostics 构建路由表时的冲突检查结果
+func (t *RouteTable) Diagnostics() RouteDiagnostics {
+	return t.router.diagnostics
+}
+
+func (t *RouteTable) route(rid int) *Route {
+	if rid < 0 || rid >= len(t.router.routes) {
+		return nil
+	}
+	return t.router.routes[rid]
+}
+
+func (t *RouteTable) routeByName(name string) *Route {
+	if indexes, ok := t.router.nroute[name]; ok && len(indexes) > 0 {
+		return t.router.routes[indexes[0]]
+	}
+	return nil
+}
+
+func (t *RouteTable) findRouter(host string) (*Router, []string, []string, bool) {
+	if len(t.hosts) == 0 {
+		return t.router, nil, nil, false
+	}
+	if r, ok := t.hosts[host]; ok {
+		return r.Router, nil, nil, true
+	}
+	l := len(host)
+	for h, r := range t.hosts {
+		if r.group != nil && r.group.host != nil {
+			values, hasExpr := r.group.host.Match(host)
+			if hasExpr {
+				if len(values) > 0 {
+					return r.Router, r.group.host.names, values, true
+				}
+				continue
+			}
+		}
+		if l <= len(h) {
+			continue
+		}
+		if h[0] == '.' && strings.HasSuffix(host, h) { //.host(xxx.host)
+			return r.Router, nil, nil, true
+		}
+		if h[len(h)-1] == '.' && strings.HasPrefix(host, h) { //host.(host.xxx)
+			return r.Router, nil, nil, true
+		}
+	}
+	return t.router, nil, nil, false
+}
+
+func (r *Route) clone() *Route {
+	cp := *r
+	if r.Meta != nil {
+		cp.Meta = r.Meta.Clone()
+	}
+	return &cp
+}
+
+// sync 将路由副本构建时生成的数据同步到原始路由
+func (r *Route) sync(cp *Route) {
+	r.Handler = cp.Handler
+	r.Name = cp.Name
+	r.Format = cp.Format
+	r.Params = cp.Params
+	r.paramPatterns = cp.paramPatterns
+	if cp.Meta != nil {
+		r.Meta = cp.Meta.Clone()
+	}
+}
+
+// BuildRouteTable 根据路由列表构建新的路由表(包括通过 Echo.Host 添加的主机路由)。
+// 构建过程不影响当前正在使用的路由表，需要调用 PublishRouteTable 才会生效
+func (e *Echo) BuildRouteTable(routes []*Route) *RouteTable {
+	t := &RouteTable{
+		version: e.routeVersion.Add(1),
+		router:  NewRouter(e),
+		hosts:   make(map[string]*Host, len(e.hosts)),
+		sources: routes,
+	}
+	for name, h := range e.hosts {
+		t.hosts[name] = &Host{head: h.head, group: h.group, groups: h.groups, Router: NewRouter(e)}
+	}
+	clones := make([]*Route, len(routes))
+	for i, r := range routes {
+		cp := r.clone()
+		cp.apply(e)
+		router, _, _, _ := t.findRouter(cp.Host)
+		router.Add(cp, i)
+		if e.RouteDebug {
+			e.logger.Debugf(`Route: %7v %-30v -> %v`, cp.Method, cp.Host+cp.Format, cp.Name)
+		}
+		t.router.nroute[cp.Name] = append(t.router.nroute[cp.Name], i)
+		clones[i] = cp
+	}
+	t.router.routes = clones
+	if e.routeCheckMode != RouteCheckOff {
+		t.router.diagnostics = AnalyzeRoutes(e, clones)
+	}
+	return t
+}
+
+// PublishRouteTable 原子地替换当前路由表。
+// 正在处理中的请求会继续使用旧的路由表，新的请求使用新的路由表。
+// 路由表的版本号不大于当前路由表时(例如在此期间已经发布了之后构建的路由表)返回 ErrRouteTableOutdated
+func (e *Echo) PublishRouteTable(t *RouteTable) error {
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
+	return e.publishRouteTable(t)
+}
+
+// publishRouteTable 需要在持有 routeMu 时调用
+func (e *Echo) publishRouteTable(t *RouteTable) error {
+	if t.version <= e.table.Load().version {
+		return ErrRouteTableOutdated
+	}
+	for i, r := range t.sources {
+		r.sync(t.router.routes[i])
+	}
+	e.table.Store(t)
+	e.routesPending.Store(false)
+	e.router = &Router{
+		tree:        t.router.tree,
+		static:      t.router.static,
+		routes:      t.sources,
+		nroute:      t.router.nroute,
+		echo:        e,
+		diagnostics: t.router.diagnostics,
+	}
+	for name, h := range t.hosts {
+		if hs, ok := e.hosts[name]; ok {
+			hs.Router = h.Router
+		}
+	}
+	for _, d := range t.router.diagnostics {
+		if e.routeCheckMode == RouteCheckStrict {
+			e.logger.Error(`Route: `, d.String())
+		} else {
+			e.logger.Warn(`Route: `, d.String())
+		}
+	}
+	return nil
+}
+
+// commitPending 存在尚未发布到路由表中的路由时重新构建并发布路由表(用于挂载的 *Echo)
+func (e *Echo) commitPending() {
+	if !e.routesPending.Load() {
+		return
+	}
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
+	if !e.routesPending.Load() {
+		return
+	}
+	if err := e.publishRouteTable(e.BuildRouteTable(e.router.routes)); err != nil {
+		e.logger.Error(`Route: `, err)
+	}
+}
+
+// RouteTable 当前正在使用的路由表
+func (e *Echo) RouteTable() *RouteTable {
+	return e.table.Load()
+}
+
+// RemoveRoutes 删除符合条件的路由，并重新构建和发布路由表。返回删除的路由数量
+func (e *Echo) RemoveRoutes(match func(*Route) bool) int {
+	e.routeMu.Lock()
+	defer e.routeMu.Unlock()
+	routes := make([]*Route, 0, len(e.router.routes))
+	for _, r := range e.router.routes {
+		if !match(r) {
+			routes = append(routes, r)
+		}
+	}
+	n := len(e.router.routes) - len(routes)
+	if n > 0 {
+		if err := e.publishRouteTable(e.BuildRouteTable(routes)); err != nil {
+			e.logger.Error(`Route: `, err)
+			return 0
+		}
+	}
+	return n
+}
+
+// RemoveRoute 删除指定名称的路由
+func (e *Echo) RemoveRoute(names ...string) int {
+	return e.RemoveRoutes(func(r *Route) bool {
+		for _, name := range names {
+			if r.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RemoveGroup 删除指定前缀的路由组(包括子路由组)中的所有路由。
+// 主机路由(通过 Echo.Host 添加)中相同前缀的路由组也会被删除
+func (e *Echo) RemoveGroup(prefix string) int {
+	var groups []*Group
+	if g, ok := e.groups[prefix]; ok {
+		groups = append(groups, g)
+	}
+	for _, h := range e.hosts {
+		if g, ok := h.groups[prefix]; ok {
+			groups = append(groups, g)
+		}
+	}
+	var n int
+	for _, g := range groups {
+		n += g.Remove()
+	}
+	return n
+}
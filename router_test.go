@@ -166,3 +166,248 @@ func TestRouterMakeURI(t *testing.T) {
 	uri = r.MakeURI(New(), map[string]any{`id`: 2002})
 	assert.Equal(t, `/2002`, uri)
 }
+
+func TestRouterCustomMethod(t *testing.T) {
+	e := New()
+	r := NewRouter(e)
+	r.Add(&Route{
+		Method:  `PROPFIND`,
+		Path:    `/files/:name`,
+		Handler: h,
+	}, 0)
+	r.Add(&Route{
+		Method:  GET,
+		Path:    `/files/:name`,
+		Handler: h2,
+	}, 1)
+
+	ctx := e.NewContext(nil, nil)
+	found := r.Find(`PROPFIND`, `/files/a.txt`, ctx)
+	assert.True(t, found)
+	assert.Equal(t, fmt.Sprintf(`%p`, h), fmt.Sprintf(`%p`, ctx.(*XContext).handler))
+	assert.Equal(t, `a.txt`, ctx.Param(`name`))
+
+	ctx = e.NewContext(nil, nil)
+	found = r.Find(`MKCOL`, `/files/a.txt`, ctx)
+	assert.False(t, found)
+	assert.Equal(t, `GET, PROPFIND`, r.tree.paramChild.methodHandler.Allowed())
+}
+
+func TestRouterParamConstraint(t *testing.T) {
+	e := New()
+	r := NewRouter(e)
+	rtInt := &Route{
+		Method:  GET,
+		Path:    `/user/:id<int>`,
+		Handler: h,
+	}
+	r.Add(rtInt, 0)
+	r.Add(&Route{
+		Method:  GET,
+		Path:    `/user/:name`,
+		Handler: h2,
+	}, 1)
+	rtDate := &Route{
+		Method:  GET,
+		Path:    `/archive/:day<date>/list`,
+		Handler: h,
+	}
+	r.Add(rtDate, 2)
+	assert.Equal(t, `/user/%v`, rtInt.Format)
+	assert.Equal(t, []string{`id`}, rtInt.Params)
+	assert.Equal(t, `/archive/%v/list`, rtDate.Format)
+	assert.Equal(t, []string{`day`}, rtDate.Params)
+
+	ctx := e.NewContext(nil, nil)
+	found := r.Find(GET, `/user/123`, ctx)
+	assert.True(t, found)
+	assert.Equal(t, fmt.Sprintf(`%p`, h), fmt.Sprintf(`%p`, ctx.(*XContext).handler))
+	assert.Equal(t, `123`, ctx.Param(`id`))
+	assert.Equal(t, `/user/:id<int>`, ctx.Path())
+
+	// fall through to param route
+	ctx = e.NewContext(nil, nil)
+	found = r.Find(GET, `/user/abc`, ctx)
+	assert.True(t, found)
+	assert.Equal(t, fmt.Sprintf(`%p`, h2), fmt.Sprintf(`%p`, ctx.(*XContext).handler))
+	assert.Equal(t, `abc`, ctx.Param(`name`))
+
+	ctx = e.NewContext(nil, nil)
+	found = r.Find(GET, `/archive/2024-02-29/list`, ctx)
+	assert.True(t, found)
+	assert.Equal(t, `2024-02-29`, ctx.Param(`day`))
+
+	ctx = e.NewContext(nil, nil)
+	found = r.Find(GET, `/archive/2024-13-01/list`, ctx)
+	assert.False(t, found)
+
+	assert.Equal(t, `/user/100`, rtInt.MakeURI(e, 100))
+	assert.Equal(t, ``, rtInt.MakeURI(e, `abc`))
+	_, err := rtInt.BuildURI(e, H{`id`: `abc`})
+	assert.ErrorIs(t, err, ErrInvalidRouteParam)
+	uri, err := rtDate.BuildURI(e, url.Values{`day`: []string{`2024-01-31`}, `page`: []string{`2`}})
+	assert.NoError(t, err)
+	assert.Equal(t, `/archive/2024-01-31/list?page=2`, uri)
+
+	e.AddParamConstraint(`year`, `[0-9]{4}`)
+	rtYear := &Route{
+		Method:  GET,
+		Path:    `/year/:y<year>`,
+		Handler: h,
+	}
+	r.Add(rtYear, 3)
+	ctx = e.NewContext(nil, nil)
+	assert.True(t, r.Find(GET, `/year/2024`, ctx))
+	ctx = e.NewContext(nil, nil)
+	assert.False(t, r.Find(GET, `/year/24`, ctx))
+
+	assert.Panics(t, func() {
+		r.Add(&Route{Method: GET, Path: `/bad/:x<unknown>`, Handler: h}, 4)
+	})
+	assert.Panics(t, func() {
+		e.AddParamConstraint(`path`, `[a-z]+/[a-z]+`)
+	})
+	_, ok := e.ParamConstraint(`path`)
+	assert.False(t, ok)
+}
+
+func TestRouterParamConstraintSamePosition(t *testing.T) {
+	e := New()
+	r := NewRouter(e)
+	r.Add(&Route{Method: GET, Path: `/user/:id<int>`, Handler: h}, 0)
+	r.Add(&Route{Method: GET, Path: `/user/:u<uuid>`, Handler: h2}, 1)
+	r.Add(&Route{Method: GET, Path: `/user/:id<int>/posts`, Handler: h2}, 2)
+	r.Add(&Route{Method: GET, Path: `/user/:u<uuid>/posts`, Handler: h}, 3)
+
+	ctx := e.NewContext(nil, nil)
+	assert.True(t, r.Find(GET, `/user/12`, ctx))
+	assert.Equal(t, fmt.Sprintf(`%p`, h), fmt.Sprintf(`%p`, ctx.(*XContext).handler))
+	assert.Equal(t, `12`, ctx.Param(`id`))
+	assert.Equal(t, `/user/:id<int>`, ctx.Path())
+
+	const uuid = `3f2504e0-4f89-41d3-9a0c-0305e82c3301`
+	ctx = e.NewContext(nil, nil)
+	assert.True(t, r.Find(GET, `/user/`+uuid, ctx))
+	assert.Equal(t, fmt.Sprintf(`%p`, h2), fmt.Sprintf(`%p`, ctx.(*XContext).handler))
+	assert.Equal(t, uuid, ctx.Param(`u`))
+	assert.Equal(t, `/user/:u<uuid>`, ctx.Path())
+
+	ctx = e.NewContext(nil, nil)
+	assert.True(t, r.Find(GET, `/user/12/posts`, ctx))
+	assert.Equal(t, fmt.Sprintf(`%p`, h2), fmt.Sprintf(`%p`, ctx.(*XContext).handler))
+	assert.Equal(t, `12`, ctx.Param(`id`))
+
+	ctx = e.NewContext(nil, nil)
+	assert.True(t, r.Find(GET, `/user/`+uuid+`/posts`, ctx))
+	assert.Equal(t, fmt.Sprintf(`%p`, h), fmt.Sprintf(`%p`, ctx.(*XContext).handler))
+	assert.Equal(t, uuid, ctx.Param(`u`))
+
+	// 第一个正则匹配但后续路径不匹配时回溯到下一个正则节点
+	r.Add(&Route{Method: GET, Path: `/v/<a:[0-9a-f]+>/x`, Handler: h}, 4)
+	r.Add(&Route{Method: GET, Path: `/v/<b:[0-9]+>/y`, Handler: h2}, 5)
+	ctx = e.NewContext(nil, nil)
+	assert.True(t, r.Find(GET, `/v/123/y`, ctx))
+	assert.Equal(t, fmt.Sprintf(`%p`, h2), fmt.Sprintf(`%p`, ctx.(*XContext).handler))
+	assert.Equal(t, `123`, ctx.Param(`b`))
+
+	ctx = e.NewContext(nil, nil)
+	assert.False(t, r.Find(GET, `/user/abc`, ctx))
+}
+
+func TestRouteDiagnostics(t *testing.T) {
+	e := New()
+	e.SetRouteCheckMode(RouteCheckOff)
+	e.Get("/users/:id", h)
+	e.Get("/users/:uid", h)
+	e.Post("/users/:id", h)
+	e.Get("/users/:id/posts", h)
+	e.Get("/files/<path:.+>", h)
+	e.Get("/files/:name", h)
+	e.Get("/static/:file", h)
+	e.Get("/static/*", h)
+	e.Get("/assets/:file", h)
+	e.Get("/assets/:file/raw", h)
+	e.Get("/assets/*", h)
+	e.Get("/code/<id:[0-9]+>", h)
+	e.Get("/code/<id:[a-z]+>", h)
+	e.Get("/any/*/tail", h)
+	e.Get("noslash", h)
+	e.Get("/ok", h)
+	e.Commit()
+	assert.Empty(t, e.RouteDiagnostics())
+
+	diagnostics := AnalyzeRoutes(e, e.Routes())
+
+	dup := diagnostics.Filter(RouteDuplicate)
+	if assert.Len(t, dup, 1) {
+		assert.Equal(t, "/users/:uid", dup[0].Route.Path)
+		assert.Equal(t, "/users/:id", dup[0].Other.Path)
+	}
+
+	amb := diagnostics.Filter(RouteAmbiguous)
+	var paths []string
+	for _, d := range amb {
+		paths = append(paths, d.Route.Path, d.Other.Path)
+	}
+	assert.Contains(t, paths, "/users/:uid")
+	// 同一位置不同正则表达式的参数使用不同的节点，不冲突
+	assert.NotContains(t, paths, "/code/<id:[a-z]+>")
+
+	sha := diagnostics.Filter(RouteShadowed)
+	paths = paths[:0]
+	for _, d := range sha {
+		paths = append(paths, d.Route.Path)
+	}
+	assert.Contains(t, paths, "/files/:name")
+	assert.Contains(t, paths, "/static/*")
+	assert.NotContains(t, paths, "/assets/*")
+
+	unr := diagnostics.Filter(RouteUnreachable)
+	paths = paths[:0]
+	for _, d := range unr {
+		paths = append(paths, d.Route.Path)
+	}
+	assert.ElementsMatch(t, []string{"/any/*/tail", "noslash"}, paths)
+
+	for _, d := range diagnostics {
+		if d.Route.Path == "/ok" || (d.Other != nil && d.Other.Path == "/ok") {
+			t.Errorf("unexpected diagnostic: %s", d)
+		}
+	}
+}
+
+func TestRouteCheckStrict(t *testing.T) {
+	e := New()
+	assert.Equal(t, RouteCheckOff, e.RouteCheckMode())
+	e.Get("/users/:id", h)
+	e.Get("/users/:id", h2)
+	e.Commit()
+	assert.Empty(t, e.RouteDiagnostics())
+
+	e.SetRouteCheckMode(RouteCheckWarn)
+	e.Commit()
+	assert.Len(t, e.RouteDiagnostics(), 1)
+	assert.NoError(t, e.Build())
+
+	e.SetRouteCheckMode(RouteCheckStrict)
+	err := e.Build()
+	if assert.Error(t, err) {
+		diagnostics, ok := err.(RouteDiagnostics)
+		assert.True(t, ok)
+		assert.Equal(t, RouteDuplicate, diagnostics[0].Kind)
+		assert.Contains(t, err.Error(), "[duplicate] GET /users/:id")
+	}
+
+	// Commit 和 RebuildRouter 只记录日志，RebuildRouterE 返回错误
+	e.Commit()
+	assert.Len(t, e.RouteDiagnostics(), 1)
+	err = e.RebuildRouterE()
+	assert.IsType(t, RouteDiagnostics{}, err)
+	assert.NoError(t, e.RebuildRouterE(e.Routes()[:1]))
+
+	e = New()
+	e.SetRouteCheckMode(RouteCheckStrict)
+	e.Get("/users/:id", h)
+	e.Get("/users/:id/posts", h)
+	assert.NoError(t, e.Build())
+}
@@ -117,23 +117,29 @@ func (v *NopValidation) Validate(_ any, _ ...any) ValidateResult {
 
 func NewValidation() Validator {
 	return &Validation{
-		validator: validation.New(),
+		fieldNameFormatter: DefaultFieldNameFormatter,
 	}
 }
 
 type Validation struct {
-	validator *validation.Validation
+	fieldNameFormatter FieldNameFormatter
+}
+
+// SetFieldNameFormatter 设置校验错误中字段路径的格式化函数(默认为 DefaultFieldNameFormatter，与 StructToForm 生成的表单字段名一致)
+func (v *Validation) SetFieldNameFormatter(formatter FieldNameFormatter) *Validation {
+	v.fieldNameFormatter = formatter
+	return v
 }
 
 // Validate 此处支持两种用法：
 // 1. Validate(表单字段名, 表单值, 验证规则名)
 // 2. Validate(结构体实例, 要验证的结构体字段1，要验证的结构体字段2)
-// Validate(结构体实例) 代表验证所有带“valid”标签的字段
+// Validate(结构体实例) 代表验证所有带“valid”标签的字段。
+// 验证结构体时返回 *ValidationErrors，包含所有校验失败的字段(含切片、数组和 map 中的结构体元素)
 func (v *Validation) Validate(i any, args ...any) ValidateResult {
-	e := NewValidateResult()
-	var err error
 	switch m := i.(type) {
 	case string:
+		e := NewValidateResult()
 		field := m
 		var value any
 		var rule string
@@ -147,21 +153,27 @@ func (v *Validation) Validate(i any, args ...any) ValidateResult {
 		if len(rule) == 0 {
 			return e
 		}
-		_, err = v.validator.ValidSimple(field, value, rule)
+		validator := validation.New()
+		if _, err := validator.ValidSimple(field, value, rule); err != nil {
+			return e.SetError(err)
+		}
+		if validator.HasError() {
+			vErr := validator.Errors[0].WithField()
+			e.SetError(vErr)
+			e.SetField(vErr.Field)
+			e.SetRaw(validator.Errors)
+		}
+		return e
 	default:
-		_, err = v.validator.Valid(i, InterfacesToStrings(args)...)
-	}
-	if err != nil {
-		return e.SetError(err)
-	}
-	if v.validator.HasError() {
-		vErr := v.validator.Errors[0].WithField()
-		e.SetError(vErr)
-		e.SetField(vErr.Field)
-		e.SetRaw(v.validator.Errors)
-		v.validator.Errors = nil
+		if v.fieldNameFormatter == nil {
+			v.fieldNameFormatter = DefaultFieldNameFormatter
+		}
+		errs := NewValidationErrors()
+		if err := v.validateStruct(errs, i, ``, InterfacesToStrings(args)); err != nil {
+			return NewValidateResult().SetError(err)
+		}
+		return errs
 	}
-	return e
 }
 
 func InterfacesToStrings(args []any) []string {
@@ -201,6 +213,9 @@ func ValidateStruct(c Context, item any, args ...any) error {
 	}
 	result := c.Validator().Validate(item, args...)
 	if err := result.Unwrap(); err != nil {
+		if errs, ok := result.(*ValidationErrors); ok {
+			errs.Translate(c)
+		}
 		return result
 	}
 	if after, ok := item.(AfterValidate); ok {
@@ -215,6 +230,8 @@ func DetectError(c Context, err error) error {
 	switch ve := err.(type) {
 	case *Error:
 		return ve
+	case *ValidationErrors:
+		return NewErrorWith(err, ve.Error(), code.InvalidParameter).NoClone().SetZone(ve.Field())
 	case ValidateResult:
 		return c.NewError(code.InvalidParameter, ve.Error()).SetError(err).SetZone(ve.Field())
 	case nil:
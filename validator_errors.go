@@ -0,0 +1,291 @@
+package echo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/webx-top/tagfast"
+	"github.com/webx-top/validation"
+)
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`                       // 表单字段路径。例如：Profile.Addresses[2].Zip
+	Rule    string `json:"rule,omitempty" xml:"rule,omitempty"`     // 校验规则名称。例如：Required
+	Params  []any  `json:"params,omitempty" xml:"params,omitempty"` // 校验规则参数
+	Message string `json:"message" xml:"message"`
+	Value   any    `json:"-" xml:"-"`
+	tmpl    string // 默认错误信息的模板(自定义错误信息时为空)
+}
+
+func (f *FieldError) Error() string {
+	return f.Field + `: ` + f.Message
+}
+
+// NewValidationErrors 创建聚合的校验结果
+func NewValidationErrors(errs ...*FieldError) *ValidationErrors {
+	return &ValidationErrors{errors: errs}
+}
+
+// ValidationErrors 聚合的校验结果，包含所有校验失败的字段。
+// 实现了 ValidateResult 接口(Error()、Field() 等对应第一个错误)
+type ValidationErrors struct {
+	errors []*FieldError
+	raw    any
+}
+
+func (v *ValidationErrors) first() *FieldError {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors[0]
+}
+
+func (v *ValidationErrors) Ok() bool {
+	return len(v.errors) == 0
+}
+
+func (v *ValidationErrors) Error() string {
+	if f := v.first(); f != nil {
+		return f.Error()
+	}
+	return ``
+}
+
+func (v *ValidationErrors) Unwrap() error {
+	if f := v.first(); f != nil {
+		return f
+	}
+	return nil
+}
+
+func (v *ValidationErrors) Field() string {
+	if f := v.first(); f != nil {
+		return f.Field
+	}
+	return ``
+}
+
+func (v *ValidationErrors) Raw() any {
+	return v.raw
+}
+
+func (v *ValidationErrors) SetError(err error) ValidateResult {
+	if err == nil {
+		v.errors = nil
+		return v
+	}
+	if fe, ok := err.(*FieldError); ok {
+		v.errors = append([]*FieldError{fe}, v.errors...)
+		return v
+	}
+	if f := v.first(); f != nil {
+		f.Message = err.Error()
+		f.tmpl = ``
+	} else {
+		v.errors = []*FieldError{{Message: err.Error()}}
+	}
+	return v
+}
+
+func (v *ValidationErrors) SetField(field string) ValidateResult {
+	if f := v.first(); f != nil {
+		f.Field = field
+	} else {
+		v.errors = []*FieldError{{Field: field}}
+	}
+	return v
+}
+
+func (v *ValidationErrors) SetRaw(raw any) ValidateResult {
+	v.raw = raw
+	return v
+}
+
+func (v *ValidationErrors) AsError() error {
+	if v.Ok() {
+		return nil
+	}
+	return v
+}
+
+// Add 添加字段错误
+func (v *ValidationErrors) Add(errs ...*FieldError) *ValidationErrors {
+	v.errors = append(v.errors, errs...)
+	return v
+}
+
+// Errors 所有字段错误
+func (v *ValidationErrors) Errors() []*FieldError {
+	return v.errors
+}
+
+// Map 字段路径与错误信息的映射(每个字段只保留第一个错误)
+func (v *ValidationErrors) Map() map[string]string {
+	r := make(map[string]string, len(v.errors))
+	for _, f := range v.errors {
+		if _, ok := r[f.Field]; !ok {
+			r[f.Field] = f.Message
+		}
+	}
+	return r
+}
+
+// InvalidParams 用于 RFC 9457 问题详情
+func (v *ValidationErrors) InvalidParams() []*InvalidParam {
+	r := make([]*InvalidParam, len(v.errors))
+	for i, f := range v.errors {
+		r[i] = &InvalidParam{Name: f.Field, Reason: f.Message}
+	}
+	return r
+}
+
+// Translate 翻译错误信息
+func (v *ValidationErrors) Translate(t Translator) *ValidationErrors {
+	for _, f := range v.errors {
+		if len(f.tmpl) > 0 {
+			f.Message = t.T(f.tmpl, f.Params...)
+		} else if len(f.Message) > 0 {
+			f.Message = t.T(f.Message)
+		}
+	}
+	return v
+}
+
+func sprintf(format string, args ...any) string {
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// validateStruct 校验结构体(包括切片、数组和 map 中的结构体)，并将错误添加到 errs
+func (v *Validation) validateStruct(errs *ValidationErrors, i any, topName string, fields []string) error {
+	validator := validation.New()
+	if _, err := validator.Valid(i, fields...); err != nil {
+		return err
+	}
+	for _, ve := range validator.Errors {
+		errs.Add(v.fieldError(topName, ve))
+	}
+	var only map[string]struct{}
+	if len(fields) > 0 {
+		only = map[string]struct{}{}
+		for _, field := range fields {
+			only[strings.SplitN(field, `.`, 2)[0]] = struct{}{}
+		}
+	}
+	return v.validateElements(errs, reflect.ValueOf(i), topName, only)
+}
+
+func (v *Validation) validateElements(errs *ValidationErrors, rv reflect.Value, topName string, only map[string]struct{}) error {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	for i, l := 0, rt.NumField(); i < l; i++ {
+		f := rt.Field(i)
+		if !f.IsExported() || tagfast.Value(rt, f, validation.VALIDTAG) == `-` {
+			continue
+		}
+		if only != nil {
+			if _, ok := only[f.Name]; !ok {
+				continue
+			}
+		}
+		fv := rv.Field(i)
+		name := v.fieldNameFormatter(topName, f.Name)
+		var err error
+		switch fv.Kind() {
+		case reflect.Struct, reflect.Pointer:
+			err = v.validateElements(errs, fv, name, nil)
+		case reflect.Slice, reflect.Array:
+			for j, n := 0, fv.Len(); j < n && err == nil; j++ {
+				err = v.validateElement(errs, fv.Index(j), name+`[`+strconv.Itoa(j)+`]`)
+			}
+		case reflect.Map:
+			keys := fv.MapKeys()
+			sort.Slice(keys, func(a, b int) bool {
+				return fmt.Sprint(keys[a].Interface()) < fmt.Sprint(keys[b].Interface())
+			})
+			for _, key := range keys {
+				if err = v.validateElement(errs, fv.MapIndex(key), name+`[`+fmt.Sprint(key.Interface())+`]`); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Validation) validateElement(errs *ValidationErrors, ev reflect.Value, name string) error {
+	for ev.Kind() == reflect.Interface {
+		if ev.IsNil() {
+			return nil
+		}
+		ev = ev.Elem()
+	}
+	switch ev.Kind() {
+	case reflect.Pointer:
+		if ev.IsNil() || ev.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+	case reflect.Struct:
+		if ev.CanAddr() {
+			ev = ev.Addr()
+		}
+	default:
+		return nil
+	}
+	return v.validateStruct(errs, ev.Interface(), name, nil)
+}
+
+// fieldError 转换校验错误。字段名称(例如：Profile.Zip)会经过 fieldNameFormatter 转换为表单字段路径
+func (v *Validation) fieldError(topName string, ve *validation.ValidationError) *FieldError {
+	name := topName
+	for _, part := range strings.Split(ve.Field, `.`) {
+		name = v.fieldNameFormatter(name, part)
+	}
+	fe := &FieldError{
+		Field:   name,
+		Rule:    ve.Name,
+		Message: ve.Message,
+		Value:   ve.Value,
+	}
+	switch limit := ve.LimitValue.(type) {
+	case nil:
+	case []float64:
+		for _, p := range limit {
+			fe.Params = append(fe.Params, p)
+		}
+	case []any:
+		fe.Params = limit
+	default:
+		fe.Params = []any{limit}
+	}
+	if len(ve.Tmpl) == 0 || ve.Message != sprintf(ve.Tmpl, fe.Params...) {
+		// 自定义的错误信息
+		return fe
+	}
+	// 整数形式的参数(例如：Min(18))转换为 int64，以便模板中的 %d 能够正确输出
+	for i, p := range fe.Params {
+		if f, ok := p.(float64); ok && f == float64(int64(f)) {
+			fe.Params[i] = int64(f)
+		}
+	}
+	fe.tmpl = ve.Tmpl
+	fe.Message = sprintf(fe.tmpl, fe.Params...)
+	return fe
+}
@@ -79,6 +79,9 @@ var (
 	ErrRendererNotRegistered              = errors.New("renderer not registered")
 	ErrInvalidRedirectCode                = errors.New("invalid redirect status code")
 	ErrNotFoundFileInput                  = errors.New("the specified name file input was not found")
+	ErrInvalidRouteParam                  = errors.New("invalid route param")
+	ErrRouteTableOutdated                 = errors.New("route table is not newer than the current one")
+	ErrUnsupportedRenderData              = errors.New("unsupported render data")
 
 	//----------------
 	// Error handlers
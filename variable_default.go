@@ -34,9 +34,10 @@ import (
 var (
 	DefaultAcceptFormats = map[string]string{
 		//json
-		MIMEApplicationJSON:       ContentTypeJSON,
-		`text/javascript`:         ContentTypeJSON,
-		MIMEApplicationJavaScript: ContentTypeJSON,
+		MIMEApplicationJSON:        ContentTypeJSON,
+		MIMEApplicationProblemJSON: ContentTypeJSON,
+		`text/javascript`:          ContentTypeJSON,
+		MIMEApplicationJavaScript:  ContentTypeJSON,
 
 		//xml
 		MIMEApplicationXML: ContentTypeXML,
@@ -82,7 +83,7 @@ var (
 				case *json.SyntaxError:
 					return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: offset=%v, error=%v", ev.Offset, ev.Error())).SetRaw(err)
 				}
-				return NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+				return bodyReadError(err)
 			}
 			return err
 		},
@@ -100,7 +101,7 @@ var (
 				case *xml.SyntaxError:
 					return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: line=%v, error=%v", ev.Line, ev.Error())).SetRaw(err)
 				}
-				return NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+				return bodyReadError(err)
 			}
 			return err
 		},
@@ -141,6 +142,18 @@ var (
 	DefaultUploadURLGenerator = func(ctx Context, subdir string, values ...any) string {
 		return subdir
 	}
+	// DefaultParamConstraints 路由参数约束(用法: /user/:id<int>)
+	DefaultParamConstraints = map[string]string{
+		`int`:   `-?[0-9]+`,
+		`uint`:  `[0-9]+`,
+		`alpha`: `[a-zA-Z]+`,
+		`alnum`: `[a-zA-Z0-9]+`,
+		`slug`:  `[a-z0-9]+(?:-[a-z0-9]+)*`,
+		`uuid`:  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+		`date`:  `[0-9]{4}-(?:0[1-9]|1[0-2])-(?:0[1-9]|[12][0-9]|3[01])`,
+	}
+	// DefaultProblemConfig 默认的问题详情(RFC 9457)配置
+	DefaultProblemConfig = NewProblemConfig()
 )
 
 func binderValueDecoderSplitKVRows(field string, values []string, seperator string) (any, error) {
@@ -140,7 +140,7 @@ func WrapMiddlewareFromStdHandler(h http.Handler) Middleware {
 				c.Response().StdResponseWriter(),
 				c.Request().StdRequest().WithContext(AsStdContext(c)),
 			)
-			if c.Response().Committed() {
+			if resp := c.Response(); resp.Committed() || resp.Size() > 0 {
 				return nil
 			}
 			return next.Handle(c)
@@ -156,7 +156,7 @@ func WrapMiddlewareFromStdHandleFunc(h func(http.ResponseWriter, *http.Request))
 				c.Response().StdResponseWriter(),
 				c.Request().StdRequest().WithContext(AsStdContext(c)),
 			)
-			if c.Response().Committed() {
+			if resp := c.Response(); resp.Committed() || resp.Size() > 0 {
 				return nil
 			}
 			return next.Handle(c)
@@ -174,7 +174,7 @@ func WrapMiddlewareFromStdHandleFuncd(h func(http.ResponseWriter, *http.Request)
 			); err != nil {
 				return err
 			}
-			if c.Response().Committed() {
+			if resp := c.Response(); resp.Committed() || resp.Size() > 0 {
 				return nil
 			}
 			return next.Handle(c)